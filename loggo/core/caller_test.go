@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// here — "file.go:line" строки на delta ниже той, откуда вызван here.
+func here(delta int) string {
+	_, _, line, _ := runtime.Caller(1)
+	return "caller_test.go:" + strconv.Itoa(line+delta)
+}
+
+func callerOf(t *testing.T, l *Logger, w *memWriter) string {
+	t.Helper()
+	l.Flush()
+	recs := w.Records()
+	if len(recs) == 0 {
+		t.Fatal("no records")
+	}
+	return recs[len(recs)-1].Caller
+}
+
+func TestCallerNamesEntryPointCaller(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	defer l.Close()
+	l.EnableCaller(true, true)
+	const fn = "core.TestCallerNamesEntryPointCaller"
+
+	var loc string
+	l.Log(info("log"))
+	loc = here(-1)
+	if got, want := callerOf(t, l, w), fn+" ("+loc+")"; got != want {
+		t.Errorf("Log caller = %q, want %q", got, want)
+	}
+	l.TryLog(info("try"))
+	loc = here(-1)
+	if got, want := callerOf(t, l, w), fn+" ("+loc+")"; got != want {
+		t.Errorf("TryLog caller = %q, want %q", got, want)
+	}
+	l.LogAt(time.Now(), Info, "at", nil)
+	loc = here(-1)
+	if got, want := callerOf(t, l, w), fn+" ("+loc+")"; got != want {
+		t.Errorf("LogAt caller = %q, want %q", got, want)
+	}
+	l.LogContext(context.Background(), info("ctx"))
+	loc = here(-1)
+	if got, want := callerOf(t, l, w), fn+" ("+loc+")"; got != want {
+		t.Errorf("LogContext caller = %q, want %q", got, want)
+	}
+}
+
+func TestCallerOfRecoveredPanic(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	defer l.Close()
+	l.EnableCaller(true, true)
+
+	var loc string
+	func() {
+		defer l.Recover()
+		loc = here(1)
+		panic("boom")
+	}()
+	want := "core.TestCallerOfRecoveredPanic.func1 (" + loc + ")"
+	if got := callerOf(t, l, w); got != want {
+		t.Errorf("Recover caller = %q, want %q", got, want)
+	}
+
+	func() {
+		defer l.Recover()
+		var m map[string]int
+		loc = here(1)
+		m["x"] = 1
+	}()
+	want = "core.TestCallerOfRecoveredPanic.func2 (" + loc + ")"
+	if got := callerOf(t, l, w); got != want {
+		t.Errorf("Recover caller of runtime panic = %q, want %q", got, want)
+	}
+}
+
+func TestCallerModes(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	defer l.Close()
+
+	l.Log(info("off"))
+	if got := callerOf(t, l, w); got != "" {
+		t.Errorf("caller by default = %q, want empty", got)
+	}
+
+	l.EnableCaller(true, false)
+	var loc string
+	l.Log(info("line"))
+	loc = here(-1)
+	if got := callerOf(t, l, w); got != loc {
+		t.Errorf("line-only caller = %q, want %q", got, loc)
+	}
+
+	l.Log(LogRecordRaw{Level: Info, Caller: "host.py:7"})
+	if got := callerOf(t, l, w); got != "host.py:7" {
+		t.Errorf("host caller = %q, want host.py:7", got)
+	}
+
+	l.EnableCaller(false, false)
+	l.Log(info("off again"))
+	if got := callerOf(t, l, w); got != "" {
+		t.Errorf("caller after disable = %q, want empty", got)
+	}
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+// tsFormatter выводит время записи и сообщение.
+type tsFormatter struct{}
+
+func (tsFormatter) Format(r LogRecord) ([]byte, error) {
+	return []byte(r.Timestamp.Format(time.RFC3339Nano) + " " + r.Message), nil
+}
+
+func TestSetClockFixesTimestamp(t *testing.T) {
+	fixed := time.Date(2025, 8, 14, 10, 0, 0, 123000000, time.UTC)
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(tsFormatter{}, w, Info))
+	l.SetClock(func() time.Time { return fixed })
+	l.Log(info("a"))
+	l.TryLog(info("b"))
+	l.SetClock(nil)
+	before := time.Now()
+	l.Log(info("c"))
+	l.Close()
+
+	lines := w.Lines()
+	if len(lines) != 3 {
+		t.Fatalf("lines %q", lines)
+	}
+	for i, want := range []string{"2025-08-14T10:00:00.123Z a", "2025-08-14T10:00:00.123Z b"} {
+		if lines[i] != want {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want)
+		}
+	}
+	if ts := w.Records()[2].Timestamp; ts.Before(before) {
+		t.Errorf("after SetClock(nil): %v", ts)
+	}
+}
+
+func TestLogAtKeepsProvidedTimestamp(t *testing.T) {
+	clock := time.Date(2025, 8, 14, 10, 0, 0, 0, time.UTC)
+	past := time.Date(2019, 3, 1, 12, 30, 45, 500000000, time.UTC)
+	w := &memWriter{}
+	l := NewLogger(NewSyncRouteProcessor(tsFormatter{}, w, Info))
+	l.SetClock(func() time.Time { return clock })
+
+	l.LogAt(past, Info, "imported", map[string]string{"src": "archive"})
+	l.LogAt(time.Time{}, Info, "now", nil) // нулевое время — часы логгера
+	l.LogAt(past, Debug, "filtered", nil)
+	l.Close()
+
+	want := []string{"2019-03-01T12:30:45.5Z imported", "2025-08-14T10:00:00Z now"}
+	lines := w.Lines()
+	if len(lines) != len(want) {
+		t.Fatalf("lines %q", lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+	if f := w.Records()[0].Fields; f["src"] != "archive" {
+		t.Errorf("fields %v", f)
+	}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"sync"
+	"testing"
+)
+
+// Enqueue и Close из разных горутин: без паники (send on closed channel) и без
+// потерь — всё, что принято до Close, записано.
+func TestEnqueueCloseRace(t *testing.T) {
+	for iter := 0; iter < 20; iter++ {
+		w := &memWriter{}
+		r := NewRouteProcessor(lineFormatter{}, w, Info)
+		l := NewLogger(r)
+
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				for i := 0; i < 200; i++ {
+					r.Enqueue(info("x"))
+				}
+			}()
+		}
+		for c := 0; c < 3; c++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				l.Close()
+			}()
+		}
+		close(start)
+		wg.Wait()
+		r.Enqueue(info("after close")) // молча отбрасывается
+
+		if got, want := uint64(len(w.Lines())), r.stats.enqueued.Load(); got != want {
+			t.Fatalf("iteration %d: wrote %d of %d accepted records", iter, got, want)
+		}
+	}
+}
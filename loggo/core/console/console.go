@@ -0,0 +1,47 @@
+package console
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"funchooooza-ossh/loggo/core/formatter"
+	"funchooooza-ossh/loggo/core/writer"
+	"os"
+)
+
+// NewConsoleLogger собирает готовый логгер в stdout: для терминала — цветной
+// TextFormatter с коротким временем, при перенаправлении в файл/пайп — без цветов
+// и с полной датой.
+func NewConsoleLogger(level core.LogLevel) *core.Logger {
+	return newConsoleLogger(os.Stdout, level)
+}
+
+func newConsoleLogger(out *os.File, level core.LogLevel) *core.Logger {
+	route := core.NewRouteProcessor(newConsoleFormatter(isTerminal(out)), writer.NewStdoutWriter(), level)
+	return core.NewLogger(route)
+}
+
+func newConsoleFormatter(tty bool) *formatter.TextFormatter {
+	if !tty {
+		return formatter.NewTextFormatter(nil, nil)
+	}
+	style := &core.FormatStyle{
+		ColorKeys:   true,
+		ColorValues: false,
+		ColorLevel:  true,
+		KeyColor:    "\033[36m", // голубой
+		ValueColor:  "\033[37m",
+		Reset:       "\033[0m",
+	}
+	f := formatter.NewTextFormatter(style, nil)
+	// в терминале дата обычно не нужна
+	f.ShortTimestamp = true
+	return f
+}
+
+// isTerminal сообщает, что out — символьное устройство (TTY), а не файл или пайп.
+func isTerminal(out *os.File) bool {
+	info, err := out.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
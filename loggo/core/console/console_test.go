@@ -0,0 +1,59 @@
+package console
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConsoleFormatterByOutput(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "out.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	// /dev/null — символьное устройство, как терминал
+	dev, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("no %s: %v", os.DevNull, err)
+	}
+	defer dev.Close()
+
+	if isTerminal(file) {
+		t.Error("regular file detected as terminal")
+	}
+	if !isTerminal(dev) {
+		t.Errorf("%s not detected as character device", os.DevNull)
+	}
+
+	r := core.LogRecord{
+		Level: core.Info, Timestamp: time.Date(2025, 8, 14, 10, 0, 0, 0, time.UTC),
+		Message: "m", Fields: map[string]any{"k": 1},
+	}
+	plain, err := newConsoleFormatter(isTerminal(file)).Format(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(plain), "\033[") || !strings.Contains(string(plain), "2025-08-14") {
+		t.Errorf("file output: %q", plain)
+	}
+	tty, err := newConsoleFormatter(isTerminal(dev)).Format(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(tty), "\033[") || strings.Contains(string(tty), "2025-08-14") || !strings.Contains(string(tty), "10:00:00.000") {
+		t.Errorf("terminal output: %q", tty)
+	}
+
+	// логгер собирается для обоих вариантов
+	for _, out := range []*os.File{file, dev} {
+		l := newConsoleLogger(out, core.Warning)
+		if l.Enabled(core.Info) || !l.Enabled(core.Warning) {
+			t.Errorf("level threshold not applied")
+		}
+		l.Close()
+	}
+}
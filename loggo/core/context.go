@@ -0,0 +1,78 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// ContextExtractor достаёт из контекста поля для записи (trace_id, user_id, ...).
+type ContextExtractor func(ctx context.Context) map[string]string
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor добавляет экстрактор, который LogContext применяет
+// к каждой записи. Регистрировать при старте, до логирования.
+func RegisterContextExtractor(e ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, e)
+}
+
+// LogContext — как Log, но дополняет поля записи значениями из зарегистрированных
+// экстракторов. Поля, уже переданные вызывающим, не перезаписываются.
+func (l *Logger) LogContext(ctx context.Context, record LogRecordRaw) {
+	if !l.AnyRouteShouldLog(record.Level) {
+		// экстракторы не нужны, но уровень учитывается, как в Log
+		l.emit(record, 1, false)
+		return
+	}
+
+	extractorsMu.RLock()
+	exs := extractors
+	extractorsMu.RUnlock()
+
+	for _, e := range exs {
+		for k, v := range e(ctx) {
+			if !hasRawField(record.Fields, k) {
+				record.Fields = appendRawField(record.Fields, k, v)
+			}
+		}
+	}
+	l.emit(record, 1, false)
+}
+
+// appendRawField дописывает пару key\0value\0 к сырым полям записи.
+func appendRawField(raw []byte, key, value string) []byte {
+	if len(raw) > 0 && raw[len(raw)-1] != 0 {
+		// не пары key\0value\0 (например, заглушка "0" без полей) — начинаем заново
+		raw = nil
+	} else {
+		// не дописываем в чужой буфер
+		raw = raw[:len(raw):len(raw)]
+	}
+	raw = append(raw, key...)
+	raw = append(raw, 0)
+	raw = append(raw, value...)
+	return append(raw, 0)
+}
+
+// hasRawField проверяет, есть ли ключ среди сырых полей key\0value\0...
+func hasRawField(raw []byte, key string) bool {
+	isKey := true
+	for len(raw) > 0 {
+		i := bytes.IndexByte(raw, 0)
+		if i < 0 {
+			return false
+		}
+		if isKey && string(raw[:i]) == key {
+			return true
+		}
+		isKey = !isKey
+		raw = raw[i+1:]
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// exclusiveWriter ловит одновременные вызовы Write; первая запись ждёт release.
+type exclusiveWriter struct {
+	memWriter
+	inflight   atomic.Int32
+	concurrent atomic.Bool
+	stalled    chan struct{} // закрывается, когда первая запись начала ждать
+	release    chan struct{}
+	once       sync.Once
+}
+
+func newExclusiveWriter() *exclusiveWriter {
+	return &exclusiveWriter{stalled: make(chan struct{}), release: make(chan struct{})}
+}
+
+// WriteRecord перекрывает memWriter.WriteRecord, чтобы запись шла через Write ниже.
+func (w *exclusiveWriter) WriteRecord(_ LogRecord, formatted []byte) error {
+	return w.Write(formatted)
+}
+
+func (w *exclusiveWriter) Write(p []byte) error {
+	if w.inflight.Add(1) > 1 {
+		w.concurrent.Store(true)
+	}
+	defer w.inflight.Add(-1)
+	first := false
+	w.once.Do(func() { first = true })
+	if first {
+		close(w.stalled)
+		<-w.release
+	}
+	return w.memWriter.Write(p)
+}
+
+func TestDrainTimeoutPerRoute(t *testing.T) {
+	stuck := newExclusiveWriter()
+	fast := &memWriter{}
+
+	var abandonedName string
+	var abandonedN int
+	slow := NewRouteProcessor(lineFormatter{}, stuck, Trace)
+	slow.Name = "slow"
+	slow.DrainTimeout = 30 * time.Millisecond
+	slow.OnDrainAbandoned = func(name string, n int) { abandonedName, abandonedN = name, n }
+	healthy := NewRouteProcessor(lineFormatter{}, fast, Trace)
+	healthy.DrainTimeout = 5 * time.Second
+
+	l := NewLogger(slow, healthy)
+	const n = 10
+	for i := 0; i < n; i++ {
+		l.Log(info(fmt.Sprint(i)))
+	}
+	<-stuck.stalled
+
+	start := time.Now()
+	l.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Close took %s, the stalled route should be abandoned after 30ms", d)
+	}
+	if got := len(fast.Lines()); got != n {
+		t.Fatalf("healthy route wrote %d records, want %d", got, n)
+	}
+	// первая запись висит в writer'е, остальные брошены
+	if abandonedName != "slow" || abandonedN != n-1 {
+		t.Fatalf("OnDrainAbandoned(%q, %d), want (\"slow\", %d)", abandonedName, abandonedN, n-1)
+	}
+	if h := slow.Health(time.Hour); h.Abandoned != n-1 {
+		t.Fatalf("Health.Abandoned = %d, want %d", h.Abandoned, n-1)
+	}
+	if h := healthy.Health(time.Hour); h.Abandoned != 0 {
+		t.Fatalf("healthy route abandoned %d records", h.Abandoned)
+	}
+
+	close(stuck.release)
+	waitFor(t, func() bool { return len(stuck.Lines()) == 1 })
+}
+
+func TestResetAfterDrainTimeoutWaitsForOldWorker(t *testing.T) {
+	stuck := newExclusiveWriter()
+	r := NewRouteProcessor(lineFormatter{}, stuck, Trace)
+	r.DrainTimeout = 20 * time.Millisecond
+	r.queue = make(chan LogRecordRaw, 1)
+	r.SpillDir = t.TempDir()
+	l := NewLogger(r)
+
+	l.Log(info("old-0"))
+	<-stuck.stalled
+	l.Log(info("old-1"))
+	l.Reset() // старый воркер брошен и всё ещё висит в Write
+
+	// новые записи уходят в новый файл переполнения: очередь на одну запись
+	for i := 0; i < 5; i++ {
+		l.Log(info(fmt.Sprint("new-", i)))
+	}
+	close(stuck.release)
+	l.Close()
+
+	if stuck.concurrent.Load() {
+		t.Fatal("old and new workers wrote to the writer concurrently")
+	}
+	want := []string{"old-0", "new-0", "new-1", "new-2", "new-3", "new-4"}
+	got := stuck.Lines()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// waitFor ждёт выполнения cond не дольше секунды.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// slowWriter — exclusiveWriter, каждая запись которого занимает delay.
+type slowWriter struct {
+	*exclusiveWriter
+	delay time.Duration
+}
+
+func (w slowWriter) WriteRecord(_ LogRecord, formatted []byte) error {
+	return w.Write(formatted)
+}
+
+func (w slowWriter) Write(p []byte) error {
+	time.Sleep(w.delay)
+	return w.exclusiveWriter.Write(p)
+}
+
+func TestDrainProgressDecreases(t *testing.T) {
+	w := slowWriter{newExclusiveWriter(), time.Millisecond}
+	route := NewRouteProcessor(lineFormatter{}, w, Trace)
+	route.Name = "backlog"
+	route.DrainProgressInterval = 5 * time.Millisecond
+	var mu sync.Mutex
+	var progress []int
+	route.OnDrainProgress = func(name string, remaining int) {
+		if name != "backlog" {
+			t.Errorf("route name %q", name)
+		}
+		mu.Lock()
+		progress = append(progress, remaining)
+		mu.Unlock()
+	}
+
+	l := NewLogger(route)
+	const n = 50
+	for i := 0; i < n; i++ {
+		l.Log(info(fmt.Sprint(i)))
+	}
+	<-w.stalled // воркер держит первую запись, остальные ждут в очереди
+
+	closed := make(chan struct{})
+	go func() {
+		l.Close()
+		close(closed)
+	}()
+	waitFor(t, route.queueClosed.Load)
+	close(w.release)
+	<-closed
+
+	if got := len(w.Lines()); got != n {
+		t.Fatalf("wrote %d records, want %d", got, n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) < 3 || progress[0] != n-1 || progress[len(progress)-1] != 0 {
+		t.Fatalf("progress %v: want start at %d, intermediate reports, end at 0", progress, n-1)
+	}
+	// промежуточный отчёт может совпасть с финальным нулём
+	for i := 1; i < len(progress); i++ {
+		if progress[i] > progress[i-1] || progress[i] == progress[i-1] && progress[i] != 0 {
+			t.Fatalf("progress not decreasing: %v", progress)
+		}
+	}
+}
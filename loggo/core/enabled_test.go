@@ -0,0 +1,34 @@
+package core
+
+import "testing"
+
+func TestEnabledMatchesRouting(t *testing.T) {
+	warn, errs := &memWriter{}, &memWriter{}
+	l := NewLogger(
+		NewRouteProcessor(lineFormatter{}, warn, Warning),
+		NewRouteProcessor(lineFormatter{}, errs, Error),
+	)
+
+	levels := []LogLevel{Trace, Debug, Info, Info + 5, Warning, Warning + 5, Error}
+	enabled := map[LogLevel]bool{}
+	for _, lvl := range levels {
+		enabled[lvl] = l.Enabled(lvl)
+		l.Log(LogRecordRaw{Level: lvl, Message: []byte(lvl.String())})
+	}
+	l.Close()
+
+	routed := map[LogLevel]bool{}
+	for _, w := range []*memWriter{warn, errs} {
+		for _, r := range w.Records() {
+			routed[r.Level] = true
+		}
+	}
+	for _, lvl := range levels {
+		if enabled[lvl] != routed[lvl] {
+			t.Errorf("level %d: Enabled = %v, routed = %v", lvl, enabled[lvl], routed[lvl])
+		}
+	}
+	if !enabled[Warning] || enabled[Info] {
+		t.Errorf("thresholds not applied: %v", enabled)
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"testing"
+)
+
+// stubExit подменяет exitFunc; возвращает код выхода (-1 — не вызывался) и
+// число записей у w в момент выхода.
+func stubExit(t *testing.T, w *memWriter) (code *int, linesAtExit *int) {
+	t.Helper()
+	c, n := -1, -1
+	orig := exitFunc
+	exitFunc = func(status int) {
+		c = status
+		n = len(w.Lines())
+	}
+	t.Cleanup(func() { exitFunc = orig })
+	return &c, &n
+}
+
+func TestExitOnExceptionFlushesBeforeExit(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	l.ExitOnException = true
+	code, linesAtExit := stubExit(t, w)
+
+	for i := 0; i < 100; i++ {
+		l.Log(info("before"))
+	}
+	l.Log(LogRecordRaw{Level: Exception, Message: []byte("fatal")})
+
+	if *code != 1 {
+		t.Fatalf("exit code %d, want 1", *code)
+	}
+	if *linesAtExit != 101 {
+		t.Fatalf("%d records written before exit, want 101", *linesAtExit)
+	}
+	if w.flushes == 0 {
+		t.Fatal("writer not flushed before exit")
+	}
+}
+
+func TestExitOnExceptionIgnoresRouteThresholds(t *testing.T) {
+	w := &memWriter{}
+	// роут пропускает только уровни выше Exception — запись отсеяна
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Exception+1))
+	l.ExitOnException = true
+	code, _ := stubExit(t, w)
+
+	l.Log(LogRecordRaw{Level: Exception, Message: []byte("fatal")})
+	if *code != 1 {
+		t.Fatalf("exit code %d, want 1 even for a filtered record", *code)
+	}
+	if n := len(w.Lines()); n != 0 {
+		t.Fatalf("filtered record written: %d lines", n)
+	}
+}
+
+func TestPanicOnExceptionFlushesAndKeepsLogging(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	l.PanicOnException = true
+	defer l.Close()
+
+	func() {
+		defer func() {
+			if v := recover(); v != "fatal" {
+				t.Fatalf("recovered %v, want panic with the message", v)
+			}
+			if n := len(w.Lines()); n != 2 {
+				t.Fatalf("%d records written before panic, want 2", n)
+			}
+		}()
+		l.Log(info("before"))
+		l.Log(LogRecordRaw{Level: Exception, Message: []byte("fatal")})
+	}()
+
+	// логгер работает и после recover
+	l.Log(info("after"))
+	l.Flush()
+	if lines := w.Lines(); len(lines) != 3 || lines[2] != "after" {
+		t.Fatalf("lines after recover: %q", lines)
+	}
+}
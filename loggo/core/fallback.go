@@ -0,0 +1,38 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// format вызывает форматтер; при FormatFallback паника внутри Format
+// превращается в ошибку, а не роняет воркер.
+func (r *RouteProcessor) format(record LogRecord) (data []byte, err error) {
+	if r.FormatFallback {
+		defer func() {
+			if v := recover(); v != nil {
+				data, err = nil, fmt.Errorf("formatter panic: %v", v)
+			}
+		}()
+	}
+	return r.Formatter.Format(record)
+}
+
+// fallbackFormat — минимальное безопасное представление записи, которую не
+// удалось отформатировать: только служебные поля и текст ошибки.
+func fallbackFormat(record LogRecord, cause error) []byte {
+	// структура из строк сериализуется всегда
+	data, _ := json.Marshal(struct {
+		Level       string `json:"level"`
+		Ts          string `json:"ts"`
+		Msg         string `json:"msg"`
+		FormatError string `json:"format_error"`
+	}{
+		Level:       record.Level.String(),
+		Ts:          record.Timestamp.Format(time.RFC3339Nano),
+		Msg:         record.Message,
+		FormatError: cause.Error(),
+	})
+	return data
+}
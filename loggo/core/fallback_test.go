@@ -0,0 +1,73 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// faultyFormatter паникует на "panic" и возвращает ошибку на "error".
+type faultyFormatter struct{}
+
+func (faultyFormatter) Format(r LogRecord) ([]byte, error) {
+	switch r.Message {
+	case "panic":
+		panic("boom")
+	case "error":
+		return nil, errors.New("bad value")
+	}
+	return lineFormatter{}.Format(r)
+}
+
+func TestFormatFallback(t *testing.T) {
+	w := &memWriter{}
+	route := NewSyncRouteProcessor(faultyFormatter{}, w, Info)
+	route.FormatFallback = true
+	var errs []error
+	route.OnError = func(err error, formatted []byte) {
+		if formatted != nil {
+			t.Errorf("formatted = %q, want nil for a formatter error", formatted)
+		}
+		errs = append(errs, err)
+	}
+	l := NewLogger(route)
+	l.Log(info("ok"))
+	l.Log(LogRecordRaw{Level: Error, Message: []byte("panic")})
+	l.Log(info("error"))
+	l.Log(info("after"))
+	l.Close()
+
+	lines := w.Lines()
+	if len(lines) != 4 || lines[0] != "ok" || lines[3] != "after" {
+		t.Fatalf("lines %q", lines)
+	}
+	for i, want := range []struct{ level, msg, cause string }{
+		{"ERROR", "panic", "formatter panic: boom"},
+		{"INFO", "error", "bad value"},
+	} {
+		var got struct {
+			Level       string `json:"level"`
+			Msg         string `json:"msg"`
+			FormatError string `json:"format_error"`
+		}
+		if err := json.Unmarshal([]byte(lines[i+1]), &got); err != nil {
+			t.Fatalf("fallback line %q: %v", lines[i+1], err)
+		}
+		if got.Level != want.level || got.Msg != want.msg || got.FormatError != want.cause {
+			t.Errorf("fallback %d = %+v", i, got)
+		}
+	}
+	if len(errs) != 2 {
+		t.Errorf("OnError calls: %v", errs)
+	}
+
+	// без FormatFallback запись с ошибкой форматирования теряется
+	w = &memWriter{}
+	l = NewLogger(NewSyncRouteProcessor(faultyFormatter{}, w, Info))
+	l.Log(info("error"))
+	l.Log(info("ok"))
+	l.Close()
+	if lines := w.Lines(); len(lines) != 1 || lines[0] != "ok" {
+		t.Errorf("without fallback: %q", lines)
+	}
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDuplicateFieldsAcrossSourcesLastWins(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	defer l.Close()
+
+	l.SetBaseFields(map[string]any{"k": "base", "only_base": 1})
+	var raw []byte
+	raw = appendRawField(raw, "k", "call1")
+	raw = appendRawField(raw, "k", "call2")
+	l.Log(LogRecordRaw{Level: Info, Message: []byte("m"), Fields: raw})
+	l.Flush()
+
+	recs := w.Records()
+	if len(recs) != 1 {
+		t.Fatalf("%d records, want 1", len(recs))
+	}
+	if got := recs[0].Fields["k"]; got != "call2" {
+		t.Errorf("k = %v, want the last value call2", got)
+	}
+	if got := recs[0].Fields["only_base"]; got != "1" {
+		t.Errorf("only_base = %v, want 1", got)
+	}
+	if got := w.Lines()[0]; got != "m k=call2 only_base=1" {
+		t.Errorf("line = %q", got)
+	}
+}
+
+// Запускать с -race: базовые поля меняются во время логирования, и каждая
+// запись видит один целостный набор.
+func TestSetBaseFieldsWhileLogging(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	l.SetBaseFields(map[string]any{"version": 0, "pair": 0})
+
+	stop := make(chan struct{})
+	var updater sync.WaitGroup
+	updater.Add(1)
+	go func() {
+		defer updater.Done()
+		for i := 1; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			l.SetBaseFields(map[string]any{"version": i, "pair": i})
+		}
+	}()
+
+	var loggers sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		loggers.Add(1)
+		go func() {
+			defer loggers.Done()
+			for i := 0; i < 200; i++ {
+				l.Log(info("m"))
+			}
+		}()
+	}
+	loggers.Wait()
+	close(stop)
+	updater.Wait()
+
+	l.SetBaseFields(nil)
+	l.Log(info("cleared"))
+	l.Close()
+
+	recs := w.Records()
+	if len(recs) != 801 {
+		t.Fatalf("%d records", len(recs))
+	}
+	for _, r := range recs[:800] {
+		if r.Fields["version"] == nil || r.Fields["version"] != r.Fields["pair"] {
+			t.Fatalf("inconsistent base fields: %v", r.Fields)
+		}
+	}
+	if f := recs[800].Fields; len(f) != 0 {
+		t.Errorf("after SetBaseFields(nil): %v", f)
+	}
+}
+
+// Запись без полей: Fields == nil и ни одной аллокации, будь поля nil или
+// пустыми; вывод одинаковый.
+func TestRawToRecordWithoutFieldsDoesNotAllocate(t *testing.T) {
+	ts := time.Now()
+	for name, fields := range map[string][]byte{"nil": nil, "empty": {}} {
+		rec := LogRecordRaw{Level: Info, Timestamp: ts, Fields: fields}
+		if r := rawToRecord(rec); r.Fields != nil {
+			t.Errorf("%s: Fields = %v, want nil", name, r.Fields)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { _ = rawToRecord(rec) }); allocs != 0 {
+			t.Errorf("%s: %v allocs, want 0", name, allocs)
+		}
+	}
+
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	l.LogAt(ts, Info, "m", nil)
+	l.LogAt(ts, Info, "m", map[string]string{})
+	l.Flush()
+	l.Close()
+	if lines := w.Lines(); len(lines) != 2 || lines[0] != lines[1] {
+		t.Errorf("lines = %q", lines)
+	}
+	if recs := w.Records(); len(recs) != 2 || recs[0].Fields != nil || recs[1].Fields != nil {
+		t.Errorf("records = %v", recs)
+	}
+
+	r := rawToRecord(LogRecordRaw{Level: Info, Timestamp: ts, Fields: appendRawField(nil, "k", "v")})
+	if len(r.Fields) != 1 || r.Fields["k"] != "v" {
+		t.Errorf("with a field: %v", r.Fields)
+	}
+}
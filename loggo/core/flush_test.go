@@ -0,0 +1,65 @@
+package core
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// bufferedWriter держит записи в буфере до Flush, как bufio поверх файла.
+type bufferedWriter struct {
+	mu      sync.Mutex
+	pending []string
+	flushed []string
+	syncs   int
+}
+
+func (w *bufferedWriter) Write(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, string(p))
+	return nil
+}
+
+func (w *bufferedWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flushed = append(w.flushed, w.pending...)
+	w.pending = nil
+	return nil
+}
+
+func (w *bufferedWriter) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncs++
+	return nil
+}
+
+func (w *bufferedWriter) state() (pending, flushed []string, syncs int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.pending...), append([]string(nil), w.flushed...), w.syncs
+}
+
+func TestFlushMinLevel(t *testing.T) {
+	w := &bufferedWriter{}
+	route := NewRouteProcessor(lineFormatter{}, w, Info)
+	route.FlushMinLevel = Error
+	l := NewLogger(route)
+	defer l.Close()
+
+	l.Log(info("info"))
+	waitFor(t, func() bool { p, _, _ := w.state(); return len(p) == 1 })
+	// Info записан, но остаётся в буфере
+	if _, flushed, syncs := w.state(); len(flushed) != 0 || syncs != 0 {
+		t.Fatalf("info flushed: %q, %d syncs", flushed, syncs)
+	}
+
+	l.Log(LogRecordRaw{Level: Error, Message: []byte("error")})
+	waitFor(t, func() bool { _, _, s := w.state(); return s == 1 })
+	pending, flushed, _ := w.state()
+	if len(pending) != 0 || !reflect.DeepEqual(flushed, []string{"info", "error"}) {
+		t.Errorf("after error: pending %q, flushed %q", pending, flushed)
+	}
+}
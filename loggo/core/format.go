@@ -3,3 +3,10 @@ package core
 type FormatProcessor interface {
 	Format(record LogRecord) ([]byte, error)
 }
+
+// SizeHinter — необязательное расширение форматтера: дешёвая оценка размера
+// записи после Format, чтобы writer мог заранее выделить буфер. Оценка
+// приблизительная, не точная.
+type SizeHinter interface {
+	SizeHint(record LogRecord) int
+}
@@ -0,0 +1,164 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchRecords — набор типовых записей для бенчмарков форматтеров: примитивы,
+// только строки, вложенные map, структуры и патологически глубокое значение.
+// Набор стабилен, чтобы результаты (в том числе allocs/op) можно было
+// сравнивать между версиями.
+func benchRecords() map[string]core.LogRecord {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	record := func(fields map[string]any) core.LogRecord {
+		return core.LogRecord{Level: core.Info, Timestamp: ts, Message: "request handled", Fields: fields}
+	}
+
+	type address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip,omitempty"`
+	}
+	type user struct {
+		ID      int64     `json:"id"`
+		Name    string    `json:"name"`
+		Tags    []string  `json:"tags"`
+		Created time.Time `json:"created"`
+		Address *address  `json:"address"`
+	}
+
+	// вложенность глубже defaultDepth — форматтер должен обрезать, а не падать
+	deep := map[string]any{"leaf": 1}
+	for i := 0; i < 2*defaultDepth; i++ {
+		deep = map[string]any{"next": deep}
+	}
+
+	return map[string]core.LogRecord{
+		// частый случай: все значения — строки (быстрый путь форматтеров)
+		"strings": record(map[string]any{
+			"method": "GET", "path": "/api/v1/users", "status": "ok",
+			"request_id": "7f3c2a91", "user_agent": "curl/8.5.0",
+		}),
+		"primitives": record(map[string]any{
+			"int": 42, "float": 3.25, "bool": true, "str": "ok",
+			"dur": 150 * time.Millisecond, "time": ts,
+		}),
+		"nested_maps": record(map[string]any{
+			"http": map[string]any{
+				"method": "GET", "status": 200,
+				"headers": map[string]any{"accept": "application/json", "x-request-id": "abc"},
+			},
+			"items": []any{1, "two", map[string]any{"three": 3}},
+		}),
+		"structs": record(map[string]any{
+			"user": user{
+				ID: 7, Name: "alice", Tags: []string{"admin", "ops"}, Created: ts,
+				Address: &address{City: "Berlin"},
+			},
+		}),
+		"deep": record(map[string]any{"root": deep}),
+	}
+}
+
+// benchFormat прогоняет f по всем записям benchRecords, по подбенчмарку на запись.
+func benchFormat(b *testing.B, f core.FormatProcessor) {
+	recs := benchRecords()
+	names := make([]string, 0, len(recs))
+	for name := range recs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := recs[name]
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Format(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFormatJSON(b *testing.B) {
+	benchFormat(b, NewJsonFormatter(nil, nil))
+}
+
+func BenchmarkFormatText(b *testing.B) {
+	benchFormat(b, NewTextFormatter(nil, nil))
+}
+
+func BenchmarkFormatMsgpack(b *testing.B) {
+	benchFormat(b, NewMsgpackFormatter(nil))
+}
+
+func BenchmarkFormatDebug(b *testing.B) {
+	benchFormat(b, NewDebugFormatter(nil, false))
+}
+
+// Форматтеры не держат состояния записи между вызовами: результат не зависит
+// от предыдущих записей и не портится последующими (пулы буферов).
+func TestFormattersKeepNoRecordState(t *testing.T) {
+	formatters := map[string]core.FormatProcessor{
+		"json":    NewJsonFormatter(nil, nil),
+		"text":    NewTextFormatter(nil, nil),
+		"msgpack": NewMsgpackFormatter(nil),
+		"debug":   NewDebugFormatter(nil, false),
+	}
+	recs := benchRecords()
+	for fname, f := range formatters {
+		first := map[string][]byte{}
+		for name, r := range recs {
+			out, err := f.Format(r)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", fname, name, err)
+			}
+			first[name] = append([]byte(nil), out...)
+			kept := out
+			for _, other := range recs {
+				_, _ = f.Format(other)
+			}
+			if string(kept) != string(first[name]) {
+				t.Errorf("%s/%s: output changed by later Format calls", fname, name)
+			}
+		}
+		if r, ok := f.(interface{ Reset() }); ok {
+			r.Reset()
+		}
+		for name, r := range recs {
+			out, _ := f.Format(r)
+			if string(out) != string(first[name]) {
+				t.Errorf("%s/%s: output depends on earlier records or Reset", fname, name)
+			}
+		}
+	}
+}
+
+// Крупная запись (~20 КиБ) с предвыделением буфера и без. Размер больше
+// maxPooledBufSize ограничивается им: буфер по-прежнему возвращается в пул.
+func BenchmarkFormatJSONInitialBufferSize(b *testing.B) {
+	fields := make(map[string]any, 200)
+	for i := 0; i < 200; i++ {
+		fields["field_"+strconv.Itoa(i)] = strings.Repeat("v", 80)
+	}
+	r := core.LogRecord{Level: core.Info, Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: "large record", Fields: fields}
+
+	for _, size := range []int{0, 32 << 10, 256 << 10} {
+		f := NewJsonFormatter(nil, nil)
+		f.InitialBufferSize = size
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Format(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestBigNumbersExact(t *testing.T) {
+	const digits = "1234567890123456789012345678901234567890" // 40 цифр
+	n, _ := new(big.Int).SetString(digits, 10)
+	neg := new(big.Int).Neg(n)
+	pi, _ := new(big.Float).SetPrec(200).SetString("3.14159265358979323846264338327950288")
+	var nilInt *big.Int
+	type holder struct {
+		N *big.Int `json:"n"`
+		V big.Int  `json:"v"`
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"n":      n,
+		"neg":    neg,
+		"pi":     pi,
+		"inf":    new(big.Float).SetInf(true),
+		"nil":    nilInt,
+		"nested": holder{N: n, V: *big.NewInt(7)},
+		"list":   []*big.Int{n, big.NewInt(1)},
+	}}
+
+	out := string(mustFormat(t, NewJsonFormatter(nil, nil), r))
+	for _, want := range []string{
+		`"n":` + digits,
+		`"neg":-` + digits,
+		`"pi":3.14159265358979323846264338327950288`,
+		`"inf":"-Infinity"`,
+		`"nil":null`,
+		`"nested":{"n":` + digits + `,"v":7}`,
+		`"list":[` + digits + `,1]`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("json: missing %s in %s", want, out)
+		}
+	}
+	// вывод остаётся валидным JSON
+	decodeJSON(t, []byte(out))
+
+	f := NewJsonFormatter(nil, nil)
+	f.BigFloatAsString = true
+	if out := string(mustFormat(t, f, r)); !strings.Contains(out, `"pi":"3.14159265358979323846264338327950288"`) || !strings.Contains(out, `"n":`+digits) {
+		t.Errorf("BigFloatAsString: %s", out)
+	}
+
+	text := string(mustFormat(t, NewTextFormatter(nil, nil), r))
+	for _, want := range []string{" n=" + digits, " neg=-" + digits, "{n: " + digits + ", v: 7}"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text: missing %s in %s", want, text)
+		}
+	}
+}
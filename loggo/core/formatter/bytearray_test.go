@@ -0,0 +1,68 @@
+package formatter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+type idByte byte
+
+type digestHolder struct {
+	Sum  [32]byte   `json:"sum"`
+	UUID [16]idByte `json:"uuid"`
+	Raw  []byte     `json:"raw"`
+	Keys [2][4]byte `json:"keys"`
+}
+
+// [N]byte внутри структуры кодируется так же, как []byte верхнего уровня.
+func TestByteArraysInStructs(t *testing.T) {
+	sum := sha256.Sum256([]byte("payload"))
+	var uuid [16]idByte
+	for i := range uuid {
+		uuid[i] = idByte(i)
+	}
+	uuidBytes := make([]byte, 16)
+	for i := range uuidBytes {
+		uuidBytes[i] = byte(i)
+	}
+	v := digestHolder{Sum: sum, UUID: uuid, Raw: sum[:]}
+	r := core.LogRecord{Level: core.Info, Fields: map[string]any{"d": v, "top": sum[:]}}
+
+	doc := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r))
+	d := doc["d"].(map[string]any)
+	b64 := base64.StdEncoding.EncodeToString(sum[:])
+	if d["sum"] != b64 || d["raw"] != b64 || doc["top"] != b64 {
+		t.Errorf("json sum = %v, raw = %v, top = %v; want %s", d["sum"], d["raw"], doc["top"], b64)
+	}
+	if d["uuid"] != base64.StdEncoding.EncodeToString(uuidBytes) {
+		t.Errorf("json uuid = %v", d["uuid"])
+	}
+	if k, _ := d["keys"].([]any); len(k) != 2 || k[0] != "AAAAAA==" {
+		t.Errorf("json keys = %v", d["keys"])
+	}
+
+	jf := NewJsonFormatter(nil, nil)
+	jf.ByteEncoding = ByteEncodingHex
+	d = decodeJSON(t, mustFormat(t, jf, r))["d"].(map[string]any)
+	if d["sum"] != hex.EncodeToString(sum[:]) || d["uuid"] != hex.EncodeToString(uuidBytes) {
+		t.Errorf("json hex: %v", d)
+	}
+
+	text := string(mustFormat(t, NewTextFormatter(nil, nil), r))
+	if !strings.Contains(text, "sum: []byte(32)") || !strings.Contains(text, "uuid: []byte(16)") {
+		t.Errorf("text: %s", text)
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.BytesAsHex = true
+	text = string(mustFormat(t, tf, r))
+	for _, w := range []string{"sum: " + hex.EncodeToString(sum[:]), "uuid: " + hex.EncodeToString(uuidBytes), "top=" + hex.EncodeToString(sum[:]), "keys: [00000000, 00000000]"} {
+		if !strings.Contains(text, w) {
+			t.Errorf("text hex missing %q: %s", w, text)
+		}
+	}
+}
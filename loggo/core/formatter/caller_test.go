@@ -0,0 +1,56 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// lineSink — WriteProcessor, запоминающий отформатированные строки.
+type lineSink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *lineSink) Write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, string(p))
+	return nil
+}
+
+// Caller проходит от Logger через роут до вывода обоих форматтеров.
+func TestCallerEndToEnd(t *testing.T) {
+	jsonOut, textOut := &lineSink{}, &lineSink{}
+	l := core.NewLogger(
+		core.NewSyncRouteProcessor(NewJsonFormatter(nil, nil), jsonOut, core.Info),
+		core.NewSyncRouteProcessor(NewTextFormatter(nil, nil), textOut, core.Info),
+	)
+	l.EnableCaller(true, false)
+
+	l.Log(core.LogRecordRaw{Level: core.Info, Message: []byte("captured")})
+	_, _, line, _ := runtime.Caller(0)
+	captured := "caller_test.go:" + strconv.Itoa(line-1)
+	l.Log(core.LogRecordRaw{Level: core.Info, Message: []byte("host"), Caller: "host.py:7"})
+	l.EnableCaller(false, false)
+	l.Log(core.LogRecordRaw{Level: core.Info, Message: []byte("none")})
+	l.Close()
+
+	if len(jsonOut.lines) != 3 || len(textOut.lines) != 3 {
+		t.Fatalf("json %q, text %q", jsonOut.lines, textOut.lines)
+	}
+	for i, want := range []string{captured, "host.py:7"} {
+		if got := decodeJSON(t, []byte(jsonOut.lines[i]))["caller"]; got != want {
+			t.Errorf("json %d: caller = %v, want %s", i, got, want)
+		}
+		if !strings.Contains(textOut.lines[i], " "+want+" → ") {
+			t.Errorf("text %d: %q, want caller %s", i, textOut.lines[i], want)
+		}
+	}
+	if _, ok := decodeJSON(t, []byte(jsonOut.lines[2]))["caller"]; ok {
+		t.Errorf("json without caller: %s", jsonOut.lines[2])
+	}
+}
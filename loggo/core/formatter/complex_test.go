@@ -0,0 +1,40 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComplexValues(t *testing.T) {
+	r := core.LogRecord{Level: core.Info, Fields: map[string]any{
+		"neg":  complex(1.5, -2),
+		"c64":  complex64(complex(0.5, 0.25)),
+		"inf":  complex(math.Inf(1), -1),
+		"nan":  complex(0, math.NaN()),
+		"list": []complex128{complex(-1, 3)},
+	}}
+
+	doc := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r))
+	want := map[string]any{
+		"neg":  []any{1.5, -2.0},
+		"c64":  []any{0.5, 0.25},
+		"inf":  []any{"Infinity", -1.0},
+		"nan":  []any{0.0, "NaN"},
+		"list": []any{[]any{-1.0, 3.0}},
+	}
+	for k, w := range want {
+		if !reflect.DeepEqual(doc[k], w) {
+			t.Errorf("json %s = %#v, want %#v", k, doc[k], w)
+		}
+	}
+
+	text := string(mustFormat(t, NewTextFormatter(nil, nil), r))
+	for _, w := range []string{"neg=1.5-2i", "c64=0.5+0.25i", "inf=+Inf-1i", "nan=0+NaNi", "-1+3i"} {
+		if !strings.Contains(text, w) {
+			t.Errorf("text missing %q: %s", w, text)
+		}
+	}
+}
@@ -1,3 +1,28 @@
 package formatter
 
 const defaultDepth int = 3
+
+// defaultLevelWidth — ширина колонки уровня по длине "WARNING".
+const defaultLevelWidth = 7
+
+// defaultTimestampLayout — раскладка времени записи в текстовых форматтерах.
+const defaultTimestampLayout = "2006-01-02 15:04:05.000"
+
+// schemaVersionKey — имя поля с версией схемы записи (см. SchemaVersion у форматтеров).
+const schemaVersionKey = "schema_version"
+
+// tagsKey — имя массива меток записи (core.LogRecord.Tags) в JSON и MessagePack.
+const tagsKey = "tags"
+
+// maxElementsMarker заменяет элементы сверх бюджета MaxElements.
+const maxElementsMarker = "<max_elements>"
+
+// truncatedKeyMarker дописывается к ключам, обрезанным по MaxKeyLen.
+const truncatedKeyMarker = "…"
+
+// collisionPrefix — префикс для полей, совпавших со служебным ключом (KeyCollisionPrefix).
+const collisionPrefix = "fields."
+
+// dupKeySep отделяет номер от ключа, ставшего повторным после преобразований
+// (префикс collisionPrefix, KeyCase, MaxKeyLen, строковые ключи map): "id#2".
+const dupKeySep = "#"
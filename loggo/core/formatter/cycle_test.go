@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"bytes"
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+type cycleNode struct {
+	Name string
+	Next *cycleNode
+}
+
+// Циклы через указатели на срезы, map и структуры обрываются маркером <cycle>,
+// а не уходят в бесконечную рекурсию.
+func TestPointerCyclesTerminate(t *testing.T) {
+	var s []any
+	ps := &s
+	s = []any{ps}
+
+	m := map[string]any{}
+	pm := &m
+	m["self"] = pm
+
+	n := &cycleNode{Name: "a"}
+	n.Next = n
+
+	var inner []any
+	pi := &inner
+	wrapped := map[string]any{"list": pi}
+	inner = []any{1, wrapped}
+
+	fields := map[string]any{"slice": ps, "map": pm, "node": n, "wrapped": wrapped}
+	depth := 64
+	formatters := map[string]core.FormatProcessor{
+		"json":    NewJsonFormatter(nil, &depth),
+		"text":    NewTextFormatter(nil, &depth),
+		"msgpack": NewMsgpackFormatter(&depth),
+		"debug":   NewDebugFormatter(&depth, false),
+	}
+	for name, f := range formatters {
+		for k, v := range fields {
+			out := mustFormat(t, f, core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{k: v}})
+			if !bytes.Contains(out, []byte("<cycle>")) {
+				t.Errorf("%s %s: no cycle marker: %q", name, k, out)
+			}
+			if name == "json" {
+				decodeJSON(t, out)
+			}
+		}
+	}
+
+	// одна и та же структура дважды, но без цикла — не маркер
+	shared := &cycleNode{Name: "x"}
+	out := string(mustFormat(t, NewJsonFormatter(nil, nil), core.LogRecord{Level: core.Info, Fields: map[string]any{
+		"pair": []any{shared, shared},
+	}}))
+	if strings.Contains(out, "<cycle>") {
+		t.Errorf("shared pointer reported as cycle: %s", out)
+	}
+}
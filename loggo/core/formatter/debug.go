@@ -0,0 +1,210 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// DebugFormatter выводит поля подробным reflect-дампом с именами типов.
+// Только для локальной отладки: медленный, формат не стабилен и не предназначен для разбора.
+type DebugFormatter struct {
+	MaxDepth int
+	// ShowUnexported включает вывод неэкспортируемых полей структур (через unsafe).
+	ShowUnexported bool
+	// SchemaVersion — если задан, в каждую запись добавляется поле schema_version.
+	SchemaVersion string
+}
+
+// NewDebugFormatter создаёт DebugFormatter; maxDepth == nil, 0 или < 0 — глубина
+// по умолчанию.
+func NewDebugFormatter(maxDepth *int, showUnexported bool) *DebugFormatter {
+	depth := depthOrDefault(maxDepth)
+	return &DebugFormatter{MaxDepth: depth, ShowUnexported: showUnexported}
+}
+
+func (f *DebugFormatter) Format(r core.LogRecord) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString("[")
+	b.WriteString(r.Timestamp.Round(0).Format(defaultTimestampLayout))
+	b.WriteString("] ")
+	if r.Seq != 0 {
+		b.WriteByte('#')
+		b.WriteString(strconv.FormatUint(r.Seq, 10))
+		b.WriteByte(' ')
+	}
+	b.WriteString(padLevel(r.Level.String(), defaultLevelWidth))
+	b.WriteString(" → ")
+	b.WriteString(r.Message)
+
+	if f.SchemaVersion != "" {
+		b.WriteString(" | ")
+		b.WriteString(schemaVersionKey)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(f.SchemaVersion))
+	}
+
+	if len(r.Fields) > 0 {
+		if f.SchemaVersion == "" {
+			b.WriteString(" |")
+		}
+		keys := make([]string, 0, len(r.Fields))
+		for k := range r.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		visited := newVisitSet(0)
+		for _, k := range keys {
+			b.WriteByte(' ')
+			b.WriteString(k)
+			b.WriteByte('=')
+			f.dump(&b, reflect.ValueOf(r.Fields[k]), 0, visited)
+		}
+	}
+	if len(r.Tags) > 0 {
+		b.WriteString(" [")
+		b.WriteString(strings.Join(r.Tags, ", "))
+		b.WriteByte(']')
+	}
+	return b.Bytes(), nil
+}
+
+func (f *DebugFormatter) dump(b *bytes.Buffer, rv reflect.Value, depth int, visited visitSet) {
+	if !rv.IsValid() {
+		b.WriteString("<nil>")
+		return
+	}
+	if depth >= f.MaxDepth {
+		b.WriteString("<max_depth>")
+		return
+	}
+
+	if rv.CanInterface() {
+		switch x := rv.Interface().(type) {
+		case time.Time:
+			fmt.Fprintf(b, "(time.Time) %s", x.Round(0).Format(time.RFC3339Nano))
+			return
+		case time.Duration:
+			fmt.Fprintf(b, "(time.Duration) %s", x)
+			return
+		}
+	}
+
+	// структуры не помечаем: их адрес совпадает с указателем, через который
+	// мы в них пришли, а цикл без указателя/контейнера невозможен
+	if rv.Kind() != reflect.Struct {
+		ok, release := markAndCheck(rv, visited)
+		if !ok {
+			b.WriteString("<cycle>")
+			return
+		}
+		defer release()
+	}
+
+	t := rv.Type()
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			fmt.Fprintf(b, "(%s)(nil)", t)
+			return
+		}
+		b.WriteByte('&')
+		f.dump(b, rv.Elem(), depth+1, visited)
+
+	case reflect.Interface:
+		if rv.IsNil() {
+			fmt.Fprintf(b, "(%s)(nil)", t)
+			return
+		}
+		f.dump(b, rv.Elem(), depth, visited)
+
+	case reflect.Struct:
+		if f.ShowUnexported && !rv.CanAddr() {
+			// копия нужна, чтобы получить адрес неэкспортируемых полей
+			cp := reflect.New(t).Elem()
+			cp.Set(rv)
+			rv = cp
+		}
+		b.WriteString(t.String())
+		b.WriteByte('{')
+		n := 0
+		for i := 0; i < rv.NumField(); i++ {
+			sf := t.Field(i)
+			fv := rv.Field(i)
+			if !sf.IsExported() {
+				if !f.ShowUnexported {
+					continue
+				}
+				fv = reflect.NewAt(sf.Type, unsafe.Pointer(fv.UnsafeAddr())).Elem()
+			}
+			if n > 0 {
+				b.WriteString(", ")
+			}
+			n++
+			b.WriteString(sf.Name)
+			b.WriteString(": ")
+			f.dump(b, fv, depth+1, visited)
+		}
+		b.WriteByte('}')
+
+	case reflect.Map:
+		if rv.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", t)
+			return
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+		})
+		b.WriteString(t.String())
+		b.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			f.dump(b, k, depth+1, visited)
+			b.WriteString(": ")
+			f.dump(b, rv.MapIndex(k), depth+1, visited)
+		}
+		b.WriteByte('}')
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			fmt.Fprintf(b, "%s(nil)", t)
+			return
+		}
+		b.WriteString(t.String())
+		b.WriteByte('{')
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			f.dump(b, rv.Index(i), depth+1, visited)
+		}
+		b.WriteByte('}')
+
+	case reflect.String:
+		fmt.Fprintf(b, "(%s) %s", t, strconv.Quote(rv.String()))
+	case reflect.Bool:
+		fmt.Fprintf(b, "(%s) %t", t, rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fmt.Fprintf(b, "(%s) %d", t, rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		fmt.Fprintf(b, "(%s) %d", t, rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		fmt.Fprintf(b, "(%s) %s", t, strconv.FormatFloat(rv.Float(), 'g', -1, t.Bits()))
+	case reflect.Complex64, reflect.Complex128:
+		fmt.Fprintf(b, "(%s) %v", t, rv.Complex())
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		fmt.Fprintf(b, "(%s) %#x", t, rv.Pointer())
+	default:
+		fmt.Fprintf(b, "(%s) <%s>", t, rv.Kind())
+	}
+}
@@ -0,0 +1,38 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+type debugUser struct {
+	Name string
+	age  int
+	next *debugUser
+}
+
+func TestDebugFormatterUnexportedFields(t *testing.T) {
+	u := &debugUser{Name: "alice", age: 7}
+	u.next = u
+	r := core.LogRecord{Level: core.Debug, Message: "m", Fields: map[string]any{"u": u}}
+
+	out := string(mustFormat(t, NewDebugFormatter(nil, false), r))
+	if !strings.Contains(out, `u=&formatter.debugUser{Name: (string) "alice"}`) {
+		t.Errorf("exported only: %s", out)
+	}
+
+	out = string(mustFormat(t, NewDebugFormatter(nil, true), r))
+	want := `u=&formatter.debugUser{Name: (string) "alice", age: (int) 7, next: <cycle>}`
+	if !strings.Contains(out, want) {
+		t.Errorf("with unexported:\n%s\nwant %s", out, want)
+	}
+
+	depth := 2
+	out = string(mustFormat(t, NewDebugFormatter(&depth, true), core.LogRecord{
+		Level: core.Debug, Fields: map[string]any{"u": debugUser{Name: "a", next: &debugUser{}}},
+	}))
+	if !strings.Contains(out, "next: &<max_depth>") {
+		t.Errorf("MaxDepth: %s", out)
+	}
+}
@@ -0,0 +1,43 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+func TestNonPositiveMaxDepthUsesDefault(t *testing.T) {
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"n": map[string]any{"a": map[string]any{"b": 1}},
+	}}
+	for _, d := range []int{0, -1, -100} {
+		depth := d
+		jf := NewJsonFormatter(nil, &depth)
+		tf := NewTextFormatter(nil, &depth)
+		mf := NewMsgpackFormatter(&depth)
+		df := NewDebugFormatter(&depth, false)
+		for name, got := range map[string]int{"json": jf.MaxDepth, "text": tf.MaxDepth, "msgpack": mf.MaxDepth, "debug": df.MaxDepth} {
+			if got != defaultDepth {
+				t.Errorf("%s(%d): MaxDepth = %d, want %d", name, d, got, defaultDepth)
+			}
+		}
+
+		if out := string(mustFormat(t, jf, r)); !strings.Contains(out, `"n":{"a":{"b":1}}`) {
+			t.Errorf("json(%d): %s", d, out)
+		}
+		if out := string(mustFormat(t, tf, r)); !strings.HasSuffix(out, "n={a: {b: 1}}") {
+			t.Errorf("text(%d): %s", d, out)
+		}
+		for name, f := range map[string]core.FormatProcessor{"msgpack": mf, "debug": df} {
+			if out := mustFormat(t, f, r); strings.Contains(string(out), "<max_depth>") {
+				t.Errorf("%s(%d): %q", name, d, out)
+			}
+		}
+	}
+
+	// явная положительная глубина соблюдается
+	one := 1
+	if out := string(mustFormat(t, NewJsonFormatter(nil, &one), r)); !strings.Contains(out, `"n":{"a":"<max_depth>"}`) {
+		t.Errorf("depth 1: %s", out)
+	}
+}
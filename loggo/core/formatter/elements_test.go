@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+func TestMaxElementsBudgetAcrossSiblings(t *testing.T) {
+	// пять срезов по 4 элемента — каждый меньше бюджета, вместе больше
+	fields := map[string]any{}
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		fields[k] = []int{1, 2, 3, 4}
+	}
+	fields["nested"] = map[string]any{"x": []string{"p", "q", "r"}}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: fields}
+
+	jf := NewJsonFormatter(nil, nil)
+	jf.MaxElements = 10
+	out, err := jf.Format(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !json.Valid(out) {
+		t.Fatalf("invalid JSON: %s", out)
+	}
+	if n := strings.Count(string(out), maxElementsMarker); n != 1 {
+		t.Fatalf("%d markers, want exactly one: %s", n, out)
+	}
+	var doc map[string]any
+	_ = json.Unmarshal(out, &doc)
+	elems := 0
+	var count func(v any)
+	count = func(v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, e := range v {
+				if e != maxElementsMarker {
+					elems++
+				}
+				count(e)
+			}
+		case map[string]any:
+			for _, e := range v {
+				count(e)
+			}
+		}
+	}
+	for _, k := range []string{"a", "b", "c", "d", "e", "nested"} {
+		count(doc[k])
+	}
+	// вложенный map тоже тратит бюджет — на свой член
+	if elems > 10 {
+		t.Fatalf("%d slice elements rendered, budget 10: %s", elems, out)
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.MaxElements = 10
+	out, err = tf.Format(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(out), maxElementsMarker); n != 1 {
+		t.Fatalf("text: %d markers, want exactly one: %s", n, out)
+	}
+
+	jf.MaxElements = 0
+	out, _ = jf.Format(r)
+	if strings.Contains(string(out), maxElementsMarker) {
+		t.Fatalf("MaxElements 0 must not truncate: %s", out)
+	}
+}
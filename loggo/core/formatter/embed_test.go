@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type embedBase struct {
+	ID     int
+	Name   string
+	Shared string
+}
+
+type EmbedMeta struct {
+	Shared string
+	Tagged string `json:"tagged"`
+}
+
+type embedOther struct {
+	Tagged string `json:"tagged"`
+}
+
+type embedInner struct {
+	X int
+}
+
+type embedOuter struct {
+	embedBase            // поля поднимаются
+	*EmbedMeta           // Shared: та же глубина, что у embedBase.Shared — поле опускается
+	embedOther           // tagged: та же глубина и тоже с тегом — опускается
+	embedInner           // неэкспортируемый тип: экспортируемые поля всё равно поднимаются
+	Name       string    // менее глубокое поле побеждает embedBase.Name
+	Named      embedBase `json:"named"` // имя в теге — вложенный объект
+}
+
+type embedLoop struct {
+	*embedLoop
+	V int
+}
+
+// Вывод совпадает с encoding/json (с точностью до порядка ключей).
+func TestEmbeddedStructsMatchEncodingJSON(t *testing.T) {
+	loop := &embedLoop{V: 1}
+	loop.embedLoop = loop
+	values := map[string]any{
+		"outer": embedOuter{
+			embedBase:  embedBase{ID: 1, Name: "base", Shared: "b"},
+			EmbedMeta:  &EmbedMeta{Shared: "m", Tagged: "meta"},
+			embedOther: embedOther{Tagged: "other"},
+			embedInner: embedInner{X: 5},
+			Name:       "outer",
+			Named:      embedBase{ID: 2},
+		},
+		"nil_ptr": embedOuter{Name: "no meta"},
+		"loop":    loop,
+	}
+	for name, v := range values {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var wantDoc any
+		if err := json.Unmarshal(want, &wantDoc); err != nil {
+			t.Fatal(err)
+		}
+		got := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), core.LogRecord{
+			Level: core.Info, Fields: map[string]any{"v": v},
+		}))
+		if !reflect.DeepEqual(got["v"], wantDoc) {
+			t.Errorf("%s:\n got %v\nwant %s", name, got["v"], want)
+		}
+	}
+
+	text := string(mustFormat(t, NewTextFormatter(nil, nil), core.LogRecord{
+		Level: core.Info, Fields: map[string]any{"v": values["outer"]},
+	}))
+	if !strings.Contains(text, `ID: 1`) || !strings.Contains(text, `Name: "outer"`) || strings.Contains(text, `Shared: "b"`) {
+		t.Errorf("text: %s", text)
+	}
+}
@@ -0,0 +1,60 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+// Пустые map/срез — {} и [], nil — null, на любом уровне вложенности и в обоих форматтерах.
+func TestEmptyVersusNil(t *testing.T) {
+	type holder struct {
+		M map[string]any `json:"m"`
+		S []any          `json:"s"`
+	}
+	var nilMap map[string]any
+	var nilSlice []any
+	var nilInts []int
+	empty := map[string]any{}
+	fields := map[string]any{
+		"em":  map[string]any{},
+		"es":  []any{},
+		"ei":  []int{},
+		"nm":  nilMap,
+		"ns":  nilSlice,
+		"ni":  nilInts,
+		"pem": &empty,
+		"in":  []any{map[string]any{}, []any{}, nilMap, nilSlice},
+		"st":  holder{M: map[string]any{}, S: []any{}},
+		"sn":  holder{},
+		"mp":  map[string]any{"e": map[string]int{}, "n": nilMap},
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: fields}
+
+	jsonWant := map[string]string{
+		"em": `{}`, "es": `[]`, "ei": `[]`, "nm": `null`, "ns": `null`, "ni": `null`, "pem": `{}`,
+		"in": `[{},[],null,null]`,
+		"st": `{"m":{},"s":[]}`, "sn": `{"m":null,"s":null}`,
+		"mp": `{"e":{},"n":null}`,
+	}
+	out := string(mustFormat(t, NewJsonFormatter(nil, nil), r))
+	decodeJSON(t, []byte(out))
+	for k, want := range jsonWant {
+		if !strings.Contains(out, `"`+k+`":`+want) {
+			t.Errorf("json %s: want %s in %s", k, want, out)
+		}
+	}
+
+	textWant := map[string]string{
+		"em": `{}`, "es": `[]`, "ei": `[]`, "nm": `null`, "ns": `null`, "ni": `null`, "pem": `{}`,
+		"in": `[{}, [], null, null]`,
+		"st": `{m: {}, s: []}`, "sn": `{m: null, s: null}`,
+		"mp": `{e: {}, n: null}`,
+	}
+	text := string(mustFormat(t, NewTextFormatter(nil, nil), r))
+	for k, want := range textWant {
+		if !strings.Contains(text, " "+k+"="+want) {
+			t.Errorf("text %s: want %s in %s", k, want, text)
+		}
+	}
+}
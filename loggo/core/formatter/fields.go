@@ -0,0 +1,211 @@
+package formatter
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// StructFieldOrder задаёт порядок вывода полей структур.
+type StructFieldOrder int
+
+const (
+	StructFieldsSorted   StructFieldOrder = iota // по алфавиту ключей (по умолчанию)
+	StructFieldsDeclared                         // в порядке объявления, как encoding/json
+)
+
+// structField — поле структуры, готовое к выводу.
+type structField struct {
+	key   string
+	value reflect.Value
+	index []int // путь индексов через встроенные структуры — порядок объявления
+
+	depth     int  // уровень встраивания: 0 — собственное поле
+	tagged    bool // имя задано json-тегом
+	omitEmpty bool
+	quoted    bool // опция ",string": скаляр выводится строкой
+}
+
+// structFields собирает поля структуры по правилам encoding/json: учитывает
+// json-теги, а поля встроенных (anonymous) структур без имени в теге поднимает
+// в родителя. При совпадении имён побеждает менее глубокое поле, при равной
+// глубине — поле с тегом; если и так неоднозначно, все такие поля опускаются.
+// Результат отсортирован по ключу или, для StructFieldsDeclared, по порядку
+// объявления (поля встроенной структуры — на месте самой встроенной структуры).
+func structFields(rv reflect.Value, order StructFieldOrder) []structField {
+	if !rv.CanAddr() {
+		// адресуемая копия нужна, чтобы читать поля неэкспортируемых встроенных типов
+		cp := reflect.New(rv.Type()).Elem()
+		cp.Set(rv)
+		rv = cp
+	}
+
+	var all []structField
+	collectStructFields(rv.Type(), rv, nil, make(map[reflect.Type]struct{}), &all)
+
+	byKey := make(map[string][]structField, len(all))
+	for _, sf := range all {
+		byKey[sf.key] = append(byKey[sf.key], sf)
+	}
+
+	fields := make([]structField, 0, len(byKey))
+	for _, cands := range byKey {
+		sf, ok := dominantField(cands)
+		// поле за nil-указателем участвует в разрешении имён, но не выводится
+		if !ok || !sf.value.IsValid() || (sf.omitEmpty && sf.value.IsZero()) {
+			continue
+		}
+		fields = append(fields, sf)
+	}
+	if order == StructFieldsDeclared {
+		sort.Slice(fields, func(i, j int) bool { return lessIndex(fields[i].index, fields[j].index) })
+	} else {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+	}
+	return fields
+}
+
+// lessIndex сравнивает пути индексов полей лексикографически.
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// collectStructFields обходит поля типа t; rv — его значение или нулевой
+// reflect.Value, если встроенный указатель на пути равен nil.
+func collectStructFields(t reflect.Type, rv reflect.Value, index []int, seen map[reflect.Type]struct{}, out *[]structField) {
+	depth := len(index)
+	// защита от бесконечного встраивания через указатели (type A struct{ *A })
+	if _, ok := seen[t]; ok {
+		return
+	}
+	seen[t] = struct{}{}
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		var fv reflect.Value
+		if rv.IsValid() {
+			fv = rv.Field(i)
+		}
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				// как в encoding/json: указатель на неэкспортируемый тип пропускаем
+				if sf.Type.Kind() == reflect.Ptr {
+					if !sf.IsExported() {
+						continue
+					}
+					if fv.IsValid() {
+						fv = fv.Elem() // для nil — нулевой Value
+					}
+				}
+				collectStructFields(ft, fv, fieldIndex, seen, out)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		key := sf.Name
+		if name != "" {
+			key = name
+		}
+		*out = append(*out, structField{
+			key:       key,
+			value:     exportedValue(fv),
+			index:     fieldIndex,
+			depth:     depth,
+			tagged:    name != "",
+			omitEmpty: hasTagOption(opts, "omitempty"),
+			quoted:    hasTagOption(opts, "string") && isQuotableKind(sf.Type),
+		})
+	}
+}
+
+// dominantField выбирает поле среди претендентов на одно имя.
+func dominantField(cands []structField) (structField, bool) {
+	if len(cands) == 1 {
+		return cands[0], true
+	}
+	minDepth := cands[0].depth
+	for _, c := range cands[1:] {
+		if c.depth < minDepth {
+			minDepth = c.depth
+		}
+	}
+
+	var top []structField
+	for _, c := range cands {
+		if c.depth == minDepth {
+			top = append(top, c)
+		}
+	}
+	if len(top) == 1 {
+		return top[0], true
+	}
+
+	var tagged []structField
+	for _, c := range top {
+		if c.tagged {
+			tagged = append(tagged, c)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return structField{}, false
+}
+
+// exportedValue снимает с экспортируемого поля флаг read-only, который reflect
+// ставит полям неэкспортируемых встроенных структур (иначе Interface() паникует).
+func exportedValue(fv reflect.Value) reflect.Value {
+	if !fv.IsValid() || fv.CanInterface() || !fv.CanAddr() {
+		return fv
+	}
+	return reflect.NewAt(fv.Type(), unsafe.Pointer(fv.UnsafeAddr())).Elem()
+}
+
+// isQuotableKind — типы, к которым encoding/json применяет опцию ",string":
+// строки, числа и bool (в том числе через указатель).
+func isQuotableKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func hasTagOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package formatter
+
+import "funchooooza-ossh/loggo/core"
+
+// FormatJSON форматирует одну запись JsonFormatter'ом по умолчанию — для тестов,
+// превью и разовых вызовов без роута. opts настраивают форматтер перед вызовом:
+//
+//	formatter.FormatJSON(r, func(f *JsonFormatter) { f.FieldsKey = "fields" })
+func FormatJSON(r core.LogRecord, opts ...func(*JsonFormatter)) ([]byte, error) {
+	f := NewJsonFormatter(nil, nil)
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f.Format(r)
+}
+
+// FormatText — то же для TextFormatter (без цветов).
+func FormatText(r core.LogRecord, opts ...func(*TextFormatter)) ([]byte, error) {
+	f := NewTextFormatter(nil, nil)
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f.Format(r)
+}
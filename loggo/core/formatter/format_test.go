@@ -0,0 +1,51 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"testing"
+	"time"
+)
+
+func TestFormatHelpersMatchFormatters(t *testing.T) {
+	r := core.LogRecord{
+		Level: core.Warning, Timestamp: time.Date(2025, 8, 14, 10, 0, 0, 123456789, time.UTC),
+		Message: "m", Caller: "a.go:1", Fields: map[string]any{"k": 1, "nested": map[string]any{"s": "v"}},
+	}
+
+	got, err := FormatJSON(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustFormat(t, NewJsonFormatter(nil, nil), r); string(got) != string(want) {
+		t.Errorf("FormatJSON:\n got %s\nwant %s", got, want)
+	}
+
+	got, err = FormatJSON(r, func(f *JsonFormatter) { f.TimePrecision = time.Second; f.FieldsKey = "fields" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	jf := NewJsonFormatter(nil, nil)
+	jf.TimePrecision = time.Second
+	jf.FieldsKey = "fields"
+	if want := mustFormat(t, jf, r); string(got) != string(want) {
+		t.Errorf("FormatJSON with options:\n got %s\nwant %s", got, want)
+	}
+
+	got, err = FormatText(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustFormat(t, NewTextFormatter(nil, nil), r); string(got) != string(want) {
+		t.Errorf("FormatText:\n got %q\nwant %q", got, want)
+	}
+
+	got, err = FormatText(r, func(f *TextFormatter) { f.FieldSep = "\t" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	tf := NewTextFormatter(nil, nil)
+	tf.FieldSep = "\t"
+	if want := mustFormat(t, tf, r); string(got) != string(want) {
+		t.Errorf("FormatText with options:\n got %q\nwant %q", got, want)
+	}
+}
@@ -1,11 +1,40 @@
 package formatter
 
 import (
+	"bytes"
+	"fmt"
+	"math/big"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
+// maxPooledBufSize — буферы крупнее не возвращаются в пул, чтобы редкая
+// огромная запись не держала память навсегда.
+const maxPooledBufSize = 64 << 10
+
+var bufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func putBuf(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBufSize {
+		return
+	}
+	bufPool.Put(b)
+}
+
+// depthOrDefault — глубина из аргумента конструктора: nil, 0 и отрицательные
+// значения означают defaultDepth (иначе каждое значение стало бы "<max_depth>").
+func depthOrDefault(maxDepth *int) int {
+	if maxDepth == nil || *maxDepth <= 0 {
+		return defaultDepth
+	}
+	return *maxDepth
+}
+
 func toFloatString(v interface{}) string {
 	switch f := v.(type) {
 	case float32:
@@ -17,32 +46,417 @@ func toFloatString(v interface{}) string {
 	}
 }
 
+// bigNumberString возвращает точное десятичное представление *big.Int/*big.Float
+// (и их значений). Их String() не годится: у big.Float он округляет до 10
+// значащих цифр. special — значение не число (nil, ±Inf), s тогда — токен
+// для вывода строкой или null.
+func bigNumberString(v any) (s string, special, ok bool) {
+	switch x := v.(type) {
+	case *big.Int:
+		if x == nil {
+			return "", true, true
+		}
+		return x.String(), false, true
+	case big.Int:
+		return x.String(), false, true
+	case *big.Float:
+		if x == nil {
+			return "", true, true
+		}
+		return bigFloatString(x)
+	case big.Float:
+		return bigFloatString(&x)
+	}
+	return "", false, false
+}
+
+func bigFloatString(x *big.Float) (string, bool, bool) {
+	if x.IsInf() {
+		if x.Signbit() {
+			return "-Infinity", true, true
+		}
+		return "Infinity", true, true
+	}
+	// -1 — минимум цифр, однозначно задающий значение при его точности
+	return x.Text('f', -1), false, true
+}
+
+// bytesOf копирует элементы []byte, [N]byte или их аналогов с именованным
+// байтовым типом элемента ([16]MyByte) в []byte. reflect.Copy для последних
+// не годится: он требует совпадения типов элементов.
+func bytesOf(rv reflect.Value) []byte {
+	bs := make([]byte, rv.Len())
+	if rv.Type().Elem() == reflect.TypeOf(byte(0)) {
+		reflect.Copy(reflect.ValueOf(bs), rv)
+		return bs
+	}
+	for i := range bs {
+		bs[i] = byte(rv.Index(i).Uint())
+	}
+	return bs
+}
+
+// allStringFields сообщает, что все значения полей — string (не именованные
+// строковые типы: у тех могут быть String()/Error()).
+func allStringFields(fields map[string]any) bool {
+	for _, v := range fields {
+		if _, ok := v.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// hasScalarElems сообщает, что элементы slice/array можно выводить без упаковки
+// в any: скалярный kind и нет методов, в том числе с pointer receiver'ом
+// (иначе сработали бы Stringer/error/Duration).
+func hasScalarElems(rv reflect.Value) bool {
+	et := rv.Type().Elem()
+	if et.NumMethod() > 0 || reflect.PointerTo(et).NumMethod() > 0 {
+		return false
+	}
+	switch et.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		// uintptr — не здесь: с HexPointers он выводится иначе, чем число
+		return true
+	}
+	return false
+}
+
+// pointerHex — адрес uintptr или unsafe.Pointer (в том числе именованных
+// типов) строкой "0x...", для опции HexPointers форматтеров.
+func pointerHex(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Uintptr:
+		return "0x" + strconv.FormatUint(rv.Uint(), 16), true
+	case reflect.UnsafePointer:
+		return "0x" + strconv.FormatUint(uint64(rv.Pointer()), 16), true
+	}
+	return "", false
+}
+
+var (
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// interfaceOf — v.Interface(), но если fmt.Stringer/error реализован только на
+// указателе (func (*T) String()), возвращает указатель: для адресуемого v — его
+// адрес, иначе адрес копии. Так метод срабатывает и для значений из полей,
+// элементов срезов и map.
+func interfaceOf(v reflect.Value) any {
+	t := v.Type()
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface ||
+		t.Implements(stringerType) || t.Implements(errorType) {
+		return v.Interface()
+	}
+	pt := reflect.PointerTo(t)
+	if !pt.Implements(stringerType) && !pt.Implements(errorType) {
+		return v.Interface()
+	}
+	if !v.CanAddr() {
+		cp := reflect.New(t).Elem()
+		cp.Set(v)
+		v = cp
+	}
+	return v.Addr().Interface()
+}
+
+// isNilValue сообщает, что v — nil любого вида: nil-интерфейс или nil-указатель,
+// map, срез, функция, канал, unsafe.Pointer внутри непустого интерфейса.
+// Форматтеры выводят такие значения как null и не вызывают у них String/Error:
+// метод с nil-получателем обычно паникует.
+func isNilValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan,
+		reflect.Interface, reflect.UnsafePointer:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// keySet — ключи, уже выведенные в объект.
+type keySet map[string]struct{}
+
+// unique возвращает key, если он ещё не выведен, иначе key с первым свободным
+// номером ("id#2", "id#3", ...), и отмечает результат выведенным. nil-набор
+// ничего не проверяет: для объектов, где повторов быть не может.
+func (s keySet) unique(key string) string {
+	if s == nil {
+		return key
+	}
+	if _, dup := s[key]; dup {
+		for i := 2; ; i++ {
+			k := key + dupKeySep + strconv.Itoa(i)
+			if _, dup := s[k]; !dup {
+				key = k
+				break
+			}
+		}
+	}
+	s[key] = struct{}{}
+	return key
+}
+
+// mapEntry — элемент map с ключом, приведённым к строке.
+type mapEntry struct {
+	key   string
+	value reflect.Value
+	raw   reflect.Value // исходный ключ
+}
+
+// sortedMapEntries возвращает элементы map, отсортированные по строковому ключу.
+// Нестроковые ключи приводятся к строке только при stringify: fmt.Stringer
+// (в том числе на указателе), иначе числа и bool через strconv. ok=false —
+// ключ не приводится. Разные ключи с одной строкой (1 и "1" в map[any]any,
+// Stringer'ы с одинаковым String()) разводит dedupeMapKeys.
+func sortedMapEntries(rv reflect.Value, stringify bool) (entries []mapEntry, ok bool) {
+	if rv.Type().Key().Kind() != reflect.String && !stringify {
+		return nil, false
+	}
+	entries = make([]mapEntry, 0, rv.Len())
+	it := rv.MapRange()
+	for it.Next() {
+		k, ok := mapKeyString(it.Key())
+		if !ok {
+			return nil, false
+		}
+		entries = append(entries, mapEntry{key: k, value: it.Value(), raw: it.Key()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	if rv.Type().Key().Kind() != reflect.String {
+		dedupeMapKeys(entries)
+	}
+	return entries, true
+}
+
+// dedupeMapKeys нумерует повторные строковые ключи в entries, отсортированных
+// по key: внутри группы порядок задают тип и %#v исходного ключа (и значения),
+// первый элемент сохраняет ключ, остальные получают номер, как в keySet.unique.
+func dedupeMapKeys(entries []mapEntry) {
+	var used keySet
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && entries[j].key == entries[i].key {
+			j++
+		}
+		if j-i > 1 {
+			if used == nil {
+				used = make(keySet, len(entries))
+				for _, e := range entries {
+					used[e.key] = struct{}{}
+				}
+			}
+			group := entries[i:j]
+			sort.Slice(group, func(a, b int) bool { return mapEntryID(group[a]) < mapEntryID(group[b]) })
+			for n := 1; n < len(group); n++ {
+				group[n].key = used.unique(group[n].key)
+			}
+		}
+		i = j
+	}
+}
+
+func mapEntryID(e mapEntry) string {
+	k := interfaceOf(e.raw)
+	return fmt.Sprintf("%T %#v %#v", k, k, interfaceOf(e.value))
+}
+
+func mapKeyString(k reflect.Value) (string, bool) {
+	// ключи map[any]... — по динамическому значению
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+	// строковые ключи — как есть, как в encoding/json
+	if k.Kind() == reflect.String {
+		return k.String(), true
+	}
+	if s, ok := interfaceOf(k).(fmt.Stringer); ok && !isNilValue(s) {
+		return s.String(), true
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(k.Float(), 'g', -1, k.Type().Bits()), true
+	case reflect.Bool:
+		return strconv.FormatBool(k.Bool()), true
+	}
+	return "", false
+}
+
+// estimateValueSize — грубая оценка размера значения поля без его обхода:
+// строки — по длине (+кавычки), скаляры — константой, контейнеры — по числу элементов.
+func estimateValueSize(v any) int {
+	switch x := v.(type) {
+	case nil:
+		return 4
+	case string:
+		return len(x) + 2
+	case []byte:
+		return len(x)*4/3 + 4
+	case bool:
+		return 5
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return 8
+	case float32, float64:
+		return 12
+	case time.Time:
+		return 30
+	case map[string]any:
+		n := 2
+		for k, e := range x {
+			n += len(k) + 4 + estimateValueSize(e)
+		}
+		return n
+	case []any:
+		n := 2
+		for _, e := range x {
+			n += 1 + estimateValueSize(e)
+		}
+		return n
+	}
+	return 32
+}
+
+var syncMapType = reflect.TypeOf((*sync.Map)(nil)).Elem()
+
+// syncMapToMap копирует содержимое sync.Map (по значению или указателю) в
+// map[string]any через Range; ключи приводятся к строке через fmt.Sprint.
+// ok=false — v не sync.Map (или nil-указатель на него).
+func syncMapToMap(v any) (m map[string]any, ok bool) {
+	var sm *sync.Map
+	if p, isPtr := v.(*sync.Map); isPtr {
+		if p == nil {
+			return nil, false
+		}
+		sm = p
+	} else {
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || rv.Type() != syncMapType {
+			return nil, false
+		}
+		// значение из интерфейса неадресуемо — Range вызываем на копии
+		cp := reflect.New(syncMapType)
+		cp.Elem().Set(rv)
+		sm = cp.Interface().(*sync.Map)
+	}
+
+	m = make(map[string]any)
+	sm.Range(func(k, val any) bool {
+		m[fmt.Sprint(k)] = val
+		return true
+	})
+	return m, true
+}
+
+// visitKey — узел обхода: адрес вместе с типом. Одного адреса мало: указатель
+// на первое поле структуры совпадает с адресом самой структуры, хотя циклом не является.
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// visitSet — состояние обхода одного значения записи: узлы (указатели,
+// контейнеры) в текущем стеке и общий на запись бюджет элементов (MaxElements).
+type visitSet = *walkState
+
+type walkState struct {
+	nodes map[visitKey]struct{}
+	// left — сколько ещё элементов контейнеров можно вывести; < 0 — без ограничения
+	left int
+	// marked — маркер исчерпания уже выведен (он один на запись)
+	marked bool
+}
+
+// newVisitSet создаёт состояние обхода; maxElements <= 0 — без ограничения.
+func newVisitSet(maxElements int) visitSet {
+	left := -1
+	if maxElements > 0 {
+		left = maxElements
+	}
+	return &walkState{nodes: make(map[visitKey]struct{}), left: left}
+}
+
+// take списывает из бюджета один элемент; false — бюджет исчерпан.
+func (w *walkState) take() bool {
+	if w == nil || w.left < 0 {
+		return true
+	}
+	if w.left == 0 {
+		return false
+	}
+	w.left--
+	return true
+}
+
+// fits списывает n элементов, если бюджета хватает на весь контейнер, — для
+// быстрых путей, которые выводят контейнер целиком.
+func (w *walkState) fits(n int) bool {
+	if w == nil || w.left < 0 {
+		return true
+	}
+	if n > w.left {
+		return false
+	}
+	w.left -= n
+	return true
+}
+
+// markTruncated сообщает, что маркер исчерпания бюджета нужно вывести здесь:
+// true только при первом вызове, последующие контейнеры просто обрываются.
+func (w *walkState) markTruncated() bool {
+	if w.marked {
+		return false
+	}
+	w.marked = true
+	return true
+}
+
 // Возвращает ok=false, если rv уже встречался в текущем стеке обхода.
 // release() нужно вызвать при выходе из узла (обычно через defer).
-func markAndCheck(rv reflect.Value, visited map[uintptr]struct{}) (ok bool, release func()) {
+func markAndCheck(rv reflect.Value, visited visitSet) (ok bool, release func()) {
+	var p uintptr
 	switch rv.Kind() {
 	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan, reflect.UnsafePointer:
-		p := rv.Pointer()
-		if p != 0 {
-			if _, seen := visited[p]; seen {
-				return false, func() {}
-			}
-			visited[p] = struct{}{}
-			return true, func() { delete(visited, p) }
-		}
+		p = rv.Pointer()
 	case reflect.Struct:
 		if rv.CanAddr() {
-			p := rv.Addr().Pointer()
-			if p != 0 {
-				if _, seen := visited[p]; seen {
-					return false, func() {}
-				}
-				visited[p] = struct{}{}
-				return true, func() { delete(visited, p) }
-			}
+			p = rv.Addr().Pointer()
 		}
 	}
-	return true, func() {}
+	if p == 0 {
+		return true, func() {}
+	}
+	key := visitKey{ptr: p, typ: rv.Type()}
+	if _, seen := visited.nodes[key]; seen {
+		return false, func() {}
+	}
+	visited.nodes[key] = struct{}{}
+	return true, func() { delete(visited.nodes, key) }
+}
+
+// truncateKey обрезает ключ длиннее max байт (по границе символа) и добавляет
+// маркер "…". max <= 0 — без ограничения.
+func truncateKey(k string, max int) string {
+	if max <= 0 || len(k) <= max {
+		return k
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(k[cut]) {
+		cut--
+	}
+	return k[:cut] + truncatedKeyMarker
 }
 
 // addMultilinePrefix вставляет префикс "│ " после каждого перевода строки.
@@ -55,3 +469,44 @@ func addMultilinePrefix(s string) string {
 	s = strings.ReplaceAll(s, "\r\n", "\n")
 	return strings.ReplaceAll(s, "\n", "\n│ ")
 }
+
+// formatTime убирает монотонную составляющую (Round(0)), чтобы логически равные
+// моменты печатались одинаково. При precision > 0 время усекается до неё и
+// печатается с фиксированным числом знаков дробной части.
+func formatTime(t time.Time, precision time.Duration) string {
+	t = t.Round(0)
+	if precision <= 0 {
+		return t.Format(time.RFC3339Nano)
+	}
+	return t.Truncate(precision).Format("2006-01-02T15:04:05" + fractionLayout(precision) + "Z07:00")
+}
+
+// formatRelative печатает смещение от старта в секундах: "+0.123s". Точность —
+// как у formatTime, по умолчанию миллисекунды.
+func formatRelative(d, precision time.Duration) string {
+	if precision <= 0 {
+		precision = time.Millisecond
+	}
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	d = d.Truncate(precision)
+	digits := max(len(fractionLayout(precision))-1, 0)
+	return sign + strconv.FormatFloat(d.Seconds(), 'f', digits, 64) + "s"
+}
+
+// fractionLayout — дробная часть секунд в раскладке для заданной точности:
+// "" для секунд, ".000" для миллисекунд, ".000000" для микросекунд, иначе наносекунды.
+func fractionLayout(precision time.Duration) string {
+	switch {
+	case precision >= time.Second:
+		return ""
+	case precision >= time.Millisecond:
+		return ".000"
+	case precision >= time.Microsecond:
+		return ".000000"
+	default:
+		return ".000000000"
+	}
+}
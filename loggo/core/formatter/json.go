@@ -3,9 +3,11 @@ package formatter
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"funchooooza-ossh/loggo/core"
 	"math"
+	"math/big"
 	"reflect"
 	"sort"
 	"strconv"
@@ -13,20 +15,169 @@ import (
 	"time"
 )
 
+// KeyCollisionPolicy — обработка пользовательских полей с именем служебного ключа.
+// Действует только при выводе полей на верхнем уровне (пустой FieldsKey).
+type KeyCollisionPolicy int
+
+const (
+	// KeyCollisionPrefix — поле переименовывается в "fields.<key>", служебное
+	// значение сохраняется. Политика по умолчанию. Если "fields.<key>" уже есть
+	// среди полей, переименованное получает номер: "fields.<key>#2".
+	KeyCollisionPrefix KeyCollisionPolicy = iota
+	// KeyCollisionOverwrite — last-wins: пишется значение поля, служебное опускается.
+	KeyCollisionOverwrite
+	// KeyCollisionDrop — поле отбрасывается, служебное значение сохраняется.
+	KeyCollisionDrop
+)
+
+func (p KeyCollisionPolicy) String() string {
+	switch p {
+	case KeyCollisionOverwrite:
+		return "overwrite"
+	case KeyCollisionPrefix:
+		return "prefix"
+	case KeyCollisionDrop:
+		return "drop"
+	default:
+		return "unknown"
+	}
+}
+
+// ByteEncoding задаёт, как []byte выводится строкой в JSON.
+type ByteEncoding int
+
+const (
+	ByteEncodingStd    ByteEncoding = iota // base64.StdEncoding (по умолчанию, как encoding/json)
+	ByteEncodingURL                        // base64.URLEncoding: '-' и '_' вместо '+' и '/'
+	ByteEncodingRawStd                     // base64.RawStdEncoding: без '=' в конце
+	ByteEncodingRawURL                     // base64.RawURLEncoding
+	ByteEncodingHex                        // шестнадцатеричная строка
+)
+
+func (e ByteEncoding) String() string {
+	switch e {
+	case ByteEncodingStd:
+		return "std"
+	case ByteEncodingURL:
+		return "url"
+	case ByteEncodingRawStd:
+		return "rawstd"
+	case ByteEncodingRawURL:
+		return "rawurl"
+	case ByteEncodingHex:
+		return "hex"
+	default:
+		return "unknown"
+	}
+}
+
+// encode кодирует bs; неизвестное значение — как ByteEncodingStd.
+func (e ByteEncoding) encode(bs []byte) string {
+	switch e {
+	case ByteEncodingURL:
+		return base64.URLEncoding.EncodeToString(bs)
+	case ByteEncodingRawStd:
+		return base64.RawStdEncoding.EncodeToString(bs)
+	case ByteEncodingRawURL:
+		return base64.RawURLEncoding.EncodeToString(bs)
+	case ByteEncodingHex:
+		return hex.EncodeToString(bs)
+	default:
+		return base64.StdEncoding.EncodeToString(bs)
+	}
+}
+
 // JsonFormatter сериализует LogRecord в JSON-подобный формат без зависимостей.
+// Как в encoding/json, nil-map и nil-срез выводятся как null, а пустые — как {} и [].
 type JsonFormatter struct {
 	style    *core.FormatStyle
 	MaxDepth int
+	// TimePrecision задаёт точность ts и полей time.Time: time.Second, time.Millisecond,
+	// time.Microsecond или time.Nanosecond (фиксированное число знаков).
+	// 0 — RFC3339Nano без хвостовых нулей.
+	TimePrecision time.Duration
+	// FieldsKey — если задан, поля пишутся вложенным объектом под этим ключом
+	// (например "fields"), а не на верхнем уровне рядом с level/ts/msg.
+	FieldsKey string
+	// SeverityKey — если задан, рядом с level пишется числовой уровень под этим
+	// ключом (например "severity_num": 40).
+	SeverityKey string
+	// SchemaVersion — если задан, в каждую запись добавляется поле schema_version.
+	SchemaVersion string
+	// ExpandSyncMap выводит sync.Map как объект с отсортированными ключами
+	// (иначе он выглядит как пустая структура).
+	ExpandSyncMap bool
+	// MaxKeyLen ограничивает длину ключей (в байтах) на всех уровнях; 0 — без ограничения.
+	MaxKeyLen int
+	// InitialBufferSize — заранее выделяемая ёмкость буфера под запись; 0 — без
+	// предвыделения. Полезно, когда записи стабильно крупные. Больше 64 КиБ не
+	// выделяется: буферы крупнее не возвращаются в пул, и предвыделение
+	// оборачивалось бы новым буфером на каждую запись.
+	InitialBufferSize int
+	// OmitEmptyNested опускает поля, чьё значение после фильтрации (omitempty и т.п.)
+	// оказалось пустым объектом {} или массивом [].
+	OmitEmptyNested bool
+	// OmitEmptyMessage не выводит "msg", если сообщение пустое (метрики и т.п.).
+	// По умолчанию пустой "msg" остаётся для стабильности схемы.
+	OmitEmptyMessage bool
+	// TrimMessage обрезает пробельные символы (в том числе \n) по краям
+	// сообщения; переводы строк внутри сохраняются. По умолчанию сообщение
+	// выводится как есть. Срабатывает до OmitEmptyMessage.
+	TrimMessage bool
+	// StringifyMapKeys выводит map с нестроковыми ключами (int, fmt.Stringer, ...),
+	// приводя ключи к строке; без него такие map — "<unsupported_map_key>".
+	StringifyMapKeys bool
+	// StructFieldOrder — порядок полей структур: по алфавиту (по умолчанию) или
+	// в порядке объявления, как в encoding/json.
+	StructFieldOrder StructFieldOrder
+	// ColumnarSlices выводит срез структур по столбцам: {"id":[1,2],"name":["a","b"]}
+	// вместо [{"id":1,"name":"a"},...] — короче для больших однородных срезов.
+	// Поля, отсутствующие в элементе (omitempty), выводятся как null.
+	ColumnarSlices bool
+	// RelativeToStart выводит время записи как смещение от StartTime ("+0.123s")
+	// вместо абсолютного; точность — TimePrecision (по умолчанию миллисекунды).
+	RelativeToStart bool
+	// StartTime — база для RelativeToStart; конструктор ставит момент создания.
+	StartTime time.Time
+	// TimeFunc, если задан, форматирует время записи (ts) целиком: перекрывает
+	// TimePrecision и RelativeToStart. Поля time.Time он не затрагивает.
+	TimeFunc func(time.Time) string
+	// MaxElements — бюджет элементов срезов, массивов и map на всю запись: он
+	// общий для всех полей и уровней вложенности, так что запись не раздуть
+	// и множеством средних срезов. Когда бюджет кончается, выводится один
+	// маркер "<max_elements>", остальные элементы (и дальнейших контейнеров)
+	// опускаются. 0 — без ограничения.
+	MaxElements int
+	// KeyCase нормализует ключи полей, вложенных map и структур (snake_case,
+	// camelCase, kebab-case). Перекрытие служебных ключей (KeyCollision)
+	// проверяется уже по нормализованным именам.
+	KeyCase KeyCase
+	// HexPointers выводит uintptr и unsafe.Pointer адресом "0x...". По умолчанию
+	// выключено: сырые адреса в логах обычно не нужны (uintptr — числом,
+	// unsafe.Pointer — "<unsupported:unsafe.Pointer>").
+	HexPointers bool
+	// ByteEncoding — кодировка []byte (и [N]byte): base64 в вариантах Std, URL,
+	// RawStd, RawURL или hex. По умолчанию base64.StdEncoding.
+	ByteEncoding ByteEncoding
+	// FieldLayout — порядок служебных полей level/ts/caller/msg; seq идёт за ts,
+	// SeverityKey — за level. По умолчанию level, ts, caller, msg.
+	FieldLayout FieldLayout
+	// BigFloatAsString выводит *big.Float строкой ("3.14"), а не JSON-числом:
+	// многие парсеры читают числа в float64 и теряют точность. *big.Int всегда
+	// выводится числом.
+	BigFloatAsString bool
+	// KeyCollision — что делать с полем, чьё имя совпало со служебным ключом
+	// (level, ts, msg, ...). По умолчанию KeyCollisionPrefix.
+	KeyCollision KeyCollisionPolicy
+	// OnError получает некритичные предупреждения (например, о перекрытии
+	// служебного ключа полем); запись при этом не теряется.
+	OnError func(err error)
 }
 
 // NewJsonFormatter создаёт JsonFormatter с заданным стилем (или дефолтным).
+// maxDepth == nil, 0 или < 0 — глубина по умолчанию (3).
 func NewJsonFormatter(style *core.FormatStyle, maxDepth *int) *JsonFormatter {
-	var depth int
-	if maxDepth == nil {
-		depth = defaultDepth
-	} else {
-		depth = *maxDepth
-	}
+	depth := depthOrDefault(maxDepth)
 
 	if style == nil {
 		style = &core.FormatStyle{
@@ -38,30 +189,50 @@ func NewJsonFormatter(style *core.FormatStyle, maxDepth *int) *JsonFormatter {
 			Reset:       "\033[0m",
 		}
 	}
-	return &JsonFormatter{style: style, MaxDepth: depth}
+	return &JsonFormatter{style: style, MaxDepth: depth, StartTime: time.Now()}
+}
+
+// Reset возвращает форматтер к состоянию после конструктора: StartTime —
+// текущий момент. Других данных между вызовами Format форматтер не хранит,
+// так что после Reset его можно переиспользовать, например между прогонами
+// бенчмарка, вместо создания нового.
+func (f *JsonFormatter) Reset() {
+	f.StartTime = time.Now()
 }
 
 // Format преобразует LogRecord в JSON-байты.
 func (f *JsonFormatter) Format(r core.LogRecord) ([]byte, error) {
-	var b bytes.Buffer
+	if f.TrimMessage {
+		r.Message = strings.TrimSpace(r.Message)
+	}
+	r.Fields = f.KeyCase.applyFields(r.Fields)
+	b := f.getBuf()
+	defer putBuf(b)
 	b.WriteByte('{')
+	n := 0 // записанные члены верхнего объекта (см. writeJSONKey)
 
-	// "level"
-	writeJSONString(&b, "level")
-	b.WriteByte(':')
-	writeJSONString(&b, r.Level.String())
+	for _, fl := range f.FieldLayout.resolve(defaultJSONLayout) {
+		f.writeReserved(b, &n, r, fl)
+	}
 
-	// ,"ts"
-	b.WriteByte(',')
-	writeJSONString(&b, "ts")
-	b.WriteByte(':')
-	writeJSONString(&b, r.Timestamp.Format(time.RFC3339Nano))
+	// ,"schema_version"
+	if f.SchemaVersion != "" && !f.shadowed(r, schemaVersionKey) {
+		writeJSONKey(b, &n, schemaVersionKey)
+		writeJSONString(b, f.SchemaVersion)
+	}
 
-	// ,"msg"
-	b.WriteByte(',')
-	writeJSONString(&b, "msg")
-	b.WriteByte(':')
-	writeJSONString(&b, r.Message)
+	// ,"tags":["a","b"]
+	if len(r.Tags) > 0 && !f.shadowed(r, tagsKey) {
+		writeJSONKey(b, &n, tagsKey)
+		b.WriteByte('[')
+		for i, t := range r.Tags {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeJSONString(b, t)
+		}
+		b.WriteByte(']')
+	}
 
 	// поля
 	if len(r.Fields) > 0 {
@@ -72,20 +243,181 @@ func (f *JsonFormatter) Format(r core.LogRecord) ([]byte, error) {
 		}
 		sort.Strings(keys)
 
-		visited := make(map[uintptr]struct{})
-		for _, k := range keys {
-			b.WriteByte(',')
-			writeJSONString(&b, k)
-			b.WriteByte(':')
-			f.writeJSON(&b, r.Fields[k], 0, visited)
+		// быстрый путь: все значения — строки (частый случай); без visitSet,
+		// замыканий и общего writeJSON, вывод тот же
+		strs := f.MaxDepth > 0 && !f.OmitEmptyNested && allStringFields(r.Fields)
+		var visited visitSet
+		if !strs {
+			visited = newVisitSet(f.MaxElements)
+		}
+		if f.FieldsKey != "" {
+			// ,"<FieldsKey>":{...} — пользовательские ключи не пересекаются с level/ts/msg
+			f.writeMember(b, &n, f.FieldsKey, func(b *bytes.Buffer) {
+				b.WriteByte('{')
+				m := 0
+				// KeyCase уже применён applyFields — здесь только MaxKeyLen
+				used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(keys))
+				for _, k := range keys {
+					key := used.unique(truncateKey(k, f.MaxKeyLen))
+					if strs {
+						writeJSONKey(b, &m, key)
+						writeJSONString(b, r.Fields[k].(string))
+						continue
+					}
+					f.writeMember(b, &m, key, func(b *bytes.Buffer) {
+						f.writeJSON(b, r.Fields[k], 0, visited)
+					})
+				}
+				b.WriteByte('}')
+			})
+		} else {
+			// переименованное поле ("fields.ts") или обрезанный ключ может
+			// совпасть с другим полем — повтор получает номер
+			used := make(keySet, len(keys))
+			for _, k := range keys {
+				key, ok := f.fieldKey(r, k)
+				if !ok {
+					continue
+				}
+				key = used.unique(truncateKey(key, f.MaxKeyLen))
+				if strs {
+					writeJSONKey(b, &n, key)
+					writeJSONString(b, r.Fields[k].(string))
+					continue
+				}
+				f.writeMember(b, &n, key, func(b *bytes.Buffer) {
+					f.writeJSON(b, r.Fields[k], 0, visited)
+				})
+			}
 		}
 	}
 
 	b.WriteByte('}')
-	return b.Bytes(), nil
+	// буфер вернётся в пул — отдаём копию
+	return append([]byte(nil), b.Bytes()...), nil
+}
+
+// writeReserved выводит служебное поле fl (см. FieldLayout); n — счётчик членов
+// объекта, как у writeJSONKey.
+func (f *JsonFormatter) writeReserved(b *bytes.Buffer, n *int, r core.LogRecord, fl LayoutField) {
+	switch fl {
+	case LayoutLevel:
+		if !f.shadowed(r, "level") {
+			writeJSONKey(b, n, "level")
+			writeJSONString(b, r.Level.String())
+		}
+		// "<SeverityKey>": числовое значение уровня (0, 10, ... 50)
+		if f.SeverityKey != "" && !f.shadowed(r, f.SeverityKey) {
+			writeJSONKey(b, n, f.SeverityKey)
+			b.WriteString(strconv.Itoa(int(r.Level)))
+		}
+	case LayoutTime:
+		if !f.shadowed(r, "ts") {
+			writeJSONKey(b, n, "ts")
+			switch {
+			case f.TimeFunc != nil:
+				writeJSONString(b, f.TimeFunc(r.Timestamp))
+			case f.RelativeToStart:
+				writeJSONString(b, formatRelative(r.Timestamp.Sub(f.StartTime), f.TimePrecision))
+			default:
+				writeJSONString(b, formatTime(r.Timestamp, f.TimePrecision))
+			}
+		}
+		if r.Seq != 0 && !f.shadowed(r, "seq") {
+			writeJSONKey(b, n, "seq")
+			b.WriteString(strconv.FormatUint(r.Seq, 10))
+		}
+	case LayoutCaller:
+		if r.Caller != "" && !f.shadowed(r, "caller") {
+			writeJSONKey(b, n, "caller")
+			writeJSONString(b, r.Caller)
+		}
+	case LayoutMessage:
+		if !f.shadowed(r, "msg") && !(f.OmitEmptyMessage && r.Message == "") {
+			writeJSONKey(b, n, "msg")
+			writeJSONString(b, r.Message)
+		}
+	}
+}
+
+// SizeHint оценивает длину Format(r) без сериализации (core.SizeHinter).
+func (f *JsonFormatter) SizeHint(r core.LogRecord) int {
+	// {"level":"WARNING","ts":"2006-01-02T15:04:05.000000000Z","msg":""}
+	n := 64 + len(r.Message) + len(r.Caller) + len(f.SchemaVersion)
+	if r.Seq != 0 {
+		n += 28
+	}
+	for k, v := range r.Fields {
+		n += len(k) + 4 + estimateValueSize(v)
+	}
+	return n
+}
+
+func (f *JsonFormatter) getBuf() *bytes.Buffer {
+	b := bufPool.Get().(*bytes.Buffer)
+	b.Reset()
+	if f.InitialBufferSize > 0 {
+		// не больше maxPooledBufSize — иначе буфер не вернётся в пул
+		b.Grow(min(f.InitialBufferSize, maxPooledBufSize))
+	}
+	return b
+}
+
+// key применяет к ключу KeyCase и ограничение MaxKeyLen.
+func (f *JsonFormatter) key(k string) string {
+	return truncateKey(f.KeyCase.apply(k), f.MaxKeyLen)
+}
+
+// shadowed сообщает, что служебный ключ не пишется: пользовательское поле с тем
+// же именем перекрывает его (политика KeyCollisionOverwrite, last-wins).
+// О любом совпадении сообщается в OnError.
+func (f *JsonFormatter) shadowed(r core.LogRecord, key string) bool {
+	if f.FieldsKey != "" {
+		return false
+	}
+	if _, ok := r.Fields[key]; !ok {
+		return false
+	}
+	if f.OnError != nil {
+		f.OnError(fmt.Errorf("json formatter: field %q collides with reserved key (policy %s)", key, f.KeyCollision))
+	}
+	return f.KeyCollision == KeyCollisionOverwrite
+}
+
+// reservedKey сообщает, что k — служебный ключ, который пишется для этой записи.
+func (f *JsonFormatter) reservedKey(r core.LogRecord, k string) bool {
+	switch k {
+	case "level", "ts", "msg":
+		return true
+	case "seq":
+		return r.Seq != 0
+	case "caller":
+		return r.Caller != ""
+	case schemaVersionKey:
+		return f.SchemaVersion != ""
+	case tagsKey:
+		return len(r.Tags) > 0
+	}
+	return f.SeverityKey != "" && k == f.SeverityKey
+}
+
+// fieldKey возвращает ключ, под которым пишется пользовательское поле, с учётом
+// политики совпадений со служебными ключами; ok=false — поле не пишется.
+func (f *JsonFormatter) fieldKey(r core.LogRecord, k string) (key string, ok bool) {
+	if !f.reservedKey(r, k) {
+		return k, true
+	}
+	switch f.KeyCollision {
+	case KeyCollisionPrefix:
+		return collisionPrefix + k, true
+	case KeyCollisionDrop:
+		return "", false
+	default:
+		return k, true
+	}
 }
 
-func (f *JsonFormatter) writeJSON(b *bytes.Buffer, v any, depth int, visited map[uintptr]struct{}) {
+func (f *JsonFormatter) writeJSON(b *bytes.Buffer, v any, depth int, visited visitSet) {
 	if depth >= f.MaxDepth {
 		writeJSONString(b, "<max_depth>")
 		return
@@ -96,6 +428,25 @@ func (f *JsonFormatter) writeJSON(b *bytes.Buffer, v any, depth int, visited map
 		return
 	}
 
+	if f.HexPointers {
+		if s, ok := pointerHex(v); ok {
+			writeJSONString(b, s)
+			return
+		}
+	}
+
+	if s, special, ok := bigNumberString(v); ok {
+		f.writeBigNumber(b, v, s, special)
+		return
+	}
+
+	if f.ExpandSyncMap {
+		if m, ok := syncMapToMap(v); ok {
+			f.writeMapStringAny(b, m, depth, visited)
+			return
+		}
+	}
+
 	switch x := v.(type) {
 	case nil:
 		b.WriteString("null")
@@ -117,10 +468,18 @@ func (f *JsonFormatter) writeJSON(b *bytes.Buffer, v any, depth int, visited map
 	case float64:
 		writeJSONFloat(b, x)
 	case time.Time:
-		writeJSONString(b, x.Format(time.RFC3339Nano))
+		writeJSONString(b, formatTime(x, f.TimePrecision))
 	case error:
+		if isNilValue(x) {
+			b.WriteString("null")
+			return
+		}
 		writeJSONString(b, x.Error())
 	case fmt.Stringer:
+		if isNilValue(x) {
+			b.WriteString("null")
+			return
+		}
 		writeJSONString(b, x.String())
 	case map[string]any:
 		f.writeMapStringAny(b, x, depth, visited)
@@ -131,7 +490,34 @@ func (f *JsonFormatter) writeJSON(b *bytes.Buffer, v any, depth int, visited map
 	}
 }
 
-func (f *JsonFormatter) writeMapStringAny(b *bytes.Buffer, m map[string]any, depth int, visited map[uintptr]struct{}) {
+// writeBigNumber выводит результат bigNumberString: big.Int — числом,
+// big.Float — числом или строкой (BigFloatAsString), nil — null.
+func (f *JsonFormatter) writeBigNumber(b *bytes.Buffer, v any, s string, special bool) {
+	switch {
+	case special && s == "":
+		b.WriteString("null")
+	case special:
+		writeJSONString(b, s)
+	case f.BigFloatAsString && isBigFloat(v):
+		writeJSONString(b, s)
+	default:
+		b.WriteString(s)
+	}
+}
+
+func isBigFloat(v any) bool {
+	switch v.(type) {
+	case *big.Float, big.Float:
+		return true
+	}
+	return false
+}
+
+func (f *JsonFormatter) writeMapStringAny(b *bytes.Buffer, m map[string]any, depth int, visited visitSet) {
+	if m == nil {
+		b.WriteString("null")
+		return
+	}
 	if ok, release := markAndCheck(reflect.ValueOf(m), visited); !ok {
 		writeJSONString(b, "<cycle>")
 		return
@@ -146,18 +532,25 @@ func (f *JsonFormatter) writeMapStringAny(b *bytes.Buffer, m map[string]any, dep
 			keys = append(keys, k)
 		}
 		sort.Strings(keys)
-		for i, k := range keys {
-			if i > 0 {
-				b.WriteByte(',')
+		n := 0
+		used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(keys))
+		for _, k := range keys {
+			if !visited.take() {
+				writeJSONElemsTruncated(b, visited, true, n)
+				break
 			}
-			writeJSONString(b, k)
-			b.WriteByte(':')
-			f.writeJSON(b, m[k], depth+1, visited)
+			f.writeMember(b, &n, used.unique(f.key(k)), func(b *bytes.Buffer) {
+				f.writeJSON(b, m[k], depth+1, visited)
+			})
 		}
 	}
 	b.WriteByte('}')
 }
-func (f *JsonFormatter) writeSliceAny(b *bytes.Buffer, a []any, depth int, visited map[uintptr]struct{}) {
+func (f *JsonFormatter) writeSliceAny(b *bytes.Buffer, a []any, depth int, visited visitSet) {
+	if a == nil {
+		b.WriteString("null")
+		return
+	}
 	if ok, release := markAndCheck(reflect.ValueOf(a), visited); !ok {
 		writeJSONString(b, "<cycle>")
 		return
@@ -167,6 +560,10 @@ func (f *JsonFormatter) writeSliceAny(b *bytes.Buffer, a []any, depth int, visit
 
 	b.WriteByte('[')
 	for i := range a {
+		if !visited.take() {
+			writeJSONElemsTruncated(b, visited, false, i)
+			break
+		}
 		if i > 0 {
 			b.WriteByte(',')
 		}
@@ -174,12 +571,17 @@ func (f *JsonFormatter) writeSliceAny(b *bytes.Buffer, a []any, depth int, visit
 	}
 	b.WriteByte(']')
 }
-func (f *JsonFormatter) writeByReflect(b *bytes.Buffer, v any, depth int, visited map[uintptr]struct{}) {
+func (f *JsonFormatter) writeByReflect(b *bytes.Buffer, v any, depth int, visited visitSet) {
 	rv := reflect.ValueOf(v)
 	if !rv.IsValid() {
 		b.WriteString("null")
 		return
 	}
+	// Stringer/error на pointer receiver'е у значения, переданного не по указателю
+	if pv := interfaceOf(rv); reflect.TypeOf(pv) != rv.Type() {
+		f.writeJSON(b, pv, depth, visited)
+		return
+	}
 
 	if ok, release := markAndCheck(rv, visited); !ok {
 		writeJSONString(b, "<cycle>")
@@ -197,7 +599,16 @@ func (f *JsonFormatter) writeByReflect(b *bytes.Buffer, v any, depth int, visite
 		b.WriteString(strconv.FormatUint(rv.Uint(), 10))
 		return
 	case reflect.Float32, reflect.Float64:
-		writeJSONFloat(b, rv.Convert(reflect.TypeOf(float64(0))).Float())
+		writeJSONFloat(b, rv.Float())
+		return
+	case reflect.Complex64, reflect.Complex128:
+		// [real, imag]; NaN/±Inf компонент — строкой, как у float
+		c := rv.Complex()
+		b.WriteByte('[')
+		writeJSONFloat(b, real(c))
+		b.WriteByte(',')
+		writeJSONFloat(b, imag(c))
+		b.WriteByte(']')
 		return
 
 	//ANCHOR: SCALARS
@@ -227,109 +638,254 @@ func (f *JsonFormatter) writeByReflect(b *bytes.Buffer, v any, depth int, visite
 	//ANCHOR: Struct
 	case reflect.Struct:
 		b.WriteByte('{')
-		t := rv.Type()
-
-		type fieldInfo struct {
-			key string
-			idx int
-		}
-		fields := make([]fieldInfo, 0, rv.NumField())
-
-		for i := 0; i < rv.NumField(); i++ {
-			sf := t.Field(i)
-			if sf.PkgPath != "" {
-				continue
-			} // unexported
-
-			key := sf.Name
-			if tag := sf.Tag.Get("json"); tag != "" {
-				parts := strings.Split(tag, ",")
-				if parts[0] == "-" {
-					continue
-				}
-				if parts[0] != "" {
-					key = parts[0]
-				}
-				for _, opt := range parts[1:] {
-					if opt == "omitempty" && rv.Field(i).IsZero() {
-						key = ""
-						break
-					}
-				}
-				if key == "" {
-					continue
+		n := 0
+		fields := structFields(rv, f.StructFieldOrder)
+		used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(fields))
+		for _, sf := range fields {
+			f.writeMember(b, &n, used.unique(f.key(sf.key)), func(b *bytes.Buffer) {
+				if sf.quoted {
+					f.writeQuoted(b, sf.value, depth+1, visited)
+					return
 				}
-			}
-			fields = append(fields, fieldInfo{key: key, idx: i})
-		}
-
-		sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
-
-		for i, fi := range fields {
-			if i > 0 {
-				b.WriteByte(',')
-			}
-			writeJSONString(b, fi.key)
-			b.WriteByte(':')
-			f.writeJSON(b, rv.Field(fi.idx).Interface(), depth+1, visited)
+				f.writeJSON(b, interfaceOf(sf.value), depth+1, visited)
+			})
 		}
 		b.WriteByte('}')
 
 	//ANCHOR: Map
 	case reflect.Map:
-		if rv.Type().Key().Kind() != reflect.String {
-			writeJSONString(b, "<unsupported_map_key>")
+		if rv.IsNil() {
+			b.WriteString("null")
 			return
 		}
-		keys := rv.MapKeys()
-		ss := make([]string, len(keys))
-		for i, k := range keys {
-			ss[i] = k.String()
+		entries, ok := sortedMapEntries(rv, f.StringifyMapKeys)
+		if !ok {
+			writeJSONString(b, "<unsupported_map_key>")
+			return
 		}
-		sort.Strings(ss)
 
 		b.WriteByte('{')
-		for i, k := range ss {
-			if i > 0 {
-				b.WriteByte(',')
+		n := 0
+		used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(entries))
+		for _, e := range entries {
+			if !visited.take() {
+				writeJSONElemsTruncated(b, visited, true, n)
+				break
 			}
-			writeJSONString(b, k)
-			b.WriteByte(':')
-			f.writeJSON(b, rv.MapIndex(reflect.ValueOf(k)).Interface(), depth+1, visited)
+			f.writeMember(b, &n, used.unique(f.key(e.key)), func(b *bytes.Buffer) {
+				f.writeJSON(b, interfaceOf(e.value), depth+1, visited)
+			})
 		}
 		b.WriteByte('}')
 
 	//ANCHOR: SLICE, ARRAYS, BYTE
 	case reflect.Slice, reflect.Array:
-		// NOTE: []byte / [N]byte / alias of []byte -> base64 string
+		// nil-срез — null, пустой — [] (как в encoding/json)
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			b.WriteString("null")
+			return
+		}
+		// NOTE: []byte / [N]byte / alias of []byte -> строка в кодировке ByteEncoding
 		if rv.Type().Elem().Kind() == reflect.Uint8 {
-			n := rv.Len()
-			bs := make([]byte, n)
-			// скопируем в bs и для slice, и для array, и для алиасов
-			reflect.Copy(reflect.ValueOf(bs), rv)
-			writeJSONString(b, base64.StdEncoding.EncodeToString(bs))
+			// и для slice, и для array, и для алиасов
+			writeJSONString(b, f.ByteEncoding.encode(bytesOf(rv)))
+			return
+		}
+		if depth+1 < f.MaxDepth && hasScalarElems(rv) && visited.fits(rv.Len()) {
+			writeJSONScalarSlice(b, rv)
+			return
+		}
+		if f.ColumnarSlices && rv.Len() > 0 && isColumnarElem(rv.Type().Elem()) && visited.fits(rv.Len()) {
+			f.writeColumnar(b, rv, depth, visited)
 			return
 		}
 		n := rv.Len()
 		b.WriteByte('[')
 		for i := 0; i < n; i++ {
+			if !visited.take() {
+				writeJSONElemsTruncated(b, visited, false, i)
+				break
+			}
 			if i > 0 {
 				b.WriteByte(',')
 			}
-			f.writeJSON(b, rv.Index(i).Interface(), depth+1, visited)
+			f.writeJSON(b, interfaceOf(rv.Index(i)), depth+1, visited)
 		}
 		b.WriteByte(']')
 
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if rv.IsNil() {
+			b.WriteString("null")
+			return
+		}
+		writeJSONString(b, fmt.Sprintf("<unsupported:%s>", rv.Kind().String()))
+
 	default:
 		writeJSONString(b, fmt.Sprintf("<unsupported:%s>", rv.Kind().String()))
 	}
 }
 
+// writeColumnar пишет срез структур по столбцам: ключи — объединение полей всех
+// элементов (по алфавиту или в порядке объявления), значения — массивы длиной в срез.
+func (f *JsonFormatter) writeColumnar(b *bytes.Buffer, rv reflect.Value, depth int, visited visitSet) {
+	n := rv.Len()
+	rows := make([]map[string]structField, n)
+	seen := make(map[string]struct{})
+	var keys []string
+	for i := 0; i < n; i++ {
+		fields := structFields(rv.Index(i), f.StructFieldOrder)
+		row := make(map[string]structField, len(fields))
+		for _, sf := range fields {
+			row[sf.key] = sf
+			if _, ok := seen[sf.key]; !ok {
+				seen[sf.key] = struct{}{}
+				keys = append(keys, sf.key)
+			}
+		}
+		rows[i] = row
+	}
+	if f.StructFieldOrder == StructFieldsSorted {
+		sort.Strings(keys)
+	}
+
+	b.WriteByte('{')
+	m := 0
+	used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(keys))
+	for _, k := range keys {
+		f.writeMember(b, &m, used.unique(f.key(k)), func(b *bytes.Buffer) {
+			b.WriteByte('[')
+			for i, row := range rows {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				sf, ok := row[k]
+				switch {
+				case !ok:
+					b.WriteString("null")
+				case sf.quoted:
+					f.writeQuoted(b, sf.value, depth+2, visited)
+				default:
+					f.writeJSON(b, interfaceOf(sf.value), depth+2, visited)
+				}
+			}
+			b.WriteByte(']')
+		})
+	}
+	b.WriteByte('}')
+}
+
+// isColumnarElem — обычная структура: не time.Time и без собственного
+// строкового представления (Stringer/error, в том числе на указателе),
+// которое writeJSON предпочёл бы полям.
+func isColumnarElem(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t != reflect.TypeOf(time.Time{}) &&
+		!t.Implements(stringerType) && !t.Implements(errorType) &&
+		!reflect.PointerTo(t).Implements(stringerType) && !reflect.PointerTo(t).Implements(errorType)
+}
+
+// writeMember пишет член объекта `"key":value` (с запятой, если он не первый);
+// n — счётчик членов объекта, как у writeJSONKey. При OmitEmptyNested значение
+// сначала пишется во временный буфер, и если оно оказалось пустым объектом или
+// массивом, член опускается целиком (и не считается).
+func (f *JsonFormatter) writeMember(b *bytes.Buffer, n *int, key string, write func(b *bytes.Buffer)) {
+	if !f.OmitEmptyNested {
+		writeJSONKey(b, n, key)
+		write(b)
+		return
+	}
+
+	tmp := bufPool.Get().(*bytes.Buffer)
+	tmp.Reset()
+	defer putBuf(tmp)
+
+	write(tmp)
+	if v := tmp.Bytes(); string(v) == "{}" || string(v) == "[]" {
+		return
+	}
+	writeJSONKey(b, n, key)
+	b.Write(tmp.Bytes())
+}
+
+// writeQuoted реализует опцию тега ",string": значение сериализуется как обычно
+// и оборачивается в JSON-строку ({"id":"42"}). nil-указатель остаётся null.
+func (f *JsonFormatter) writeQuoted(b *bytes.Buffer, v reflect.Value, depth int, visited visitSet) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		b.WriteString("null")
+		return
+	}
+	var tmp bytes.Buffer
+	f.writeJSON(&tmp, v.Interface(), depth, visited)
+	writeJSONString(b, tmp.String())
+}
+
+// writeJSONScalarSlice — быстрый путь для []string, []int, []float64, []bool и т.п.:
+// элементы читаются через reflect без Interface(), поэтому не аллоцируются.
+// Вывод совпадает с общим путём через writeJSON.
+func writeJSONScalarSlice(b *bytes.Buffer, rv reflect.Value) {
+	var scratch [32]byte
+	kind := rv.Type().Elem().Kind()
+	n := rv.Len()
+
+	b.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		ev := rv.Index(i)
+		switch kind {
+		case reflect.String:
+			writeJSONString(b, ev.String())
+		case reflect.Bool:
+			b.Write(strconv.AppendBool(scratch[:0], ev.Bool()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.Write(strconv.AppendInt(scratch[:0], ev.Int(), 10))
+		case reflect.Float32, reflect.Float64:
+			writeJSONFloat(b, ev.Float())
+		default:
+			b.Write(strconv.AppendUint(scratch[:0], ev.Uint(), 10))
+		}
+	}
+	b.WriteByte(']')
+}
+
+// writeJSONKey пишет `"key":`, добавляя запятую, если это не первый ключ объекта.
+// *n — сколько членов объекта уже записано; увеличивается на один. Счётчик
+// заводит код, открывший объект.
+func writeJSONKey(b *bytes.Buffer, n *int, key string) {
+	if *n > 0 {
+		b.WriteByte(',')
+	}
+	*n++
+	writeJSONString(b, key)
+	b.WriteByte(':')
+}
+
+// writeJSONElemsTruncated дописывает в открытый контейнер маркер исчерпания
+// MaxElements — только в первый обрезанный контейнер записи: в массив
+// элементом "<max_elements>", в объект — членом "…":"<max_elements>".
+// n — сколько элементов (членов) контейнера уже записано.
+func writeJSONElemsTruncated(b *bytes.Buffer, visited visitSet, object bool, n int) {
+	if !visited.markTruncated() {
+		return
+	}
+	if object {
+		writeJSONKey(b, &n, truncatedKeyMarker)
+	} else if n > 0 {
+		b.WriteByte(',')
+	}
+	writeJSONString(b, maxElementsMarker)
+}
+
 func writeJSONString(b *bytes.Buffer, s string) {
 	s = addMultilinePrefix(s)
-	b.WriteString(strconv.Quote(s))
+	// AppendQuote в свободный хвост буфера — без промежуточной строки
+	b.Write(strconv.AppendQuote(b.AvailableBuffer(), s))
 }
 
+// writeJSONFloat печатает число с плавающей точкой. Целые числа сюда не
+// попадают: int*/uint* всегда идут через FormatInt/FormatUint, иначе uint64 > 2^53
+// потерял бы точность.
 func writeJSONFloat(b *bytes.Buffer, f float64) {
 	switch {
 	case math.IsNaN(f):
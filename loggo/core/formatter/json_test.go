@@ -0,0 +1,319 @@
+package formatter
+
+import (
+	"encoding/json"
+	"errors"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeJSON разбирает out, проверяя, что в объекте верхнего уровня нет
+// повторных ключей.
+func decodeJSON(t *testing.T, out []byte) map[string]any {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		t.Fatalf("not an object: %s", out)
+	}
+	seen := map[string]bool{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("invalid JSON %s: %v", out, err)
+		}
+		k := tok.(string)
+		if seen[k] {
+			t.Fatalf("duplicate key %q in %s", k, out)
+		}
+		seen[k] = true
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("invalid JSON %s: %v", out, err)
+		}
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out, err)
+	}
+	return doc
+}
+
+func collisionRecord() core.LogRecord {
+	return core.LogRecord{
+		Level: core.Warning, Timestamp: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: "builtin", Caller: "a.go:1",
+		Fields: map[string]any{"level": "user", "msg": "user", "ts": 1, "caller": "user", "x": 1},
+	}
+}
+
+func TestKeyCollisionDefaultPrefixesUserField(t *testing.T) {
+	var warnings []error
+	f := NewJsonFormatter(nil, nil)
+	f.OnError = func(err error) { warnings = append(warnings, err) }
+	out, err := f.Format(collisionRecord())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := decodeJSON(t, out)
+	if doc["level"] != "WARNING" || doc["msg"] != "builtin" || doc["caller"] != "a.go:1" {
+		t.Fatalf("builtin values lost: %s", out)
+	}
+	for _, k := range []string{"level", "msg", "caller"} {
+		if doc["fields."+k] != "user" {
+			t.Errorf("fields.%s = %v, want user: %s", k, doc["fields."+k], out)
+		}
+	}
+	if doc["fields.ts"] != 1.0 || doc["x"] != 1.0 {
+		t.Errorf("fields.ts/x wrong: %s", out)
+	}
+	if len(warnings) != 4 {
+		t.Errorf("%d warnings, want one per colliding key: %v", len(warnings), errors.Join(warnings...))
+	}
+}
+
+func TestKeyCollisionPolicies(t *testing.T) {
+	f := NewJsonFormatter(nil, nil)
+	f.KeyCollision = KeyCollisionOverwrite
+	doc := decodeJSON(t, mustFormat(t, f, collisionRecord()))
+	if doc["level"] != "user" || doc["msg"] != "user" || doc["ts"] != 1.0 {
+		t.Errorf("overwrite: %v", doc)
+	}
+
+	f.KeyCollision = KeyCollisionDrop
+	doc = decodeJSON(t, mustFormat(t, f, collisionRecord()))
+	if doc["level"] != "WARNING" || doc["msg"] != "builtin" || doc["x"] != 1.0 {
+		t.Errorf("drop: %v", doc)
+	}
+	if _, ok := doc["fields.level"]; ok {
+		t.Errorf("drop must not prefix: %v", doc)
+	}
+
+	// под FieldsKey пересечений нет
+	f.FieldsKey = "fields"
+	doc = decodeJSON(t, mustFormat(t, f, collisionRecord()))
+	if doc["level"] != "WARNING" || doc["fields"].(map[string]any)["level"] != "user" {
+		t.Errorf("FieldsKey: %v", doc)
+	}
+}
+
+// Запятые между членами ставятся по числу записанных членов, а не по
+// предыдущему байту: пропущенные члены и маркеры не ломают объект.
+func TestJSONMemberSeparators(t *testing.T) {
+	r := core.LogRecord{
+		Level: core.Info, Message: "",
+		Fields: map[string]any{
+			"a_empty": map[string]any{},
+			"b":       map[string]any{"e": []int{}, "f": 1},
+			"c":       []any{map[string]any{}, map[string]any{"g": 2}},
+			"d":       map[string]any{"h": 1, "i": 2, "j": 3},
+		},
+	}
+	layouts := []FieldLayout{nil, {LayoutMessage, LayoutLevel}, {LayoutCaller}}
+	for _, omit := range []bool{false, true} {
+		for _, fk := range []string{"", "fields"} {
+			for _, maxEl := range []int{0, 1, 3, 5} {
+				for _, layout := range layouts {
+					f := NewJsonFormatter(nil, nil)
+					f.OmitEmptyNested = omit
+					f.OmitEmptyMessage = true
+					f.FieldsKey = fk
+					f.MaxElements = maxEl
+					f.FieldLayout = layout
+					decodeJSON(t, mustFormat(t, f, r))
+				}
+			}
+		}
+	}
+}
+
+func mustFormat(t *testing.T, f core.FormatProcessor, r core.LogRecord) []byte {
+	t.Helper()
+	out, err := f.Format(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestKeyCollisionPrefixKeepsKeysUnique(t *testing.T) {
+	r := core.LogRecord{
+		Level: core.Info, Message: "m",
+		Fields: map[string]any{
+			"ts": "user ts", "fields.ts": "real", "fields.ts#2": "real2",
+			"level": "user level", "msg": "user msg",
+		},
+	}
+	for _, strs := range []bool{true, false} {
+		if !strs {
+			r.Fields["n"] = 1 // общий путь вместо строкового
+		}
+		doc := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r))
+		want := map[string]any{
+			"fields.ts": "real", "fields.ts#2": "real2", "fields.ts#3": "user ts",
+			"fields.level": "user level", "fields.msg": "user msg", "msg": "m",
+		}
+		for k, v := range want {
+			if doc[k] != v {
+				t.Errorf("%s = %v, want %v (doc %v)", k, doc[k], v, doc)
+			}
+		}
+	}
+}
+
+func TestFieldsKeyLayouts(t *testing.T) {
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{"level": "user", "n": 1}}
+
+	// по умолчанию поля — на верхнем уровне
+	doc := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r))
+	if doc["level"] != "INFO" || doc["n"] != 1.0 || doc["fields.level"] != "user" {
+		t.Errorf("inline: %v", doc)
+	}
+
+	f := NewJsonFormatter(nil, nil)
+	f.FieldsKey = "data"
+	doc = decodeJSON(t, mustFormat(t, f, r))
+	data, _ := doc["data"].(map[string]any)
+	if doc["level"] != "INFO" || len(doc) != 4 || data["level"] != "user" || data["n"] != 1.0 {
+		t.Errorf("nested: %v", doc)
+	}
+}
+
+func TestSeverityKey(t *testing.T) {
+	levels := map[core.LogLevel]float64{
+		core.Trace: 0, core.Debug: 10, core.Info: 20,
+		core.Warning: 30, core.Error: 40, core.Exception: 50,
+	}
+	for lvl, num := range levels {
+		f := NewJsonFormatter(nil, nil)
+		f.SeverityKey = "severity_num"
+		out := mustFormat(t, f, core.LogRecord{Level: lvl, Message: "m"})
+		got := decodeJSON(t, out)
+		if got["level"] != lvl.String() || got["severity_num"] != num {
+			t.Errorf("level %v: %s", lvl, out)
+		}
+		if lvl == core.Error && !strings.Contains(string(out), `"severity_num":40`) {
+			t.Errorf("error severity: %s", out)
+		}
+	}
+
+	// по умолчанию числового уровня нет
+	out := mustFormat(t, NewJsonFormatter(nil, nil), core.LogRecord{Level: core.Error, Message: "m"})
+	if got := decodeJSON(t, out); len(got) != 3 || got["level"] != "ERROR" {
+		t.Errorf("default: %s", out)
+	}
+}
+
+func TestOmitEmptyNested(t *testing.T) {
+	type inner struct {
+		N int `json:"n,omitempty"`
+	}
+	type outer struct {
+		In   inner `json:"in"`
+		Keep int   `json:"keep"`
+	}
+	type wrapper struct {
+		Only inner `json:"only"`
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"outer":   outer{Keep: 1},
+		"wrapper": wrapper{},                                       // после компактизации пуст сам
+		"list":    []any{},                                         // пустой массив
+		"elems":   []any{inner{}, 1},                               // элементы массива не опускаются
+		"set":     map[string]any{"in": inner{N: 2}, "e": inner{}}, // непустой член остаётся
+	}}
+
+	f := NewJsonFormatter(nil, nil)
+	f.OmitEmptyNested = true
+	out := mustFormat(t, f, r)
+	got := decodeJSON(t, out)
+	for _, k := range []string{"wrapper", "list"} {
+		if _, ok := got[k]; ok {
+			t.Errorf("%s not omitted: %s", k, out)
+		}
+	}
+	for _, want := range []string{`"outer":{"keep":1}`, `"elems":[{},1]`, `"set":{"in":{"n":2}}`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %s in %s", want, out)
+		}
+	}
+
+	// по умолчанию пустые объекты остаются
+	out = mustFormat(t, NewJsonFormatter(nil, nil), r)
+	for _, want := range []string{`"outer":{"in":{},"keep":1}`, `"wrapper":{"only":{}}`, `"list":[]`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("default: missing %s in %s", want, out)
+		}
+	}
+}
+
+func TestColumnarSlices(t *testing.T) {
+	type row struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+		Note string `json:"note,omitempty"`
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"rows":  []row{{ID: 1, Name: "a"}, {ID: 2, Name: "b", Note: "x"}, {ID: 3, Name: "c"}},
+		"ints":  []int{1, 2},
+		"empty": []row{},
+		"times": []time.Time{time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	rowWise := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r))
+	f := NewJsonFormatter(nil, nil)
+	f.ColumnarSlices = true
+	out := mustFormat(t, f, r)
+	if !strings.Contains(string(out), `"rows":{"id":[1,2,3],"name":["a","b","c"],"note":[null,"x",null]}`) {
+		t.Errorf("columnar: %s", out)
+	}
+	columnar := decodeJSON(t, out)
+
+	// те же значения: столбец k, строка i == rows[i][k]
+	rows := rowWise["rows"].([]any)
+	cols := columnar["rows"].(map[string]any)
+	for k, col := range cols {
+		for i, v := range col.([]any) {
+			if want := rows[i].(map[string]any)[k]; v != want {
+				t.Errorf("%s[%d] = %v, row-wise %v", k, i, v, want)
+			}
+		}
+	}
+	// не срезы структур выводятся как обычно
+	for _, k := range []string{"ints", "empty", "times"} {
+		if !reflect.DeepEqual(columnar[k], rowWise[k]) {
+			t.Errorf("%s: columnar %v, row-wise %v", k, columnar[k], rowWise[k])
+		}
+	}
+}
+
+func TestByteEncodingVariants(t *testing.T) {
+	bs := []byte{0xfb, 0xff, 0xfe, 0x01}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"b":      bs,
+		"arr":    [4]byte{0xfb, 0xff, 0xfe, 0x01},
+		"nested": map[string]any{"b": bs},
+	}}
+	cases := map[ByteEncoding]string{
+		ByteEncodingStd:    "+//+AQ==",
+		ByteEncodingURL:    "-__-AQ==",
+		ByteEncodingRawStd: "+//+AQ",
+		ByteEncodingRawURL: "-__-AQ",
+		ByteEncodingHex:    "fbfffe01",
+	}
+	for enc, want := range cases {
+		f := NewJsonFormatter(nil, nil)
+		f.ByteEncoding = enc
+		got := decodeJSON(t, mustFormat(t, f, r))
+		nested, _ := got["nested"].(map[string]any)
+		if got["b"] != want || got["arr"] != want || nested["b"] != want {
+			t.Errorf("%s: b=%v arr=%v nested=%v, want %s", enc, got["b"], got["arr"], nested["b"], want)
+		}
+	}
+	// по умолчанию — StdEncoding
+	if got := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r)); got["b"] != cases[ByteEncodingStd] {
+		t.Errorf("default: %v", got["b"])
+	}
+}
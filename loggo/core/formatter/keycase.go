@@ -0,0 +1,138 @@
+package formatter
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// KeyCase задаёт нормализацию регистра ключей полей (в том числе вложенных map
+// и полей структур) при выводе. Служебные ключи (level, ts, msg, ...) не меняются.
+type KeyCase int
+
+const (
+	KeyCaseNone  KeyCase = iota // как есть (по умолчанию)
+	KeyCaseSnake                // user_id
+	KeyCaseCamel                // userId
+	KeyCaseKebab                // user-id
+)
+
+func (c KeyCase) String() string {
+	switch c {
+	case KeyCaseNone:
+		return "none"
+	case KeyCaseSnake:
+		return "snake"
+	case KeyCaseCamel:
+		return "camel"
+	case KeyCaseKebab:
+		return "kebab"
+	default:
+		return "unknown"
+	}
+}
+
+// apply приводит ключ к регистру c. Слова делятся по не-буквенно-цифровым
+// символам и по смене регистра; аббревиатура — одно слово до последней
+// заглавной перед строчной: "UserID" и "user id" → user, id; "HTTPServer" →
+// http, server. Цифры остаются в слове.
+func (c KeyCase) apply(k string) string {
+	if c == KeyCaseNone || k == "" {
+		return k
+	}
+	words := splitKeyWords(k)
+	if len(words) == 0 {
+		return k
+	}
+
+	var b strings.Builder
+	b.Grow(len(k) + len(words))
+	for i, w := range words {
+		w = strings.ToLower(w)
+		switch c {
+		case KeyCaseSnake, KeyCaseKebab:
+			if i > 0 {
+				if c == KeyCaseSnake {
+					b.WriteByte('_')
+				} else {
+					b.WriteByte('-')
+				}
+			}
+			b.WriteString(w)
+		case KeyCaseCamel:
+			if i > 0 {
+				r := []rune(w)
+				r[0] = unicode.ToUpper(r[0])
+				w = string(r)
+			}
+			b.WriteString(w)
+		}
+	}
+	return b.String()
+}
+
+// applyFields возвращает поля записи с нормализованными ключами верхнего
+// уровня; ключи, совпавшие после нормализации ("UserID" и "user id"),
+// нумеруются по сортировке исходных имён: user_id, user_id#2. KeyCaseNone —
+// fields без копирования.
+func (c KeyCase) applyFields(fields map[string]any) map[string]any {
+	if c == KeyCaseNone || len(fields) == 0 {
+		return fields
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make(map[string]any, len(fields))
+	used := make(keySet, len(fields))
+	for _, k := range keys {
+		out[used.unique(c.apply(k))] = fields[k]
+	}
+	return out
+}
+
+// nestedKeySet возвращает набор для ключей вложенного объекта из n элементов,
+// если KeyCase или maxKeyLen могут свести разные ключи к одному, иначе nil.
+func (c KeyCase) nestedKeySet(maxKeyLen, n int) keySet {
+	if c == KeyCaseNone && maxKeyLen <= 0 {
+		return nil
+	}
+	return make(keySet, n)
+}
+
+// splitKeyWords делит ключ на слова (см. KeyCase.apply).
+func splitKeyWords(k string) []string {
+	rs := []rune(k)
+	var words []string
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && end > start {
+			words = append(words, string(rs[start:end]))
+		}
+		start = -1
+	}
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+		prev := rs[i-1]
+		switch {
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			// userId, utf8Key → граница перед заглавной
+			flush(i)
+			start = i
+		case unicode.IsLower(r) && unicode.IsUpper(prev) && i-1 > start:
+			// HTTPServer → HTTP | Server: последняя заглавная начинает слово
+			flush(i - 1)
+			start = i - 1
+		}
+	}
+	flush(len(rs))
+	return words
+}
@@ -0,0 +1,82 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+func TestKeyCaseApply(t *testing.T) {
+	cases := []struct {
+		c    KeyCase
+		want string
+	}{
+		{KeyCaseNone, ""},
+		{KeyCaseSnake, "user_id"},
+		{KeyCaseCamel, "userId"},
+		{KeyCaseKebab, "user-id"},
+	}
+	for _, tc := range cases {
+		for _, k := range []string{"UserID", "user id", "user_id", "userId"} {
+			want := tc.want
+			if tc.c == KeyCaseNone {
+				want = k
+			}
+			if got := tc.c.apply(k); got != want {
+				t.Errorf("%s(%q) = %q, want %q", tc.c, k, got, want)
+			}
+		}
+	}
+	if got := KeyCaseSnake.apply("HTTPServer2Addr"); got != "http_server2_addr" {
+		t.Errorf("acronym: %q", got)
+	}
+}
+
+func TestKeyCaseCollisionsNumbered(t *testing.T) {
+	type user struct {
+		UserID  int
+		User_ID int `json:"user id"`
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"UserID":  1,
+		"user id": 2,
+		"nested":  map[string]any{"UserID": 3, "user id": 4},
+		"struct":  user{5, 6},
+	}}
+
+	f := NewJsonFormatter(nil, nil)
+	f.KeyCase = KeyCaseSnake
+	out := mustFormat(t, f, r)
+	got := decodeJSON(t, out)
+	// повтор нумеруется по сортировке исходных имён: "UserID" < "user id"
+	if got["user_id"] != 1.0 || got["user_id#2"] != 2.0 {
+		t.Errorf("top level: %s", out)
+	}
+	nested, _ := got["nested"].(map[string]any)
+	if len(nested) != 2 || nested["user_id"] != 3.0 || nested["user_id#2"] != 4.0 {
+		t.Errorf("nested: %s", out)
+	}
+	st, _ := got["struct"].(map[string]any)
+	if len(st) != 2 || st["user_id"] != 5.0 || st["user_id#2"] != 6.0 {
+		t.Errorf("struct: %s", out)
+	}
+	if n := strings.Count(string(out), `"user_id":`); n != 3 {
+		t.Errorf("%d plain user_id keys, want 3: %s", n, out)
+	}
+
+	f.FieldsKey = "fields"
+	got = decodeJSON(t, mustFormat(t, f, r))
+	fields, _ := got["fields"].(map[string]any)
+	if fields["user_id"] != 1.0 || fields["user_id#2"] != 2.0 {
+		t.Errorf("FieldsKey: %v", got["fields"])
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.KeyCase = KeyCaseCamel
+	text := string(mustFormat(t, tf, r))
+	for _, want := range []string{"userId=1", "userId#2=2", "{userId: 3, userId#2: 4}", "{userId: 5, userId#2: 6}"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text: missing %q in %s", want, text)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+func TestMaxKeyLenTruncatesKeys(t *testing.T) {
+	long := strings.Repeat("k", 1<<20)
+	type wide struct {
+		Field int `json:"abcdefghijkl"`
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		long:     1,
+		"short":  2,
+		"nested": map[string]any{long: 3, "ключключ": 4},
+		"struct": wide{5},
+	}}
+
+	jf := NewJsonFormatter(nil, nil)
+	jf.MaxKeyLen = 8
+	out := mustFormat(t, jf, r)
+	if len(out) > 1024 {
+		t.Fatalf("output not bounded: %d bytes", len(out))
+	}
+	got := decodeJSON(t, out)
+	if got["kkkkkkkk…"] != 1.0 || got["short"] != 2.0 {
+		t.Errorf("top level: %s", out)
+	}
+	nested, _ := got["nested"].(map[string]any)
+	// обрезка по границе руны: 8 байт — 4 кириллические буквы
+	if nested["kkkkkkkk…"] != 3.0 || nested["ключ…"] != 4.0 {
+		t.Errorf("nested: %s", out)
+	}
+	if st, _ := got["struct"].(map[string]any); st["abcdefgh…"] != 5.0 {
+		t.Errorf("struct: %s", out)
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.MaxKeyLen = 8
+	text := string(mustFormat(t, tf, r))
+	for _, want := range []string{"kkkkkkkk…=1", "short=2", "{kkkkkkkk…: 3, ключ…: 4}", "{abcdefgh…: 5}"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text: missing %q in %.200s", want, text)
+		}
+	}
+
+	// по умолчанию без ограничения
+	if out := mustFormat(t, NewJsonFormatter(nil, nil), r); len(out) < 1<<21 {
+		t.Errorf("default truncated keys: %d bytes", len(out))
+	}
+}
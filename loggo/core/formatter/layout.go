@@ -0,0 +1,39 @@
+package formatter
+
+// LayoutField — служебное поле записи, чьё место в строке задаёт FieldLayout.
+type LayoutField uint8
+
+const (
+	LayoutLevel   LayoutField = iota // level (в JSON вместе с SeverityKey)
+	LayoutTime                       // ts (вместе с seq)
+	LayoutCaller                     // caller
+	LayoutMessage                    // msg
+)
+
+// FieldLayout — порядок служебных полей, например
+// FieldLayout{LayoutLevel, LayoutTime, LayoutMessage} для `level ts msg`.
+// Пропущенные поля идут следом в порядке по умолчанию, повторы игнорируются;
+// nil — порядок по умолчанию (JSON: level ts caller msg, текст: ts level caller msg).
+type FieldLayout []LayoutField
+
+var (
+	defaultJSONLayout = FieldLayout{LayoutLevel, LayoutTime, LayoutCaller, LayoutMessage}
+	defaultTextLayout = FieldLayout{LayoutTime, LayoutLevel, LayoutCaller, LayoutMessage}
+)
+
+// resolve дополняет порядок недостающими полями из def.
+func (l FieldLayout) resolve(def FieldLayout) FieldLayout {
+	if len(l) == 0 {
+		return def
+	}
+	var seen [LayoutMessage + 1]bool
+	out := make(FieldLayout, 0, len(def))
+	for _, fl := range append(l[:len(l):len(l)], def...) {
+		if fl > LayoutMessage || seen[fl] {
+			continue
+		}
+		seen[fl] = true
+		out = append(out, fl)
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// permutations — все перестановки fs.
+func permutations(fs FieldLayout) []FieldLayout {
+	if len(fs) <= 1 {
+		return []FieldLayout{append(FieldLayout(nil), fs...)}
+	}
+	var out []FieldLayout
+	for i := range fs {
+		rest := append(append(FieldLayout(nil), fs[:i]...), fs[i+1:]...)
+		for _, p := range permutations(rest) {
+			out = append(out, append(FieldLayout{fs[i]}, p...))
+		}
+	}
+	return out
+}
+
+// jsonKeys — ключи объекта верхнего уровня в порядке вывода.
+func jsonKeys(t *testing.T, out []byte) []string {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(string(out)))
+	dec.Token()
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("invalid JSON %s: %v", out, err)
+		}
+		keys = append(keys, tok.(string))
+		var v any
+		dec.Decode(&v)
+	}
+	return keys
+}
+
+func TestFieldLayoutOrders(t *testing.T) {
+	r := core.LogRecord{
+		Level: core.Warning, Timestamp: time.Date(2025, 8, 14, 10, 0, 0, 0, time.UTC),
+		Message: "text", Caller: "a.go:1", Fields: map[string]any{"k": 1},
+	}
+	jsonKey := map[LayoutField]string{LayoutLevel: "level", LayoutTime: "ts", LayoutCaller: "caller", LayoutMessage: "msg"}
+	textToken := map[LayoutField]string{LayoutLevel: "WARNING", LayoutTime: "[2025-08-14", LayoutCaller: "a.go:1", LayoutMessage: "text"}
+
+	for _, layout := range permutations(FieldLayout{LayoutLevel, LayoutTime, LayoutCaller, LayoutMessage}) {
+		jf := NewJsonFormatter(nil, nil)
+		jf.FieldLayout = layout
+		var want []string
+		for _, fl := range layout {
+			want = append(want, jsonKey[fl])
+		}
+		want = append(want, "k")
+		if got := jsonKeys(t, mustFormat(t, jf, r)); !reflect.DeepEqual(got, want) {
+			t.Errorf("json %v: keys %q, want %q", layout, got, want)
+		}
+
+		tf := NewTextFormatter(nil, nil)
+		tf.FieldLayout = layout
+		line := string(mustFormat(t, tf, r))
+		prev := -1
+		for _, fl := range layout {
+			i := strings.Index(line, textToken[fl])
+			if i <= prev {
+				t.Errorf("text %v: %q out of order in %q", layout, textToken[fl], line)
+			}
+			prev = i
+		}
+	}
+
+	// по умолчанию: JSON — level, ts, caller, msg; текст — время первым
+	if got := jsonKeys(t, mustFormat(t, NewJsonFormatter(nil, nil), r)); !reflect.DeepEqual(got, []string{"level", "ts", "caller", "msg", "k"}) {
+		t.Errorf("json default: %q", got)
+	}
+	if line := string(mustFormat(t, NewTextFormatter(nil, nil), r)); !strings.HasPrefix(line, "[2025-08-14 10:00:00.000] WARNING a.go:1 → text") {
+		t.Errorf("text default: %q", line)
+	}
+	// неполный порядок дополняется полями по умолчанию
+	jf := NewJsonFormatter(nil, nil)
+	jf.FieldLayout = FieldLayout{LayoutMessage}
+	if got := jsonKeys(t, mustFormat(t, jf, r)); !reflect.DeepEqual(got, []string{"msg", "level", "ts", "caller", "k"}) {
+		t.Errorf("json partial: %q", got)
+	}
+}
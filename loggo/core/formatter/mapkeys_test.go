@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+type keyID int
+
+func (k keyID) String() string { return "id" }
+
+func TestStringifiedMapKeysUnique(t *testing.T) {
+	f := NewJsonFormatter(nil, nil)
+	f.StringifyMapKeys = true
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"ints":     map[int]string{1: "a", 2: "b"},
+		"mixed":    map[any]any{1: "a", "1": "b"},
+		"stringer": map[keyID]int{1: 1, 2: 2},
+	}}
+	out := mustFormat(t, f, r)
+	for i := 0; i < 20; i++ {
+		if again := mustFormat(t, f, r); string(again) != string(out) {
+			t.Fatalf("unstable output:\n%s\n%s", out, again)
+		}
+	}
+
+	got := decodeJSON(t, out)
+	ints, _ := got["ints"].(map[string]any)
+	if ints["1"] != "a" || ints["2"] != "b" {
+		t.Errorf("ints: %v", got["ints"])
+	}
+	// int сортируется раньше string по типу: 1 сохраняет ключ, "1" — с номером
+	mixed, _ := got["mixed"].(map[string]any)
+	if len(mixed) != 2 || mixed["1"] != "a" || mixed["1#2"] != "b" {
+		t.Errorf("mixed: %v in %s", got["mixed"], out)
+	}
+	stringer, _ := got["stringer"].(map[string]any)
+	if len(stringer) != 2 || stringer["id"] != 1.0 || stringer["id#2"] != 2.0 {
+		t.Errorf("stringer: %v in %s", got["stringer"], out)
+	}
+
+	// json.Unmarshal молча берёт последний из повторов — проверяем сам текст
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(out, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(raw["mixed"]), `"1":`); n != 1 {
+		t.Errorf("mixed has %d \"1\" keys: %s", n, raw["mixed"])
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.StringifyMapKeys = true
+	text := string(mustFormat(t, tf, r))
+	if !strings.Contains(text, "1#2") || !strings.Contains(text, "id#2") {
+		t.Errorf("text: %s", text)
+	}
+}
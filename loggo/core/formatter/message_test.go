@@ -0,0 +1,101 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOmitEmptyMessage(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	metric := core.LogRecord{Level: core.Info, Timestamp: ts, Fields: map[string]any{"rps": 10}}
+
+	for _, omit := range []bool{false, true} {
+		jf := NewJsonFormatter(nil, nil)
+		jf.OmitEmptyMessage = omit
+		got := decodeJSON(t, mustFormat(t, jf, metric))
+		if _, ok := got["msg"]; ok == omit {
+			t.Errorf("json omit=%v: msg present = %v", omit, ok)
+		}
+
+		tf := NewTextFormatter(nil, nil)
+		tf.OmitEmptyMessage = omit
+		text := string(mustFormat(t, tf, metric))
+		if strings.Contains(text, "→") == omit {
+			t.Errorf("text omit=%v: %q", omit, text)
+		}
+		if !strings.HasSuffix(text, "rps=10") {
+			t.Errorf("text omit=%v: %q", omit, text)
+		}
+	}
+
+	// непустое сообщение выводится при любой настройке
+	jf := NewJsonFormatter(nil, nil)
+	jf.OmitEmptyMessage = true
+	if got := decodeJSON(t, mustFormat(t, jf, core.LogRecord{Level: core.Info, Message: "m"})); got["msg"] != "m" {
+		t.Errorf("json: msg = %v", got["msg"])
+	}
+}
+
+// Уровень выравнивается по ширине колонки, но в конце строки — без хвостовых пробелов.
+func TestTextNoTrailingPadding(t *testing.T) {
+	f := NewTextFormatter(nil, nil)
+	f.OmitEmptyMessage = true
+	r := core.LogRecord{Level: core.Info}
+	if text := string(mustFormat(t, f, r)); strings.HasSuffix(text, " ") {
+		t.Errorf("trailing spaces: %q", text)
+	}
+
+	r.Fields = map[string]any{"k": 1}
+	if text := string(mustFormat(t, f, r)); !strings.Contains(text, "INFO    | k=1") {
+		t.Errorf("level not padded before fields: %q", text)
+	}
+
+	f.OmitEmptyMessage = false
+	f.FieldLayout = FieldLayout{LayoutTime, LayoutMessage, LayoutCaller, LayoutLevel}
+	r = core.LogRecord{Level: core.Info, Message: "m"}
+	if text := string(mustFormat(t, f, r)); !strings.HasSuffix(text, "→ m INFO") {
+		t.Errorf("level last: %q", text)
+	}
+}
+
+func TestTrimMessage(t *testing.T) {
+	msgs := map[string]string{
+		"  padded  ":              "padded",
+		"trailing newline\n":      "trailing newline",
+		"\t\r\n mixed \r\n":       "mixed",
+		"\nfirst\nsecond\n":       "first\n│ second", // переводы строк внутри остаются (с префиксом "│ ")
+		" \n\t ":                  "",
+		"unchanged":               "unchanged",
+		"\u00a0nbsp\u3000":        "nbsp", // пробелы Unicode тоже
+		"inner  spaces  kept \n ": "inner  spaces  kept",
+	}
+	for msg, want := range msgs {
+		r := core.LogRecord{Level: core.Info, Message: msg}
+
+		jf := NewJsonFormatter(nil, nil)
+		jf.TrimMessage = true
+		if got := decodeJSON(t, mustFormat(t, jf, r))["msg"]; got != want {
+			t.Errorf("json %q: msg = %q, want %q", msg, got, want)
+		}
+		// пустое после обрезки сообщение опускается при OmitEmptyMessage
+		jf.OmitEmptyMessage = true
+		if _, ok := decodeJSON(t, mustFormat(t, jf, r))["msg"]; ok != (want != "") {
+			t.Errorf("json %q with OmitEmptyMessage: msg present = %v", msg, ok)
+		}
+
+		tf := NewTextFormatter(nil, nil)
+		tf.TrimMessage = true
+		text := string(mustFormat(t, tf, r))
+		if first, _, _ := strings.Cut(want, "\n"); !strings.Contains(text, "→ "+first) || strings.HasSuffix(text, "\n") ||
+			want != "" && strings.HasSuffix(text, " ") {
+			t.Errorf("text %q: %q", msg, text)
+		}
+	}
+
+	// по умолчанию сообщение не меняется
+	if got := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), core.LogRecord{Level: core.Info, Message: " m\n"}))["msg"]; got != " m\n│ " {
+		t.Errorf("default: msg = %q", got)
+	}
+}
@@ -0,0 +1,362 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"math"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// MsgpackFormatter сериализует запись в MessagePack (без внешних зависимостей) —
+// компактный бинарный формат для транспорта через сетевые и unix-сокет
+// writer'ы с кадрированием по длине. Запись — map:
+//
+//	{"level": "INFO", "ts": <timestamp>, "seq": 1, "caller": "...", "msg": "...", "tags": [...], "fields": {...}}
+//
+// seq, caller и tags — только если заданы. Время (ts и поля time.Time) — расширение
+// timestamp (тип -1), time.Duration и fmt.Stringer — строками, []byte — bin.
+// Охват типов тот же, что у JsonFormatter: map, срезы, структуры через reflect
+// с учётом json-тегов, защита от циклов и MaxDepth.
+type MsgpackFormatter struct {
+	MaxDepth int
+	// LevelAsInt пишет level числом (0, 10, ... 50) вместо имени.
+	LevelAsInt bool
+	// StringifyMapKeys выводит map с нестроковыми ключами, приводя ключи к
+	// строке; без него такие map — "<unsupported_map_key>".
+	StringifyMapKeys bool
+	// StructFieldOrder — порядок полей структур: по алфавиту (по умолчанию) или
+	// в порядке объявления, как в encoding/json.
+	StructFieldOrder StructFieldOrder
+}
+
+// NewMsgpackFormatter создаёт MsgpackFormatter; maxDepth == nil, 0 или < 0 —
+// глубина по умолчанию (3).
+func NewMsgpackFormatter(maxDepth *int) *MsgpackFormatter {
+	return &MsgpackFormatter{MaxDepth: depthOrDefault(maxDepth)}
+}
+
+func (f *MsgpackFormatter) Format(r core.LogRecord) ([]byte, error) {
+	b := bufPool.Get().(*bytes.Buffer)
+	b.Reset()
+	defer putBuf(b)
+
+	n := 4 // level, ts, msg, fields
+	if r.Seq != 0 {
+		n++
+	}
+	if r.Caller != "" {
+		n++
+	}
+	if len(r.Tags) > 0 {
+		n++
+	}
+	writeMsgpackMapHeader(b, n)
+
+	writeMsgpackString(b, "level")
+	if f.LevelAsInt {
+		writeMsgpackInt(b, int64(r.Level))
+	} else {
+		writeMsgpackString(b, r.Level.String())
+	}
+	writeMsgpackString(b, "ts")
+	writeMsgpackTime(b, r.Timestamp)
+	if r.Seq != 0 {
+		writeMsgpackString(b, "seq")
+		writeMsgpackUint(b, r.Seq)
+	}
+	if r.Caller != "" {
+		writeMsgpackString(b, "caller")
+		writeMsgpackString(b, r.Caller)
+	}
+	writeMsgpackString(b, "msg")
+	writeMsgpackString(b, r.Message)
+	if len(r.Tags) > 0 {
+		writeMsgpackString(b, tagsKey)
+		writeMsgpackArrayHeader(b, len(r.Tags))
+		for _, t := range r.Tags {
+			writeMsgpackString(b, t)
+		}
+	}
+
+	// поля — отдельной map, чтобы не пересекаться со служебными ключами
+	writeMsgpackString(b, "fields")
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	writeMsgpackMapHeader(b, len(keys))
+	visited := newVisitSet(0)
+	for _, k := range keys {
+		writeMsgpackString(b, k)
+		f.writeValue(b, r.Fields[k], 0, visited)
+	}
+
+	// буфер вернётся в пул — отдаём копию
+	return append([]byte(nil), b.Bytes()...), nil
+}
+
+func (f *MsgpackFormatter) writeValue(b *bytes.Buffer, v any, depth int, visited visitSet) {
+	if depth >= f.MaxDepth {
+		writeMsgpackString(b, "<max_depth>")
+		return
+	}
+	if s, special, ok := bigNumberString(v); ok {
+		if special && s == "" {
+			b.WriteByte(0xc0)
+			return
+		}
+		// у MessagePack нет чисел произвольной точности — строкой, без потерь
+		writeMsgpackString(b, s)
+		return
+	}
+
+	switch x := v.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case string:
+		writeMsgpackString(b, x)
+	case bool:
+		writeMsgpackBool(b, x)
+	case int, int8, int16, int32, int64:
+		writeMsgpackInt(b, reflect.ValueOf(x).Int())
+	case uint, uint8, uint16, uint32, uint64, uintptr:
+		writeMsgpackUint(b, reflect.ValueOf(x).Uint())
+	case float32:
+		b.WriteByte(0xca)
+		b.Write(binary.BigEndian.AppendUint32(nil, math.Float32bits(x)))
+	case float64:
+		writeMsgpackFloat64(b, x)
+	case time.Duration:
+		writeMsgpackString(b, x.String())
+	case time.Time:
+		writeMsgpackTime(b, x)
+	case error:
+		if isNilValue(x) {
+			b.WriteByte(0xc0)
+			return
+		}
+		writeMsgpackString(b, x.Error())
+	case fmt.Stringer:
+		if isNilValue(x) {
+			b.WriteByte(0xc0)
+			return
+		}
+		writeMsgpackString(b, x.String())
+	default:
+		f.writeByReflect(b, x, depth, visited)
+	}
+}
+
+func (f *MsgpackFormatter) writeByReflect(b *bytes.Buffer, v any, depth int, visited visitSet) {
+	rv := reflect.ValueOf(v)
+	// Stringer/error на pointer receiver'е у значения, переданного не по указателю
+	if pv := interfaceOf(rv); reflect.TypeOf(pv) != rv.Type() {
+		f.writeValue(b, pv, depth, visited)
+		return
+	}
+
+	if ok, release := markAndCheck(rv, visited); !ok {
+		writeMsgpackString(b, "<cycle>")
+		return
+	} else {
+		defer release()
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeMsgpackInt(b, rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeMsgpackUint(b, rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeMsgpackFloat64(b, rv.Float())
+	case reflect.Bool:
+		writeMsgpackBool(b, rv.Bool())
+	case reflect.String:
+		writeMsgpackString(b, rv.String())
+
+	case reflect.Interface, reflect.Ptr:
+		if rv.IsNil() {
+			b.WriteByte(0xc0)
+			return
+		}
+		f.writeValue(b, rv.Elem().Interface(), depth+1, visited)
+
+	case reflect.Struct:
+		fields := structFields(rv, f.StructFieldOrder)
+		writeMsgpackMapHeader(b, len(fields))
+		for _, sf := range fields {
+			writeMsgpackString(b, sf.key)
+			if sf.quoted {
+				// ",string": скаляр строкой, как в encoding/json
+				writeMsgpackString(b, fmt.Sprint(sf.value.Interface()))
+				continue
+			}
+			f.writeValue(b, interfaceOf(sf.value), depth+1, visited)
+		}
+
+	case reflect.Map:
+		if rv.IsNil() {
+			b.WriteByte(0xc0)
+			return
+		}
+		entries, ok := sortedMapEntries(rv, f.StringifyMapKeys)
+		if !ok {
+			writeMsgpackString(b, "<unsupported_map_key>")
+			return
+		}
+		writeMsgpackMapHeader(b, len(entries))
+		for _, e := range entries {
+			writeMsgpackString(b, e.key)
+			f.writeValue(b, interfaceOf(e.value), depth+1, visited)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			b.WriteByte(0xc0)
+			return
+		}
+		n := rv.Len()
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			writeMsgpackBin(b, bytesOf(rv))
+			return
+		}
+		writeMsgpackArrayHeader(b, n)
+		for i := 0; i < n; i++ {
+			f.writeValue(b, interfaceOf(rv.Index(i)), depth+1, visited)
+		}
+
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if rv.IsNil() {
+			b.WriteByte(0xc0)
+			return
+		}
+		writeMsgpackString(b, fmt.Sprintf("<unsupported:%s>", rv.Kind().String()))
+
+	default:
+		writeMsgpackString(b, fmt.Sprintf("<unsupported:%s>", rv.Kind().String()))
+	}
+}
+
+// --- кодирование MessagePack ---
+
+func writeMsgpackBool(b *bytes.Buffer, v bool) {
+	if v {
+		b.WriteByte(0xc3)
+	} else {
+		b.WriteByte(0xc2)
+	}
+}
+
+func writeMsgpackInt(b *bytes.Buffer, v int64) {
+	switch {
+	case v >= 0:
+		writeMsgpackUint(b, uint64(v))
+	case v >= -32:
+		b.WriteByte(byte(v)) // negative fixint
+	case v >= math.MinInt8:
+		b.Write([]byte{0xd0, byte(v)})
+	case v >= math.MinInt16:
+		b.WriteByte(0xd1)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+	case v >= math.MinInt32:
+		b.WriteByte(0xd2)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+	default:
+		b.WriteByte(0xd3)
+		b.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+	}
+}
+
+func writeMsgpackUint(b *bytes.Buffer, v uint64) {
+	switch {
+	case v <= 0x7f:
+		b.WriteByte(byte(v)) // positive fixint
+	case v <= math.MaxUint8:
+		b.Write([]byte{0xcc, byte(v)})
+	case v <= math.MaxUint16:
+		b.WriteByte(0xcd)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+	case v <= math.MaxUint32:
+		b.WriteByte(0xce)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+	default:
+		b.WriteByte(0xcf)
+		b.Write(binary.BigEndian.AppendUint64(nil, v))
+	}
+}
+
+func writeMsgpackFloat64(b *bytes.Buffer, v float64) {
+	b.WriteByte(0xcb)
+	b.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+}
+
+func writeMsgpackString(b *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		b.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		b.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		b.WriteByte(0xda)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		b.WriteByte(0xdb)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	b.WriteString(s)
+}
+
+func writeMsgpackBin(b *bytes.Buffer, p []byte) {
+	n := len(p)
+	switch {
+	case n <= math.MaxUint8:
+		b.Write([]byte{0xc4, byte(n)})
+	case n <= math.MaxUint16:
+		b.WriteByte(0xc5)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		b.WriteByte(0xc6)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+	b.Write(p)
+}
+
+func writeMsgpackArrayHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(0xdc)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		b.WriteByte(0xdd)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func writeMsgpackMapHeader(b *bytes.Buffer, n int) {
+	switch {
+	case n < 16:
+		b.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(0xde)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		b.WriteByte(0xdf)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// writeMsgpackTime пишет расширение timestamp (тип -1) в формате timestamp 96:
+// наносекунды uint32 и секунды int64 — подходит для любого time.Time.
+func writeMsgpackTime(b *bytes.Buffer, t time.Time) {
+	b.Write([]byte{0xc7, 12, 0xff})
+	b.Write(binary.BigEndian.AppendUint32(nil, uint32(t.Nanosecond())))
+	b.Write(binary.BigEndian.AppendUint64(nil, uint64(t.Unix())))
+}
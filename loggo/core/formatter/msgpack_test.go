@@ -0,0 +1,251 @@
+package formatter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// msgpackDecoder — эталонный декодер MessagePack по спецификации (сторонних
+// библиотек в модуле нет). Целые — int64 (uint64 для значений > MaxInt64),
+// float32 — float64, str — string, bin — []byte, timestamp — time.Time.
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) take(n int) []byte {
+	if d.pos+n > len(d.data) {
+		panic(fmt.Sprintf("truncated at %d: need %d bytes", d.pos, n))
+	}
+	p := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return p
+}
+
+func (d *msgpackDecoder) uint(n int) uint64 {
+	p := d.take(n)
+	var v uint64
+	for _, c := range p {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func (d *msgpackDecoder) value() any {
+	c := d.take(1)[0]
+	switch {
+	case c <= 0x7f:
+		return int64(c)
+	case c >= 0xe0:
+		return int64(int8(c))
+	case c&0xf0 == 0x80:
+		return d.mapOf(int(c & 0x0f))
+	case c&0xf0 == 0x90:
+		return d.arrayOf(int(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		return string(d.take(int(c & 0x1f)))
+	}
+	switch c {
+	case 0xc0:
+		return nil
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	case 0xc4, 0xc5, 0xc6:
+		return append([]byte{}, d.take(int(d.uint(1<<(c-0xc4))))...)
+	case 0xc7, 0xc8, 0xc9:
+		n := int(d.uint(1 << (c - 0xc7)))
+		return d.ext(int8(d.take(1)[0]), d.take(n))
+	case 0xca:
+		return float64(math.Float32frombits(uint32(d.uint(4))))
+	case 0xcb:
+		return math.Float64frombits(d.uint(8))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v := d.uint(1 << (c - 0xcc))
+		if v > math.MaxInt64 {
+			return v
+		}
+		return int64(v)
+	case 0xd0:
+		return int64(int8(d.uint(1)))
+	case 0xd1:
+		return int64(int16(d.uint(2)))
+	case 0xd2:
+		return int64(int32(d.uint(4)))
+	case 0xd3:
+		return int64(d.uint(8))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		typ := int8(d.take(1)[0])
+		return d.ext(typ, d.take(1<<(c-0xd4)))
+	case 0xd9, 0xda, 0xdb:
+		return string(d.take(int(d.uint(1 << (c - 0xd9)))))
+	case 0xdc, 0xdd:
+		return d.arrayOf(int(d.uint(2 << (c - 0xdc))))
+	case 0xde, 0xdf:
+		return d.mapOf(int(d.uint(2 << (c - 0xde))))
+	}
+	panic(fmt.Sprintf("unknown type byte 0x%02x at %d", c, d.pos-1))
+}
+
+func (d *msgpackDecoder) mapOf(n int) map[string]any {
+	m := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		k, ok := d.value().(string)
+		if !ok {
+			panic("non-string map key")
+		}
+		m[k] = d.value()
+	}
+	return m
+}
+
+func (d *msgpackDecoder) arrayOf(n int) []any {
+	a := make([]any, n)
+	for i := range a {
+		a[i] = d.value()
+	}
+	return a
+}
+
+// ext разбирает расширение timestamp (-1) во всех трёх формах.
+func (d *msgpackDecoder) ext(typ int8, p []byte) any {
+	if typ != -1 {
+		panic(fmt.Sprintf("unexpected ext type %d", typ))
+	}
+	switch len(p) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(p)), 0).UTC()
+	case 8:
+		v := binary.BigEndian.Uint64(p)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)).UTC()
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(p[4:])), int64(binary.BigEndian.Uint32(p))).UTC()
+	}
+	panic(fmt.Sprintf("bad timestamp length %d", len(p)))
+}
+
+func decodeMsgpack(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+	d := &msgpackDecoder{data: data}
+	var doc map[string]any
+	func() {
+		defer func() {
+			if v := recover(); v != nil {
+				t.Fatalf("decode % x: %v", data, v)
+			}
+		}()
+		doc, _ = d.value().(map[string]any)
+	}()
+	if d.pos != len(data) {
+		t.Fatalf("%d trailing bytes", len(data)-d.pos)
+	}
+	return doc
+}
+
+func TestMsgpackDecodes(t *testing.T) {
+	ts := time.Date(2025, 8, 14, 10, 0, 0, 123456789, time.UTC)
+	type inner struct {
+		ID   int    `json:"id"`
+		Name string `json:"name,omitempty"`
+		Skip int    `json:"-"`
+	}
+	long := strings.Repeat("x", 70000)
+	ints := map[string]any{
+		"0": 0, "127": 127, "128": 128, "255": 255, "256": 256, "65535": 65535, "65536": 65536,
+		"1<<32": int64(1 << 32), "-1": -1, "-32": -32, "-33": -33, "-128": -128, "-129": -129,
+		"-32768": -32768, "-32769": -32769, "min32": int64(math.MinInt32), "min32-1": int64(math.MinInt32) - 1,
+		"min64": int64(math.MinInt64), "u8": uint8(200), "umax": uint64(math.MaxUint64),
+	}
+	many := map[string]any{}
+	for i := 0; i < 20; i++ {
+		many[fmt.Sprint("k", i)] = i
+	}
+	r := core.LogRecord{
+		Level: core.Warning, Timestamp: ts, Message: "hello", Seq: 42, Caller: "a.go:1", Tags: []string{"t1"},
+		Fields: map[string]any{
+			"ints":   ints,
+			"f32":    float32(1.5),
+			"f64":    3.25,
+			"strs":   []string{"", strings.Repeat("a", 31), strings.Repeat("b", 32), strings.Repeat("c", 256)},
+			"long":   long,
+			"bytes":  []byte{1, 2, 3},
+			"bigbin": make([]byte, 300),
+			"nil":    nil,
+			"bools":  []bool{true, false},
+			"time":   ts,
+			"dur":    1500 * time.Millisecond,
+			"struct": inner{ID: 7},
+			"list":   []any{1, "two", map[string]any{"three": 3}},
+			"arr20":  make([]int, 20),
+			"many":   many,
+			"big":    new(big.Int).Lsh(big.NewInt(1), 100),
+		},
+	}
+
+	doc := decodeMsgpack(t, mustFormat(t, NewMsgpackFormatter(nil), r))
+	if doc["level"] != "WARNING" || doc["msg"] != "hello" || doc["seq"] != int64(42) || doc["caller"] != "a.go:1" {
+		t.Errorf("header: %v", doc)
+	}
+	if got, _ := doc["ts"].(time.Time); !got.Equal(ts) {
+		t.Errorf("ts = %v", doc["ts"])
+	}
+	if !reflect.DeepEqual(doc[tagsKey], []any{"t1"}) {
+		t.Errorf("tags = %v", doc[tagsKey])
+	}
+
+	fields := doc["fields"].(map[string]any)
+	gotInts := fields["ints"].(map[string]any)
+	for k, v := range ints {
+		want := any(reflect.ValueOf(v).Convert(reflect.TypeOf(int64(0))).Int())
+		if u, ok := v.(uint64); ok && u > math.MaxInt64 {
+			want = u
+		}
+		if gotInts[k] != want {
+			t.Errorf("int %s = %v (%T), want %v", k, gotInts[k], gotInts[k], want)
+		}
+	}
+	checks := map[string]any{
+		"f32":    1.5,
+		"f64":    3.25,
+		"strs":   []any{"", strings.Repeat("a", 31), strings.Repeat("b", 32), strings.Repeat("c", 256)},
+		"long":   long,
+		"bytes":  []byte{1, 2, 3},
+		"bigbin": make([]byte, 300),
+		"nil":    nil,
+		"bools":  []any{true, false},
+		"dur":    "1.5s",
+		"struct": map[string]any{"id": int64(7)},
+		"list":   []any{int64(1), "two", map[string]any{"three": int64(3)}},
+		"big":    "1267650600228229401496703205376",
+	}
+	for k, want := range checks {
+		if !reflect.DeepEqual(fields[k], want) {
+			t.Errorf("%s = %#v, want %#v", k, fields[k], want)
+		}
+	}
+	if got, _ := fields["time"].(time.Time); !got.Equal(ts) {
+		t.Errorf("time = %v", fields["time"])
+	}
+	if a, _ := fields["arr20"].([]any); len(a) != 20 {
+		t.Errorf("arr20 = %v", fields["arr20"])
+	}
+	if m, _ := fields["many"].(map[string]any); len(m) != 20 || m["k19"] != int64(19) {
+		t.Errorf("many = %v", fields["many"])
+	}
+
+	// уровень числом; без seq/caller/tags — только обязательные ключи
+	f := NewMsgpackFormatter(nil)
+	f.LevelAsInt = true
+	doc = decodeMsgpack(t, mustFormat(t, f, core.LogRecord{Level: core.Error, Timestamp: ts, Message: "m"}))
+	if len(doc) != 4 || doc["level"] != int64(40) || len(doc["fields"].(map[string]any)) != 0 {
+		t.Errorf("minimal: %v", doc)
+	}
+}
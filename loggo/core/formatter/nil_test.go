@@ -0,0 +1,119 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type nilHolder struct {
+	Any  any          `json:"any"`
+	Err  error        `json:"err"`
+	Ptr  *ptrStringer `json:"ptr"`
+	Str  fmtStringer  `json:"str"`
+	Map  map[string]int
+	List []int
+}
+
+type fmtStringer interface{ String() string }
+
+// nilFlavors — все виды nil: без типа, типизированные указатели (в том числе с
+// методами String/Error на nil-получателе), nil-интерфейсы, map, срезы,
+// функции, каналы и unsafe.Pointer.
+func nilFlavors() map[string]any {
+	var err error
+	var str fmtStringer = (*ptrStringer)(nil)
+	return map[string]any{
+		"untyped":  nil,
+		"ptr":      (*int)(nil),
+		"stringer": (*ptrStringer)(nil),
+		"error":    (*ptrError)(nil),
+		"iface":    err,
+		"boxed":    str,
+		"map":      map[string]any(nil),
+		"slice":    []string(nil),
+		"func":     (func())(nil),
+		"chan":     (chan int)(nil),
+		"unsafe":   unsafe.Pointer(nil),
+		"ptrptr":   (**int)(nil),
+	}
+}
+
+func TestNilFlavorsRenderNull(t *testing.T) {
+	flavors := nilFlavors()
+	fields := map[string]any{}
+	for k, v := range flavors {
+		fields[k] = v
+	}
+	fields["nested_map"] = nilFlavors()
+	fields["nested_list"] = []any{nil, (*ptrStringer)(nil), (*ptrError)(nil), map[string]int(nil)}
+	fields["struct"] = nilHolder{Str: (*ptrStringer)(nil)}
+	fields["struct_ptr"] = &nilHolder{Err: (*ptrError)(nil)}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: fields}
+
+	t.Run("json", func(t *testing.T) {
+		doc := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r))
+		nested := doc["nested_map"].(map[string]any)
+		for k := range flavors {
+			if v, ok := doc[k]; !ok || v != nil {
+				t.Errorf("%s = %v (present %v), want null", k, v, ok)
+			}
+			if v, ok := nested[k]; !ok || v != nil {
+				t.Errorf("nested %s = %v, want null", k, v)
+			}
+		}
+		for i, v := range doc["nested_list"].([]any) {
+			if v != nil {
+				t.Errorf("nested_list[%d] = %v", i, v)
+			}
+		}
+		for _, k := range []string{"struct", "struct_ptr"} {
+			for fk, v := range doc[k].(map[string]any) {
+				if v != nil {
+					t.Errorf("%s.%s = %v", k, fk, v)
+				}
+			}
+		}
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		doc := decodeMsgpack(t, mustFormat(t, NewMsgpackFormatter(nil), r))
+		got := doc["fields"].(map[string]any)
+		nested := got["nested_map"].(map[string]any)
+		for k := range flavors {
+			if v, ok := got[k]; !ok || v != nil {
+				t.Errorf("%s = %v, want nil", k, v)
+			}
+			if v, ok := nested[k]; !ok || v != nil {
+				t.Errorf("nested %s = %v, want nil", k, v)
+			}
+		}
+		for i, v := range got["nested_list"].([]any) {
+			if v != nil {
+				t.Errorf("nested_list[%d] = %v", i, v)
+			}
+		}
+		for _, k := range []string{"struct", "struct_ptr"} {
+			for fk, v := range got[k].(map[string]any) {
+				if v != nil {
+					t.Errorf("%s.%s = %v", k, fk, v)
+				}
+			}
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		text := string(mustFormat(t, NewTextFormatter(nil, nil), r))
+		for k := range flavors {
+			if !strings.Contains(text, " "+k+"=null") || !strings.Contains(text, k+": null") {
+				t.Errorf("%s not null: %s", k, text)
+			}
+		}
+		for _, bad := range []string{"<nil>", "unsupported", "0x", "id-", "code "} {
+			if strings.Contains(text, bad) {
+				t.Errorf("text contains %q: %s", bad, text)
+			}
+		}
+	})
+}
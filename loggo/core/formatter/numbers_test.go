@@ -0,0 +1,44 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"math"
+	"strings"
+	"testing"
+)
+
+type myUint uint64
+
+func TestJSONLargeIntegersExact(t *testing.T) {
+	u := uint64(math.MaxUint64)
+	fields := map[string]any{
+		"u64":    uint64(math.MaxUint64),
+		"i64":    int64(math.MaxInt64),
+		"min":    int64(math.MinInt64),
+		"named":  myUint(math.MaxUint64),
+		"ptr":    &u,
+		"slice":  []uint64{math.MaxUint64, 1<<53 + 1},
+		"anyarr": []any{uint64(math.MaxUint64)},
+		"nested": map[string]any{"v": int64(math.MaxInt64)},
+		"struct": struct {
+			V uint64 `json:"v"`
+		}{math.MaxUint64},
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: fields}
+	out := string(mustFormat(t, NewJsonFormatter(nil, nil), r))
+	for _, want := range []string{
+		`"u64":18446744073709551615`,
+		`"i64":9223372036854775807`,
+		`"min":-9223372036854775808`,
+		`"named":18446744073709551615`,
+		`"ptr":18446744073709551615`,
+		`"slice":[18446744073709551615,9007199254740993]`,
+		`"anyarr":[18446744073709551615]`,
+		`"nested":{"v":9223372036854775807}`,
+		`"struct":{"v":18446744073709551615}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in %s", want, out)
+		}
+	}
+}
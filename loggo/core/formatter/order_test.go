@@ -0,0 +1,49 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+type orderMid struct {
+	Mid  int
+	Beta string `json:"beta"`
+}
+
+type orderRecord struct {
+	Zeta  int
+	Alpha string
+	orderMid
+	Omega bool   `json:"omega"`
+	Gamma []int  `json:"gamma"`
+	Skip  string `json:"-"`
+}
+
+func TestStructFieldsDeclared(t *testing.T) {
+	v := orderRecord{Zeta: 1, Alpha: "a", orderMid: orderMid{Mid: 2, Beta: "b"}, Omega: true, Gamma: []int{3}}
+	r := core.LogRecord{Level: core.Info, Fields: map[string]any{"v": v}}
+
+	// совпадает с encoding/json байт в байт
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jf := NewJsonFormatter(nil, nil)
+	jf.StructFieldOrder = StructFieldsDeclared
+	if out := string(mustFormat(t, jf, r)); !strings.Contains(out, `"v":`+string(want)) {
+		t.Errorf("json:\n got %s\nwant %s", out, want)
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.StructFieldOrder = StructFieldsDeclared
+	if out := string(mustFormat(t, tf, r)); !strings.HasSuffix(out, `v={Zeta: 1, Alpha: "a", Mid: 2, beta: "b", omega: true, gamma: [3]}`) {
+		t.Errorf("text: %s", out)
+	}
+
+	// по умолчанию — по алфавиту
+	if out := string(mustFormat(t, NewJsonFormatter(nil, nil), r)); !strings.Contains(out, `"v":{"Alpha":"a","Mid":2,"Zeta":1,"beta":"b","gamma":[3],"omega":true}`) {
+		t.Errorf("sorted: %s", out)
+	}
+}
@@ -0,0 +1,229 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseJSONLine разбирает строку, записанную JsonFormatter'ом с настройками по
+// умолчанию, обратно в LogRecord — для round-trip тестов и инструментов
+// воспроизведения логов. level, ts, msg, seq и caller становятся полями записи,
+// schema_version отбрасывается, остальное попадает в Fields: объекты — как
+// map[string]any, массивы — []any, числа — json.Number (без потери точности).
+// Строки восстанавливаются без префикса многострочности "│ ", который
+// форматтер ставит после каждого перевода строки; CRLF, который он
+// нормализует в LF, не восстанавливается.
+//
+// Нужен именно разбор «своего» формата: строки форматтер экранирует по правилам
+// Go (strconv.Quote), и encoding/json такие строки не всегда принимает.
+func ParseJSONLine(line []byte) (core.LogRecord, error) {
+	p := lineParser{s: strings.TrimSpace(string(line))}
+	v, err := p.value()
+	if err == nil {
+		p.skipSpace()
+		if p.pos < len(p.s) {
+			err = p.errorf("unexpected trailing data")
+		}
+	}
+	if err != nil {
+		return core.LogRecord{}, err
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return core.LogRecord{}, fmt.Errorf("parse log line: not a JSON object")
+	}
+
+	var r core.LogRecord
+	for k, v := range obj {
+		switch k {
+		case "level":
+			name, _ := v.(string)
+			if r.Level, err = core.ParseLevel(name); err != nil {
+				return core.LogRecord{}, fmt.Errorf("parse log line: %w", err)
+			}
+		case "ts":
+			s, _ := v.(string)
+			if r.Timestamp, err = time.Parse(time.RFC3339Nano, s); err != nil {
+				return core.LogRecord{}, fmt.Errorf("parse log line: ts: %w", err)
+			}
+		case "msg":
+			r.Message, _ = v.(string)
+		case "seq":
+			n, _ := v.(json.Number)
+			if r.Seq, err = strconv.ParseUint(string(n), 10, 64); err != nil {
+				return core.LogRecord{}, fmt.Errorf("parse log line: seq: %w", err)
+			}
+		case "caller":
+			r.Caller, _ = v.(string)
+		case tagsKey:
+			arr, ok := v.([]any)
+			if !ok {
+				return core.LogRecord{}, fmt.Errorf("parse log line: tags: not an array")
+			}
+			for _, t := range arr {
+				s, _ := t.(string)
+				r.Tags = append(r.Tags, s)
+			}
+		case schemaVersionKey:
+		default:
+			if r.Fields == nil {
+				r.Fields = make(map[string]any)
+			}
+			r.Fields[k] = v
+		}
+	}
+	return r, nil
+}
+
+// lineParser — минимальный разборщик JSON, строки которого — литералы Go.
+type lineParser struct {
+	s   string
+	pos int
+}
+
+func (p *lineParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("parse log line at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *lineParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *lineParser) value() (any, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.s[p.pos]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		return p.str()
+	case c == '-' || c >= '0' && c <= '9':
+		return p.number()
+	case strings.HasPrefix(p.s[p.pos:], "true"):
+		p.pos += len("true")
+		return true, nil
+	case strings.HasPrefix(p.s[p.pos:], "false"):
+		p.pos += len("false")
+		return false, nil
+	case strings.HasPrefix(p.s[p.pos:], "null"):
+		p.pos += len("null")
+		return nil, nil
+	default:
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+func (p *lineParser) object() (map[string]any, error) {
+	p.pos++ // {
+	obj := make(map[string]any)
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == '}' {
+		p.pos++
+		return obj, nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != '"' {
+			return nil, p.errorf("expected object key")
+		}
+		key, err := p.str()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != ':' {
+			return nil, p.errorf("expected ':'")
+		}
+		p.pos++
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = v
+		if done, err := p.next('}'); err != nil || done {
+			return obj, err
+		}
+	}
+}
+
+func (p *lineParser) array() ([]any, error) {
+	p.pos++ // [
+	arr := []any{}
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == ']' {
+		p.pos++
+		return arr, nil
+	}
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		if done, err := p.next(']'); err != nil || done {
+			return arr, err
+		}
+	}
+}
+
+// next читает ',' (done=false) или закрывающую скобку end (done=true).
+func (p *lineParser) next(end byte) (bool, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return false, p.errorf("unexpected end of input")
+	}
+	switch p.s[p.pos] {
+	case ',':
+		p.pos++
+		return false, nil
+	case end:
+		p.pos++
+		return true, nil
+	default:
+		return false, p.errorf("expected ',' or %q", end)
+	}
+}
+
+func (p *lineParser) str() (string, error) {
+	start := p.pos
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch p.s[i] {
+		case '\\':
+			i++
+		case '"':
+			s, err := strconv.Unquote(p.s[start : i+1])
+			if err != nil {
+				return "", p.errorf("bad string: %v", err)
+			}
+			p.pos = i + 1
+			// префикс стоит после каждого \n, так что снимается ровно один
+			return strings.ReplaceAll(s, "\n│ ", "\n"), nil
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *lineParser) number() (json.Number, error) {
+	start := p.pos
+	for p.pos < len(p.s) && strings.IndexByte("+-0123456789.eE", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+	n := p.s[start:p.pos]
+	if _, err := strconv.ParseFloat(n, 64); err != nil {
+		// вне диапазона float64 (big.Int и т.п.) — допустимо, точность хранит json.Number
+		if ne, ok := err.(*strconv.NumError); !ok || ne.Err != strconv.ErrRange {
+			return "", p.errorf("bad number %q", n)
+		}
+	}
+	return json.Number(n), nil
+}
@@ -0,0 +1,76 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseJSONLineRoundTrip(t *testing.T) {
+	in := core.LogRecord{
+		Level:     core.Warning,
+		Timestamp: time.Date(2025, 8, 14, 10, 0, 0, 123000000, time.UTC),
+		Message:   "line one\nline two",
+		Tags:      []string{"billing"},
+		Fields: map[string]any{
+			"user":    "alice",
+			"count":   42,
+			"crlf":    "a\r\nb",
+			"box":     "a\n│ b", // совпадает с префиксом многострочности
+			"list":    []string{"x\ny", "z"},
+			"nested":  map[string]any{"ok": true, "note": "p\nq"},
+			"nothing": nil,
+		},
+	}
+	line := mustFormat(t, NewJsonFormatter(nil, nil), in)
+	out, err := ParseJSONLine(line)
+	if err != nil {
+		t.Fatalf("%v: %s", err, line)
+	}
+
+	if out.Level != in.Level || out.Message != in.Message || !out.Timestamp.Equal(in.Timestamp) {
+		t.Errorf("got %v %q %v", out.Level, out.Message, out.Timestamp)
+	}
+	if !reflect.DeepEqual(out.Tags, in.Tags) {
+		t.Errorf("tags %v", out.Tags)
+	}
+	want := map[string]any{
+		"user":    "alice",
+		"count":   json.Number("42"),
+		"crlf":    "a\nb", // форматтер нормализует CRLF
+		"box":     "a\n│ b",
+		"list":    []any{"x\ny", "z"},
+		"nested":  map[string]any{"ok": true, "note": "p\nq"},
+		"nothing": nil,
+	}
+	if !reflect.DeepEqual(out.Fields, want) {
+		t.Errorf("fields\n%#v\nwant\n%#v", out.Fields, want)
+	}
+}
+
+// Разбор снимает префикс многострочности, не меняя вывод форматтеров.
+func TestParseJSONLineStripsMultilinePrefix(t *testing.T) {
+	r := core.LogRecord{Level: core.Info, Message: "a\nb", Fields: map[string]any{
+		"v":    "c\nd",
+		"list": []string{"e\nf"},
+	}}
+	line := mustFormat(t, NewJsonFormatter(nil, nil), r)
+	if !strings.Contains(string(line), `"msg":"a\n│ b"`) || !strings.Contains(string(line), `"v":"c\n│ d"`) {
+		t.Errorf("json: %s", line)
+	}
+	text := string(mustFormat(t, NewTextFormatter(nil, nil), r))
+	if !strings.Contains(text, "→ a\nb |") || !strings.Contains(text, `v="c\n│ d"`) || !strings.Contains(text, `["e\n│ f"]`) {
+		t.Errorf("text: %q", text)
+	}
+
+	out, err := ParseJSONLine(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Message != "a\nb" || out.Fields["v"] != "c\nd" || !reflect.DeepEqual(out.Fields["list"], []any{"e\nf"}) {
+		t.Errorf("parsed %q %v", out.Message, out.Fields)
+	}
+}
@@ -0,0 +1,68 @@
+package formatter
+
+import (
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type addr uintptr
+
+func TestHexPointers(t *testing.T) {
+	x := 1
+	p := unsafe.Pointer(&x)
+	hex := fmt.Sprintf("0x%x", uintptr(p))
+	fields := map[string]any{
+		"u":   uintptr(0xdeadbeef),
+		"n":   addr(255),
+		"p":   p,
+		"nil": unsafe.Pointer(nil),
+		"s":   []uintptr{16},
+	}
+	r := core.LogRecord{Level: core.Info, Fields: fields}
+
+	jf := NewJsonFormatter(nil, nil)
+	jf.HexPointers = true
+	doc := decodeJSON(t, mustFormat(t, jf, r))
+	want := map[string]any{"u": "0xdeadbeef", "n": "0xff", "p": hex, "nil": "0x0"}
+	for k, v := range want {
+		if doc[k] != v {
+			t.Errorf("json %s = %v, want %v", k, doc[k], v)
+		}
+	}
+	if s, _ := doc["s"].([]any); len(s) != 1 || s[0] != "0x10" {
+		t.Errorf("json s = %v", doc["s"])
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.HexPointers = true
+	text := string(mustFormat(t, tf, r))
+	for _, w := range []string{"u=0xdeadbeef", "n=0xff", "p=" + hex, "nil=0x0", "0x10"} {
+		if !strings.Contains(text, w) {
+			t.Errorf("text missing %q: %s", w, text)
+		}
+	}
+}
+
+func TestHexPointersOffByDefault(t *testing.T) {
+	x := 1
+	r := core.LogRecord{Level: core.Info, Fields: map[string]any{
+		"u": uintptr(0xdeadbeef),
+		"p": unsafe.Pointer(&x),
+	}}
+
+	doc := decodeJSON(t, mustFormat(t, NewJsonFormatter(nil, nil), r))
+	if doc["u"] != float64(0xdeadbeef) {
+		t.Errorf("json u = %v, want number", doc["u"])
+	}
+	if s, _ := doc["p"].(string); strings.HasPrefix(s, "0x") {
+		t.Errorf("json p = %q: address leaked", s)
+	}
+
+	text := string(mustFormat(t, NewTextFormatter(nil, nil), r))
+	if !strings.Contains(text, "u=3735928559") || !strings.Contains(text, "p=<unsupported:unsafe.Pointer>") {
+		t.Errorf("text: %s", text)
+	}
+}
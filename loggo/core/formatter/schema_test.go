@@ -0,0 +1,47 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchemaVersionStableAcrossRecords(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []core.LogRecord{
+		{Level: core.Info, Timestamp: ts, Message: "a"},
+		{Level: core.Error, Timestamp: ts, Message: "b", Fields: map[string]any{"z": 1, "a": "x"}},
+	}
+
+	jf := NewJsonFormatter(nil, nil)
+	jf.SchemaVersion = "2"
+	for _, r := range records {
+		out := string(mustFormat(t, jf, r))
+		if decodeJSON(t, []byte(out))["schema_version"] != "2" {
+			t.Errorf("json: %s", out)
+		}
+		// место стабильно: сразу после служебных полей, перед пользовательскими
+		if !strings.Contains(out, `"msg":"`+r.Message+`","schema_version":"2"`) {
+			t.Errorf("json placement: %s", out)
+		}
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.SchemaVersion = "2"
+	df := NewDebugFormatter(nil, false)
+	df.SchemaVersion = "2"
+	for _, r := range records {
+		for name, f := range map[string]core.FormatProcessor{"text": tf, "debug": df} {
+			out := string(mustFormat(t, f, r))
+			if !strings.Contains(out, ` | schema_version="2"`) {
+				t.Errorf("%s: %s", name, out)
+			}
+		}
+	}
+
+	// без SchemaVersion поля нет
+	if out := string(mustFormat(t, NewJsonFormatter(nil, nil), records[0])); strings.Contains(out, "schema_version") {
+		t.Errorf("unset: %s", out)
+	}
+}
@@ -0,0 +1,48 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSizeHintWithinTolerance(t *testing.T) {
+	ts := time.Date(2025, 8, 14, 10, 0, 0, 123456789, time.UTC)
+	many := map[string]any{}
+	for _, k := range []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta", "eta", "theta"} {
+		many[k] = k + "-value"
+	}
+	records := map[string]core.LogRecord{
+		"bare":   {Level: core.Info, Timestamp: ts, Message: "m"},
+		"long":   {Level: core.Warning, Timestamp: ts, Message: strings.Repeat("x", 4096), Caller: "main.go:42"},
+		"scalar": {Level: core.Error, Timestamp: ts, Message: "request done", Seq: 12345, Fields: map[string]any{"status": 200, "ok": true, "ms": 12.5, "path": "/api/v1/users"}},
+		"many":   {Level: core.Info, Timestamp: ts, Message: "fields", Fields: many},
+		"nested": {Level: core.Debug, Timestamp: ts, Message: "nested", Fields: map[string]any{
+			"user": map[string]any{"id": 7, "name": "alice", "tags": []any{"a", "b", "c"}},
+			"at":   ts,
+		}},
+	}
+
+	formatters := map[string]interface {
+		core.FormatProcessor
+		core.SizeHinter
+	}{
+		"json": NewJsonFormatter(nil, nil),
+		"text": NewTextFormatter(nil, nil),
+	}
+	// ёмкость буфера — не оценка записи и на подсказку не влияет
+	presized := NewJsonFormatter(nil, nil)
+	presized.InitialBufferSize = 32 << 10
+	formatters["json_presized"] = presized
+	for fname, f := range formatters {
+		for rname, r := range records {
+			actual := len(mustFormat(t, f, r))
+			hint := f.SizeHint(r)
+			// оценка дешёвая: допускаем расхождение до полутора раз в любую сторону
+			if hint < actual*2/3 || hint > actual*3/2 {
+				t.Errorf("%s %s: hint %d, actual %d", fname, rname, hint, actual)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"testing"
+)
+
+// scalarSliceRecords возвращает одни и те же значения срезами скаляров (быстрый
+// путь без упаковки) и []any (общий путь).
+func scalarSliceRecords() (fast, general core.LogRecord) {
+	fast = core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"ints":    []int{-1, 0, 1 << 40},
+		"strs":    []string{"a", "b\n", `"q"`},
+		"floats":  []float64{0.5, -2, 1e21},
+		"bools":   []bool{true, false},
+		"uints":   []uint32{7},
+		"array":   [2]int{3, 4},
+		"big_u64": []uint64{1<<64 - 1},
+	}}
+	general = core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"ints":    []any{-1, 0, 1 << 40},
+		"strs":    []any{"a", "b\n", `"q"`},
+		"floats":  []any{0.5, -2.0, 1e21},
+		"bools":   []any{true, false},
+		"uints":   []any{uint32(7)},
+		"array":   []any{3, 4},
+		"big_u64": []any{uint64(1<<64 - 1)},
+	}}
+	return fast, general
+}
+
+func TestScalarSliceFastPathMatchesGeneral(t *testing.T) {
+	fast, general := scalarSliceRecords()
+	formatters := map[string]core.FormatProcessor{
+		"json": NewJsonFormatter(nil, nil),
+		"text": NewTextFormatter(nil, nil),
+	}
+	for name, f := range formatters {
+		want := string(mustFormat(t, f, general))
+		if got := string(mustFormat(t, f, fast)); got != want {
+			t.Errorf("%s: fast path\n%s\nwant\n%s", name, got, want)
+		}
+	}
+}
+
+// Элементы []int не упаковываются в any: аллокаций не больше, чем на саму запись.
+func TestIntSliceDoesNotBoxElements(t *testing.T) {
+	ints := make([]int, 10000)
+	for i := range ints {
+		ints[i] = i * 1000
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{"ints": ints}}
+	for name, f := range map[string]core.FormatProcessor{
+		"json": NewJsonFormatter(nil, nil),
+		"text": NewTextFormatter(nil, nil),
+	} {
+		f := f
+		allocs := testing.AllocsPerRun(10, func() {
+			if _, err := f.Format(r); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > 100 {
+			t.Errorf("%s: %.0f allocs for 10k ints", name, allocs)
+		}
+	}
+}
+
+func BenchmarkFormatIntSlice(b *testing.B) {
+	ints := make([]int, 10000)
+	for i := range ints {
+		ints[i] = i
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{"ints": ints}}
+	for name, f := range map[string]core.FormatProcessor{
+		"json": NewJsonFormatter(nil, nil),
+		"text": NewTextFormatter(nil, nil),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Format(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ptrStringer и ptrError реализуют интерфейсы только на указателе.
+type ptrStringer struct{ ID int }
+
+func (p *ptrStringer) String() string { return "id-" + strconv.Itoa(p.ID) }
+
+type ptrError struct{ Code int }
+
+func (e *ptrError) Error() string { return "code " + strconv.Itoa(e.Code) }
+
+func TestPointerReceiverStringerByValue(t *testing.T) {
+	type holder struct {
+		S ptrStringer `json:"s"`
+		E ptrError    `json:"e"`
+	}
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"struct": holder{S: ptrStringer{1}, E: ptrError{2}},
+		"slice":  []ptrStringer{{3}, {4}},
+		"array":  [1]ptrError{{5}},
+		"map":    map[string]ptrStringer{"k": {6}},
+		"ptr":    &ptrStringer{7},
+	}}
+
+	out := string(mustFormat(t, NewJsonFormatter(nil, nil), r))
+	for _, want := range []string{
+		`"struct":{"e":"code 2","s":"id-1"}`,
+		`"slice":["id-3","id-4"]`,
+		`"array":["code 5"]`,
+		`"map":{"k":"id-6"}`,
+		`"ptr":"id-7"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("json: missing %s in %s", want, out)
+		}
+	}
+
+	// msgpack: те же строки как fixstr
+	mp := string(mustFormat(t, NewMsgpackFormatter(nil), r))
+	for _, want := range []string{"id-1", "code 2", "id-3", "id-4", "code 5", "id-6", "id-7"} {
+		if !strings.Contains(mp, string([]byte{byte(0xa0 | len(want))})+want) {
+			t.Errorf("msgpack: missing %q", want)
+		}
+	}
+}
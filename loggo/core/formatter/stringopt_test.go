@@ -0,0 +1,51 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type stringOpt struct {
+	ID     int      `json:"id,string"`
+	OK     bool     `json:"ok,string"`
+	Ratio  float64  `json:"ratio,string"`
+	Name   string   `json:"name,string"`
+	Ptr    *int     `json:"ptr,string"`
+	Nil    *int     `json:"nil,string"`
+	Tags   []string `json:"tags,string"` // не скаляр — опция игнорируется
+	Zero   int      `json:"zero,string,omitempty"`
+	Plain  int      `json:"plain"`
+	Spaced uint8    `json:"spaced,omitempty,string"`
+}
+
+func TestJSONTagStringOption(t *testing.T) {
+	n := 7
+	v := stringOpt{ID: 42, OK: true, Ratio: 1.5, Name: "x", Ptr: &n, Tags: []string{"a"}, Plain: 3, Spaced: 9}
+
+	out := mustFormat(t, NewJsonFormatter(nil, nil), core.LogRecord{Level: core.Info, Fields: map[string]any{"v": v}})
+	if !strings.Contains(string(out), `"id":"42"`) {
+		t.Errorf("id not quoted: %s", out)
+	}
+
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wantDoc any
+	if err := json.Unmarshal(want, &wantDoc); err != nil {
+		t.Fatal(err)
+	}
+	if got := decodeJSON(t, out)["v"]; !reflect.DeepEqual(got, wantDoc) {
+		t.Errorf("got  %v\nwant %s", got, want)
+	}
+
+	// без тега ",string" числа остаются числами
+	if out := string(mustFormat(t, NewJsonFormatter(nil, nil), core.LogRecord{
+		Level: core.Info, Fields: map[string]any{"v": struct{ ID int }{42}},
+	})); !strings.Contains(out, `"ID":42`) {
+		t.Errorf("untagged: %s", out)
+	}
+}
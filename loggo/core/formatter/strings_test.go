@@ -0,0 +1,81 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"testing"
+	"time"
+)
+
+// plainString — именованный строковый тип без методов: форматируется как
+// string, но не проходит allStringFields и идёт общим путём.
+type plainString string
+
+// stringRecords возвращает одну и ту же запись из пяти строковых полей дважды:
+// с значениями string (быстрый путь) и plainString (общий путь).
+func stringRecords() (fast, general core.LogRecord) {
+	values := map[string]string{
+		"method":     "GET",
+		"path":       "/api/v1/users?q=\"x\"",
+		"status":     "ok\ttab",
+		"request_id": "7f3c2a91\n",
+		"user_agent": "curl/8.5.0 é \x01",
+	}
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	fast = core.LogRecord{Level: core.Info, Timestamp: ts, Message: "request handled", Fields: map[string]any{}}
+	general = fast
+	general.Fields = map[string]any{}
+	for k, v := range values {
+		fast.Fields[k] = v
+		general.Fields[k] = plainString(v)
+	}
+	return fast, general
+}
+
+func TestAllStringFastPathMatchesGeneral(t *testing.T) {
+	fast, general := stringRecords()
+	if !allStringFields(fast.Fields) || allStringFields(general.Fields) {
+		t.Fatal("records do not exercise both paths")
+	}
+	formatters := map[string]core.FormatProcessor{
+		"json": NewJsonFormatter(nil, nil),
+		"text": NewTextFormatter(nil, nil),
+	}
+	for name, f := range formatters {
+		want, err := f.Format(general)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append([]byte(nil), want...)
+		got, err := f.Format(fast)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s: fast path\n%s\nwant\n%s", name, got, want)
+		}
+	}
+}
+
+func BenchmarkFormatAllStrings(b *testing.B) {
+	fast, general := stringRecords()
+	formatters := []struct {
+		name string
+		f    core.FormatProcessor
+	}{
+		{"json", NewJsonFormatter(nil, nil)},
+		{"text", NewTextFormatter(nil, nil)},
+	}
+	for _, fm := range formatters {
+		for _, c := range []struct {
+			name string
+			r    core.LogRecord
+		}{{"fast", fast}, {"general", general}} {
+			b.Run(fm.name+"/"+c.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, _ = fm.f.Format(c.r)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExpandSyncMap(t *testing.T) {
+	var sm sync.Map
+	sm.Store("b", 2)
+	sm.Store("a", "x")
+	sm.Store(3, true)
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"ptr":    &sm,
+		"nested": map[string]any{"m": &sm},
+	}}
+
+	jf := NewJsonFormatter(nil, nil)
+	jf.ExpandSyncMap = true
+	out := string(mustFormat(t, jf, r))
+	for _, want := range []string{`"ptr":{"3":true,"a":"x","b":2}`, `"nested":{"m":{"3":true,"a":"x","b":2}}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("json: missing %s in %s", want, out)
+		}
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.ExpandSyncMap = true
+	if text := string(mustFormat(t, tf, r)); !strings.Contains(text, `ptr={3: true, a: "x", b: 2}`) {
+		t.Errorf("text: %s", text)
+	}
+
+	// по умолчанию выключено
+	if out := string(mustFormat(t, NewJsonFormatter(nil, nil), r)); strings.Contains(out, `"a":"x"`) {
+		t.Errorf("expanded without ExpandSyncMap: %s", out)
+	}
+}
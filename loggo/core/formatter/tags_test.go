@@ -0,0 +1,53 @@
+package formatter
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTagsRenderAsJSONArray(t *testing.T) {
+	r := core.LogRecord{Level: core.Info, Message: "m", Tags: []string{"billing", `q"uote`}}
+	out, err := NewJsonFormatter(nil, nil).Format(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid JSON %s: %v", out, err)
+	}
+	if got := doc[tagsKey]; !reflect.DeepEqual(got, []any{"billing", `q"uote`}) {
+		t.Fatalf("tags = %#v", got)
+	}
+
+	r.Tags = nil
+	out, _ = NewJsonFormatter(nil, nil).Format(r)
+	if strings.Contains(string(out), tagsKey) {
+		t.Fatalf("no tags must not render a tags key: %s", out)
+	}
+}
+
+func TestTextTagsQuoted(t *testing.T) {
+	cases := []struct {
+		tags []string
+		want string
+	}{
+		{[]string{"billing", "hot-path"}, " [billing, hot-path]"},
+		{[]string{"a, b", "c]"}, ` ["a, b", "c]"]`},
+		{[]string{"two words", `q"`, "nl\n"}, ` ["two words", "q\"", "nl\n"]`},
+		{[]string{"bad\xff"}, ` ["bad\xff"]`},
+		{[]string{"ünï"}, " [ünï]"},
+	}
+	for _, c := range cases {
+		r := core.LogRecord{Level: core.Info, Message: "m", Tags: c.tags}
+		out, err := NewTextFormatter(nil, nil).Format(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(out), c.want) {
+			t.Errorf("tags %q: %q, want suffix %q", c.tags, out, c.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package formatter
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"funchooooza-ossh/loggo/core"
 	"reflect"
@@ -9,20 +10,86 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+// TextFormatter пишет запись одной читаемой строкой. nil-map и nil-срез
+// выводятся как null (NullToken), пустые — как {} и [].
 type TextFormatter struct {
 	style    *core.FormatStyle
 	MaxDepth int
+	// TimePrecision задаёт точность времени (time.Second, time.Millisecond, ...):
+	// для полей time.Time и для времени записи, если не задан TimestampLayout.
+	// 0 — поля как есть, время записи — с миллисекундами.
+	TimePrecision time.Duration
+	// SchemaVersion — если задан, в каждую запись добавляется поле schema_version.
+	SchemaVersion string
+	// ExpandSyncMap выводит sync.Map как объект с отсортированными ключами
+	// (иначе он выглядит как пустая структура).
+	ExpandSyncMap bool
+	// MaxKeyLen ограничивает длину ключей (в байтах) на всех уровнях; 0 — без ограничения.
+	MaxKeyLen int
+	// MaxElements — бюджет элементов срезов, массивов и map на всю запись, как
+	// у JsonFormatter: по исчерпании — один маркер "<max_elements>". 0 — без
+	// ограничения.
+	MaxElements int
+	// BytesAsHex выводит []byte и [N]byte (в том числе поля структур: хеши,
+	// UUID) hex-строкой вместо длины "[]byte(N)".
+	BytesAsHex bool
+	// TimestampLayout — раскладка времени записи; пусто — "2006-01-02 15:04:05.000".
+	TimestampLayout string
+	// ShortTimestamp выводит время записи без даты — "15:04:05.000" (дробная
+	// часть по TimePrecision): для консоли, где дата в пределах сессии лишняя.
+	// TimestampLayout, если задан, приоритетнее.
+	ShortTimestamp bool
+	// LevelWidth — ширина колонки уровня; 0 — 7 ("WARNING"), < 0 — по самому
+	// длинному имени уровня с учётом core.RegisterLevel.
+	LevelWidth int
+	// FieldSep — разделитель между полями (пусто — пробел), KeyValSep — между
+	// ключом и значением (пусто — "="). Например, "\t" для разбора по табуляции.
+	FieldSep  string
+	KeyValSep string
+	// KeyPadWidth дополняет ключи полей верхнего уровня пробелами до этой
+	// ширины (в символах), чтобы значения вставали в колонку: "id   =1".
+	// Строки независимы, поэтому ширина фиксированная; более длинные ключи
+	// не обрезаются. 0 — без выравнивания.
+	KeyPadWidth int
+	// KeyCase нормализует ключи полей, вложенных map и структур (snake_case,
+	// camelCase, kebab-case).
+	KeyCase KeyCase
+	// HexPointers выводит uintptr и unsafe.Pointer адресом 0x...; по умолчанию
+	// uintptr — числом, unsafe.Pointer — "<unsupported:unsafe.Pointer>".
+	HexPointers bool
+	// OmitEmptyMessage пропускает сегмент "→ message", если сообщение пустое.
+	OmitEmptyMessage bool
+	// TrimMessage обрезает пробельные символы (в том числе \n) по краям
+	// сообщения; переводы строк внутри сохраняются. По умолчанию сообщение
+	// выводится как есть. Срабатывает до OmitEmptyMessage.
+	TrimMessage bool
+	// StringifyMapKeys выводит map с нестроковыми ключами (int, fmt.Stringer, ...),
+	// приводя ключи к строке; без него такие map — "<unsupported_map_key>".
+	StringifyMapKeys bool
+	// StructFieldOrder — порядок полей структур: по алфавиту (по умолчанию) или
+	// в порядке объявления, как в encoding/json.
+	StructFieldOrder StructFieldOrder
+	// RelativeToStart выводит время записи как смещение от StartTime ("+0.123s")
+	// вместо абсолютного; точность — TimePrecision (по умолчанию миллисекунды).
+	RelativeToStart bool
+	// StartTime — база для RelativeToStart; конструктор ставит момент создания.
+	StartTime time.Time
+	// TimeFunc, если задан, форматирует время записи (ts) целиком: перекрывает
+	// TimePrecision, TimestampLayout и RelativeToStart. Поля time.Time он не затрагивает.
+	TimeFunc func(time.Time) string
+	// FieldLayout — порядок сегментов [ts] LEVEL caller → msg; #seq идёт за
+	// временем. По умолчанию ts, level, caller, msg.
+	FieldLayout FieldLayout
 }
 
+// NewTextFormatter создаёт TextFormatter; maxDepth == nil, 0 или < 0 — глубина
+// по умолчанию (3).
 func NewTextFormatter(style *core.FormatStyle, maxDepth *int) *TextFormatter {
-	var depth int
-	if maxDepth == nil {
-		depth = defaultDepth
-	} else {
-		depth = *maxDepth
-	}
+	depth := depthOrDefault(maxDepth)
 	if style == nil {
 		style = &core.FormatStyle{
 			ColorKeys:   false,
@@ -33,51 +100,155 @@ func NewTextFormatter(style *core.FormatStyle, maxDepth *int) *TextFormatter {
 			Reset:       "\033[0m",
 		}
 	}
-	return &TextFormatter{style: style, MaxDepth: depth}
+	return &TextFormatter{style: style, MaxDepth: depth, StartTime: time.Now()}
 }
 
-func (f *TextFormatter) Format(r core.LogRecord) ([]byte, error) {
-	var b bytes.Buffer
+// Reset возвращает форматтер к состоянию после конструктора: StartTime —
+// текущий момент. Других данных между вызовами Format форматтер не хранит,
+// так что после Reset его можно переиспользовать, например между прогонами
+// бенчмарка, вместо создания нового.
+func (f *TextFormatter) Reset() {
+	f.StartTime = time.Now()
+}
 
-	// [timestamp]
-	b.WriteString("[")
-	b.WriteString(r.Timestamp.Format("2006-01-02 15:04:05.000"))
-	b.WriteString("] ")
+func (f *TextFormatter) Format(r core.LogRecord) ([]byte, error) {
+	if f.TrimMessage {
+		r.Message = strings.TrimSpace(r.Message)
+	}
+	r.Fields = f.KeyCase.applyFields(r.Fields)
+	b := bufPool.Get().(*bytes.Buffer)
+	b.Reset()
+	defer putBuf(b)
 
-	// LEVEL
-	if f.style.ColorLevel {
+	if f.style.ColorWholeLine {
 		b.WriteString(r.Level.Color())
 	}
-	b.WriteString(padLevel(r.Level.String()))
-	if f.style.ColorLevel {
-		b.WriteString(f.style.Reset)
-	}
-	b.WriteByte(' ')
 
-	// → message
-	b.WriteString("→ ")
-	b.WriteString(r.Message)
+	// [timestamp] #seq LEVEL caller → message — в порядке FieldLayout
+	layout := f.FieldLayout.resolve(defaultTextLayout)
+	// последняя колонка строки не выравнивается: иначе хвостовые пробелы
+	last := -1
+	if f.SchemaVersion == "" && len(r.Fields) == 0 && len(r.Tags) == 0 {
+		for i, fl := range layout {
+			if !f.skipReserved(r, fl) {
+				last = i
+			}
+		}
+	}
+	first := true
+	for i, fl := range layout {
+		if f.skipReserved(r, fl) {
+			continue
+		}
+		if !first {
+			if fl == LayoutMessage {
+				b.WriteString(" → ")
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		first = false
+		f.writeReserved(b, r, fl, i != last)
+	}
 
-	// поля (отсортированы для стабильности)
+	// поля: schema_version первым, затем пользовательские (отсортированы для стабильности)
+	fieldSep, kvSep := f.separators()
+	if f.SchemaVersion != "" || len(r.Fields) > 0 {
+		b.WriteString(" | ")
+	}
+	first = true
+	if f.SchemaVersion != "" {
+		f.writeFieldKey(b, truncateKey(schemaVersionKey, f.MaxKeyLen), kvSep)
+		b.WriteString(f.colorizeValue(strconv.Quote(f.SchemaVersion)))
+		first = false
+	}
 	if len(r.Fields) > 0 {
-		b.WriteString(" |")
 		keys := make([]string, 0, len(r.Fields))
 		for k := range r.Fields {
 			keys = append(keys, k)
 		}
 		sort.Strings(keys)
-		visited := make(map[uintptr]struct{})
+		// быстрый путь: все значения — строки (частый случай); без visitSet и
+		// общего renderText, вывод тот же
+		strs := f.MaxDepth > 0 && allStringFields(r.Fields)
+		var visited visitSet
+		if !strs {
+			visited = newVisitSet(f.MaxElements)
+		}
+		// KeyCase уже применён applyFields — здесь только MaxKeyLen
+		used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(keys))
 		for _, k := range keys {
-			b.WriteByte(' ')
-			b.WriteString(f.colorizeKey(k))
-			b.WriteByte('=')
-			f.renderText(&b, r.Fields[k], 0, visited)
+			if !first {
+				b.WriteString(fieldSep)
+			}
+			first = false
+			f.writeFieldKey(b, used.unique(truncateKey(k, f.MaxKeyLen)), kvSep)
+			if strs {
+				f.writeTextString(b, r.Fields[k].(string))
+				continue
+			}
+			f.renderText(b, r.Fields[k], 0, visited)
 		}
 	}
-	return b.Bytes(), nil
+
+	// метки — суффиксом: " [billing, hot-path]"
+	if len(r.Tags) > 0 {
+		b.WriteString(" [")
+		for i, t := range r.Tags {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeTextTag(b, t)
+		}
+		b.WriteByte(']')
+	}
+
+	if f.style.ColorWholeLine {
+		b.WriteString(r.Level.Reset())
+	}
+	// буфер вернётся в пул — отдаём копию
+	return append([]byte(nil), b.Bytes()...), nil
+}
+
+// skipReserved сообщает, что служебное поле fl в строке не выводится.
+func (f *TextFormatter) skipReserved(r core.LogRecord, fl LayoutField) bool {
+	return fl == LayoutCaller && r.Caller == "" ||
+		fl == LayoutMessage && f.OmitEmptyMessage && r.Message == ""
 }
 
-func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited map[uintptr]struct{}) {
+// writeReserved выводит служебное поле fl (см. FieldLayout); pad — выравнивать
+// уровень по ширине колонки.
+func (f *TextFormatter) writeReserved(b *bytes.Buffer, r core.LogRecord, fl LayoutField, pad bool) {
+	switch fl {
+	case LayoutTime:
+		b.WriteString("[")
+		b.WriteString(f.formatTimestamp(r.Timestamp))
+		b.WriteString("]")
+		if r.Seq != 0 {
+			b.WriteString(" #")
+			b.WriteString(strconv.FormatUint(r.Seq, 10))
+		}
+	case LayoutLevel:
+		colorLevel := f.style.ColorLevel && !f.style.ColorWholeLine
+		if colorLevel {
+			b.WriteString(r.Level.Color())
+		}
+		if pad {
+			b.WriteString(padLevel(r.Level.String(), f.levelWidth()))
+		} else {
+			b.WriteString(r.Level.String())
+		}
+		if colorLevel {
+			b.WriteString(f.style.Reset)
+		}
+	case LayoutCaller:
+		b.WriteString(r.Caller)
+	case LayoutMessage:
+		b.WriteString(r.Message)
+	}
+}
+
+func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited visitSet) {
 	if depth >= f.MaxDepth {
 		b.WriteString(f.colorizeValue("<max_depth>"))
 		return
@@ -88,32 +259,55 @@ func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited ma
 		return
 	}
 
+	if f.HexPointers {
+		if s, ok := pointerHex(v); ok {
+			b.WriteString(f.colorizeValue(s))
+			return
+		}
+	}
+
+	if s, special, ok := bigNumberString(v); ok {
+		if special && s == "" {
+			s = f.nullToken()
+		}
+		b.WriteString(f.colorizeValue(s))
+		return
+	}
+
+	if f.ExpandSyncMap {
+		if m, ok := syncMapToMap(v); ok {
+			f.renderText(b, m, depth, visited)
+			return
+		}
+	}
+
 	switch x := v.(type) {
 	case nil:
-		b.WriteString(f.colorizeValue("null"))
+		b.WriteString(f.colorizeValue(f.nullToken()))
 
 	case string:
-		s := addMultilinePrefix(x)
-		// используем Quote, чтобы гарантировать однострочность (экранированные \n)
-		b.WriteString(f.colorizeValue(strconv.Quote(s)))
+		f.writeTextString(b, x)
 
 	case bool:
-		if x {
-			b.WriteString(f.colorizeValue("true"))
-		} else {
-			b.WriteString(f.colorizeValue("false"))
-		}
+		b.WriteString(f.colorizeValue(f.boolToken(x)))
 
 	case int, int8, int16, int32, int64:
-		b.WriteString(f.colorizeValue(strconv.FormatInt(reflect.ValueOf(x).Int(), 10)))
+		b.WriteString(f.colorizeValue(f.formatInt(reflect.ValueOf(x).Int())))
 
 	case uint, uint8, uint16, uint32, uint64, uintptr:
-		b.WriteString(f.colorizeValue(strconv.FormatUint(reflect.ValueOf(x).Uint(), 10)))
+		b.WriteString(f.colorizeValue(f.formatUint(reflect.ValueOf(x).Uint())))
 
 	case float32, float64:
 		b.WriteString(f.colorizeValue(toFloatString(x)))
 
+	case time.Time:
+		b.WriteString(f.colorizeValue(formatTime(x, f.TimePrecision)))
+
 	case map[string]any:
+		if x == nil {
+			b.WriteString(f.colorizeValue(f.nullToken()))
+			return
+		}
 		// защита от циклов на контейнере
 		if ok, release := markAndCheck(reflect.ValueOf(x), visited); !ok {
 			b.WriteString(f.colorizeValue("<cycle>"))
@@ -128,17 +322,26 @@ func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited ma
 			keys = append(keys, k)
 		}
 		sort.Strings(keys)
+		used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(keys))
 		for i, k := range keys {
+			if !visited.take() {
+				f.writeElemsTruncated(b, visited, i, true)
+				break
+			}
 			if i > 0 {
 				b.WriteString(", ")
 			}
-			b.WriteString(f.colorizeKey(k))
+			b.WriteString(f.colorizeName(used.unique(f.keyName(k))))
 			b.WriteString(": ")
 			f.renderText(b, x[k], depth+1, visited)
 		}
 		b.WriteByte('}')
 
 	case []any:
+		if x == nil {
+			b.WriteString(f.colorizeValue(f.nullToken()))
+			return
+		}
 		// защита от циклов на контейнере
 		if ok, release := markAndCheck(reflect.ValueOf(x), visited); !ok {
 			b.WriteString(f.colorizeValue("<cycle>"))
@@ -149,6 +352,10 @@ func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited ma
 
 		b.WriteByte('[')
 		for i := range x {
+			if !visited.take() {
+				f.writeElemsTruncated(b, visited, i, false)
+				break
+			}
 			if i > 0 {
 				b.WriteString(", ")
 			}
@@ -160,7 +367,7 @@ func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited ma
 		// Рефлект-обход без обращения к JsonFormatter
 		rv := reflect.ValueOf(v)
 		if !rv.IsValid() {
-			b.WriteString(f.colorizeValue("null"))
+			b.WriteString(f.colorizeValue(f.nullToken()))
 			return
 		}
 
@@ -175,98 +382,86 @@ func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited ma
 		switch rv.Kind() {
 		case reflect.Ptr:
 			if rv.IsNil() {
-				b.WriteString(f.colorizeValue("null"))
+				b.WriteString(f.colorizeValue(f.nullToken()))
 				return
 			}
 			f.renderText(b, rv.Elem().Interface(), depth+1, visited)
 
 		case reflect.Interface:
 			if rv.IsNil() {
-				b.WriteString(f.colorizeValue("null"))
+				b.WriteString(f.colorizeValue(f.nullToken()))
 				return
 			}
 			f.renderText(b, rv.Elem().Interface(), depth+1, visited)
 
 		case reflect.Struct:
-			// стабильно по алфавиту с учётом json-тегов
-			type kv struct {
-				key string
-				idx int
-			}
-			t := rv.Type()
-			fields := make([]kv, 0, rv.NumField())
-			for i := 0; i < rv.NumField(); i++ {
-				sf := t.Field(i)
-				if sf.PkgPath != "" {
-					continue
-				} // unexported
-				key := sf.Name
-				if tag := sf.Tag.Get("json"); tag != "" {
-					parts := strings.Split(tag, ",")
-					if parts[0] == "-" {
-						continue
-					}
-					if parts[0] != "" {
-						key = parts[0]
-					}
-					for _, opt := range parts[1:] {
-						if opt == "omitempty" && rv.Field(i).IsZero() {
-							key = ""
-							break
-						}
-					}
-					if key == "" {
-						continue
-					}
-				}
-				fields = append(fields, kv{key, i})
-
-			}
-			sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
-
+			// по StructFieldOrder с учётом json-тегов и встроенных структур
 			b.WriteByte('{')
-			for i, fdef := range fields {
+			fields := structFields(rv, f.StructFieldOrder)
+			used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(fields))
+			for i, sf := range fields {
 				if i > 0 {
 					b.WriteString(", ")
 				}
-				b.WriteString(f.colorizeKey(fdef.key))
+				b.WriteString(f.colorizeName(used.unique(f.keyName(sf.key))))
 				b.WriteString(": ")
-				f.renderText(b, rv.Field(fdef.idx).Interface(), depth+1, visited)
+				f.renderText(b, sf.value.Interface(), depth+1, visited)
 			}
 			b.WriteByte('}')
 
 		case reflect.Map:
-			// только строковые ключи красиво печатаем
-			if rv.Type().Key().Kind() != reflect.String {
-				b.WriteString(f.colorizeValue("<unsupported_map_key>"))
+			if rv.IsNil() {
+				b.WriteString(f.colorizeValue(f.nullToken()))
 				return
 			}
-			keys := rv.MapKeys()
-			ss := make([]string, len(keys))
-			for i, k := range keys {
-				ss[i] = k.String()
+			// нестроковые ключи — только со StringifyMapKeys
+			entries, ok := sortedMapEntries(rv, f.StringifyMapKeys)
+			if !ok {
+				b.WriteString(f.colorizeValue("<unsupported_map_key>"))
+				return
 			}
-			sort.Strings(ss)
 
 			b.WriteByte('{')
-			for i, k := range ss {
+			used := f.KeyCase.nestedKeySet(f.MaxKeyLen, len(entries))
+			for i, e := range entries {
+				if !visited.take() {
+					f.writeElemsTruncated(b, visited, i, true)
+					break
+				}
 				if i > 0 {
 					b.WriteString(", ")
 				}
-				b.WriteString(f.colorizeKey(k))
+				b.WriteString(f.colorizeName(used.unique(f.keyName(e.key))))
 				b.WriteString(": ")
-				f.renderText(b, rv.MapIndex(reflect.ValueOf(k)).Interface(), depth+1, visited)
+				f.renderText(b, e.value.Interface(), depth+1, visited)
 			}
 			b.WriteByte('}')
 
 		case reflect.Slice, reflect.Array:
+			// nil-срез — null, пустой — []
+			if rv.Kind() == reflect.Slice && rv.IsNil() {
+				b.WriteString(f.colorizeValue(f.nullToken()))
+				return
+			}
 			if rv.Type().Elem().Kind() == reflect.Uint8 {
+				if f.BytesAsHex {
+					b.WriteString(f.colorizeValue(hex.EncodeToString(bytesOf(rv))))
+					return
+				}
 				b.WriteString(f.colorizeValue(fmt.Sprintf("[]byte(%d)", rv.Len())))
 				return
 			}
+			if depth+1 < f.MaxDepth && hasScalarElems(rv) && visited.fits(rv.Len()) {
+				f.renderScalarSlice(b, rv)
+				return
+			}
 			n := rv.Len()
 			b.WriteByte('[')
 			for i := 0; i < n; i++ {
+				if !visited.take() {
+					f.writeElemsTruncated(b, visited, i, false)
+					break
+				}
 				if i > 0 {
 					b.WriteString(", ")
 				}
@@ -275,50 +470,319 @@ func (f *TextFormatter) renderText(b *bytes.Buffer, v any, depth int, visited ma
 			b.WriteByte(']')
 
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			b.WriteString(f.colorizeValue(strconv.FormatInt(rv.Int(), 10)))
+			b.WriteString(f.colorizeValue(f.formatInt(rv.Int())))
 
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-			b.WriteString(f.colorizeValue(strconv.FormatUint(rv.Uint(), 10)))
+			b.WriteString(f.colorizeValue(f.formatUint(rv.Uint())))
 
 		case reflect.Float32:
 			b.WriteString(f.colorizeValue(strconv.FormatFloat(rv.Float(), 'f', -1, 32)))
 		case reflect.Float64:
 			b.WriteString(f.colorizeValue(strconv.FormatFloat(rv.Float(), 'f', -1, 64)))
 
+		case reflect.Complex64, reflect.Complex128:
+			// a+bi: FormatComplex без внешних скобок
+			s := strconv.FormatComplex(rv.Complex(), 'f', -1, rv.Type().Bits())
+			b.WriteString(f.colorizeValue(s[1 : len(s)-1]))
+
 		case reflect.Bool:
-			if rv.Bool() {
-				b.WriteString(f.colorizeValue("true"))
-			} else {
-				b.WriteString(f.colorizeValue("false"))
-			}
+			b.WriteString(f.colorizeValue(f.boolToken(rv.Bool())))
 
 		case reflect.String:
 			s := addMultilinePrefix(rv.String())
 			b.WriteString(f.colorizeValue(strconv.Quote(s)))
 
+		case reflect.UnsafePointer:
+			if rv.IsNil() {
+				b.WriteString(f.colorizeValue(f.nullToken()))
+				return
+			}
+			// адрес — только с HexPointers
+			b.WriteString(f.colorizeValue("<unsupported:unsafe.Pointer>"))
+
+		case reflect.Func, reflect.Chan:
+			if rv.IsNil() {
+				b.WriteString(f.colorizeValue(f.nullToken()))
+				return
+			}
+			b.WriteString(f.colorizeValue(fmt.Sprint(v)))
+
 		default:
 			b.WriteString(f.colorizeValue(fmt.Sprint(v)))
 		}
 	}
 }
 
+// writeElemsTruncated дописывает в открытый контейнер маркер исчерпания
+// MaxElements (только в первый обрезанный контейнер записи); i — число уже
+// выведенных элементов, object — контейнер с ключами ("…: <max_elements>").
+func (f *TextFormatter) writeElemsTruncated(b *bytes.Buffer, visited visitSet, i int, object bool) {
+	if !visited.markTruncated() {
+		return
+	}
+	if i > 0 {
+		b.WriteString(", ")
+	}
+	if object {
+		b.WriteString(f.colorizeKey(truncatedKeyMarker))
+		b.WriteString(": ")
+	}
+	b.WriteString(f.colorizeValue(maxElementsMarker))
+}
+
+// renderScalarSlice — быстрый путь для срезов скаляров без упаковки элементов в any.
+// Вывод совпадает с общим путём через renderText.
+func (f *TextFormatter) renderScalarSlice(b *bytes.Buffer, rv reflect.Value) {
+	et := rv.Type().Elem()
+	n := rv.Len()
+	// без цвета и SI-суффиксов числа и строки пишутся прямо в буфер, без
+	// промежуточной строки на элемент
+	plain := !(f.style.ColorValues && !f.style.ColorWholeLine) && !f.style.HumanizeNumbers
+
+	b.WriteByte('[')
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		ev := rv.Index(i)
+		if plain {
+			switch et.Kind() {
+			case reflect.String:
+				b.Write(strconv.AppendQuote(b.AvailableBuffer(), addMultilinePrefix(ev.String())))
+				continue
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				b.Write(strconv.AppendInt(b.AvailableBuffer(), ev.Int(), 10))
+				continue
+			case reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				b.Write(strconv.AppendUint(b.AvailableBuffer(), ev.Uint(), 10))
+				continue
+			case reflect.Float32, reflect.Float64:
+				b.Write(strconv.AppendFloat(b.AvailableBuffer(), ev.Float(), 'f', -1, et.Bits()))
+				continue
+			}
+		}
+		switch et.Kind() {
+		case reflect.String:
+			b.WriteString(f.colorizeValue(strconv.Quote(addMultilinePrefix(ev.String()))))
+		case reflect.Bool:
+			b.WriteString(f.colorizeValue(f.boolToken(ev.Bool())))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(f.colorizeValue(f.formatInt(ev.Int())))
+		case reflect.Float32, reflect.Float64:
+			b.WriteString(f.colorizeValue(strconv.FormatFloat(ev.Float(), 'f', -1, et.Bits())))
+		default:
+			b.WriteString(f.colorizeValue(f.formatUint(ev.Uint())))
+		}
+	}
+	b.WriteByte(']')
+}
+
+// writeFieldKey выводит готовое имя поля верхнего уровня с выравниванием
+// KeyPadWidth (пробелы — вне цветовых кодов) и разделитель kvSep.
+func (f *TextFormatter) writeFieldKey(b *bytes.Buffer, name, kvSep string) {
+	b.WriteString(f.colorizeName(name))
+	if f.KeyPadWidth > 0 {
+		n := utf8.RuneCountInString(name)
+		for ; n < f.KeyPadWidth; n++ {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteString(kvSep)
+}
+
+// keyName применяет к ключу KeyCase и ограничение MaxKeyLen.
+func (f *TextFormatter) keyName(k string) string {
+	return truncateKey(f.KeyCase.apply(k), f.MaxKeyLen)
+}
+
 func (f *TextFormatter) colorizeKey(k string) string {
-	if f.style.ColorKeys {
+	return f.colorizeName(f.keyName(k))
+}
+
+func (f *TextFormatter) colorizeName(k string) string {
+	if f.style.ColorKeys && !f.style.ColorWholeLine {
 		return f.style.KeyColor + k + f.style.Reset
 	}
 	return k
 }
 
+// writeTextString выводит строковое значение в кавычках: Quote гарантирует
+// однострочность (экранированные \n).
+func (f *TextFormatter) writeTextString(b *bytes.Buffer, s string) {
+	s = addMultilinePrefix(s)
+	if f.style.ColorValues && !f.style.ColorWholeLine {
+		b.WriteString(f.colorizeValue(strconv.Quote(s)))
+		return
+	}
+	b.Write(strconv.AppendQuote(b.AvailableBuffer(), s))
+}
+
+// writeTextTag выводит метку как есть, если её не спутать с разметкой суффикса
+// " [a, b]", иначе — в кавычках, как strconv.Quote: с разделителями, скобками,
+// кавычками, пробелами, непечатаемыми символами и невалидным UTF-8.
+func writeTextTag(b *bytes.Buffer, t string) {
+	bare := strings.IndexFunc(t, func(r rune) bool {
+		switch r {
+		case ',', '[', ']', '"', utf8.RuneError:
+			return true
+		}
+		return unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) < 0
+	if bare {
+		b.WriteString(t)
+		return
+	}
+	b.Write(strconv.AppendQuote(b.AvailableBuffer(), t))
+}
+
 func (f *TextFormatter) colorizeValue(v string) string {
-	if f.style.ColorValues {
+	if f.style.ColorValues && !f.style.ColorWholeLine {
 		return f.style.ValueColor + v + f.style.Reset
 	}
 	return v
 }
 
-func padLevel(level string) string {
-	if len(level) < 7 {
-		return level + strings.Repeat(" ", 7-len(level))
+// formatTimestamp печатает время записи: TimeFunc, если задан, смещение при
+// RelativeToStart, TimestampLayout, если задан, иначе дата-время (только время
+// суток при ShortTimestamp) с дробной частью по TimePrecision (по умолчанию
+// миллисекунды).
+func (f *TextFormatter) formatTimestamp(t time.Time) string {
+	if f.TimeFunc != nil {
+		return f.TimeFunc(t)
+	}
+	if f.RelativeToStart {
+		return formatRelative(t.Sub(f.StartTime), f.TimePrecision)
+	}
+	t = t.Round(0)
+	if f.TimestampLayout != "" {
+		return t.Format(f.TimestampLayout)
+	}
+	layout := "2006-01-02 15:04:05"
+	if f.ShortTimestamp {
+		layout = "15:04:05"
+	}
+	if f.TimePrecision > 0 {
+		return t.Truncate(f.TimePrecision).Format(layout + fractionLayout(f.TimePrecision))
+	}
+	if !f.ShortTimestamp {
+		return t.Format(defaultTimestampLayout)
+	}
+	return t.Format(layout + ".000")
+}
+
+func (f *TextFormatter) boolToken(v bool) string {
+	if v {
+		if f.style.TrueToken != "" {
+			return f.style.TrueToken
+		}
+		return "true"
+	}
+	if f.style.FalseToken != "" {
+		return f.style.FalseToken
+	}
+	return "false"
+}
+
+func (f *TextFormatter) nullToken() string {
+	if f.style.NullToken != "" {
+		return f.style.NullToken
+	}
+	return "null"
+}
+
+var defaultHumanizeSuffixes = []string{"k", "M", "G", "T", "P", "E"}
+
+func (f *TextFormatter) formatInt(n int64) string {
+	if n >= 0 {
+		return f.formatUint(uint64(n))
+	}
+	// модуль через uint64: -MinInt64 в int64 не помещается
+	if h, ok := f.humanize(uint64(-(n + 1)) + 1); ok {
+		return "-" + h
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func (f *TextFormatter) formatUint(n uint64) string {
+	if h, ok := f.humanize(n); ok {
+		return h
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// humanize округляет n до одного знака после точки с SI-суффиксом: 1000 → 1k,
+// 1500000 → 1.5M. ok=false — значение ниже порога или опция выключена.
+func (f *TextFormatter) humanize(n uint64) (string, bool) {
+	if !f.style.HumanizeNumbers {
+		return "", false
+	}
+	threshold := f.style.HumanizeThreshold
+	if threshold == 0 {
+		threshold = 1000
+	}
+	suffixes := f.style.HumanizeSuffixes
+	if suffixes == nil {
+		suffixes = defaultHumanizeSuffixes
+	}
+	if n < threshold || n < 1000 || len(suffixes) == 0 {
+		return "", false
+	}
+
+	v := float64(n)
+	i := -1
+	for v >= 1000 && i < len(suffixes)-1 {
+		v /= 1000
+		i++
+	}
+	s := strconv.FormatFloat(v, 'f', 1, 64)
+	if s == "1000.0" && i < len(suffixes)-1 {
+		// 999950 округляется до 1000.0k — это уже 1M
+		s, i = "1.0", i+1
+	}
+	s = strings.TrimSuffix(s, ".0")
+	return s + suffixes[i], true
+}
+
+// SizeHint оценивает длину Format(r) без форматирования (core.SizeHinter).
+func (f *TextFormatter) SizeHint(r core.LogRecord) int {
+	// [2006-01-02 15:04:05.000] WARNING → msg |
+	n := 40 + len(r.Message) + len(r.Caller) + len(f.SchemaVersion)
+	if r.Seq != 0 {
+		n += 22
+	}
+	for k, v := range r.Fields {
+		n += len(k) + 2 + estimateValueSize(v)
+	}
+	if f.style.ColorKeys || f.style.ColorValues || f.style.ColorLevel || f.style.ColorWholeLine {
+		n += 16 * (len(r.Fields) + 1)
+	}
+	return n
+}
+
+func (f *TextFormatter) separators() (field, keyVal string) {
+	field, keyVal = f.FieldSep, f.KeyValSep
+	if field == "" {
+		field = " "
+	}
+	if keyVal == "" {
+		keyVal = "="
+	}
+	return field, keyVal
+}
+
+func (f *TextFormatter) levelWidth() int {
+	switch {
+	case f.LevelWidth > 0:
+		return f.LevelWidth
+	case f.LevelWidth < 0:
+		return core.MaxLevelNameLen()
+	}
+	return defaultLevelWidth
+}
+
+func padLevel(level string, width int) string {
+	if len(level) < width {
+		return level + strings.Repeat(" ", width-len(level))
 	}
 	return level
 }
@@ -0,0 +1,199 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+)
+
+func TestTextCustomTokens(t *testing.T) {
+	var nilMap map[string]any
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"on":     true,
+		"off":    false,
+		"none":   nil,
+		"nested": map[string]any{"on": true, "off": false, "none": nil, "m": nilMap},
+		"list":   []bool{true, false},
+	}}
+
+	f := NewTextFormatter(&core.FormatStyle{TrueToken: "yes", FalseToken: "no", NullToken: "-"}, nil)
+	text := string(mustFormat(t, f, r))
+	for _, want := range []string{
+		"on=yes", "off=no", "none=-",
+		"nested={m: -, none: -, off: no, on: yes}",
+		"list=[yes, no]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in %s", want, text)
+		}
+	}
+
+	// по умолчанию — true/false/null
+	text = string(mustFormat(t, NewTextFormatter(nil, nil), r))
+	if !strings.Contains(text, "nested={m: null, none: null, off: false, on: true}") {
+		t.Errorf("defaults: %s", text)
+	}
+
+	// JSON токены игнорирует
+	got := decodeJSON(t, mustFormat(t, NewJsonFormatter(&core.FormatStyle{TrueToken: "yes", NullToken: "-"}, nil), r))
+	if got["on"] != true || got["none"] != nil {
+		t.Errorf("json: %v", got)
+	}
+}
+
+func TestTextLevelWidth(t *testing.T) {
+	const critical core.LogLevel = 45
+	core.RegisterLevel(critical, "CRITICALITY", "\033[35m")
+
+	// позиция стрелки перед сообщением — ширина колонок до неё
+	arrow := func(f *TextFormatter, lvl core.LogLevel) int {
+		return strings.Index(string(mustFormat(t, f, core.LogRecord{Level: lvl, Message: "m"})), "→")
+	}
+
+	f := NewTextFormatter(nil, nil)
+	f.LevelWidth = -1
+	if a, b := arrow(f, core.Info), arrow(f, critical); a != b {
+		t.Errorf("derived width: INFO at %d, CRITICALITY at %d", a, b)
+	}
+	line := string(mustFormat(t, f, core.LogRecord{Level: core.Info, Message: "m"}))
+	if !strings.Contains(line, "] INFO"+strings.Repeat(" ", len("CRITICALITY")-len("INFO"))+" →") {
+		t.Errorf("derived width: %q", line)
+	}
+
+	f.LevelWidth = 9
+	if line := string(mustFormat(t, f, core.LogRecord{Level: core.Warning, Message: "m"})); !strings.Contains(line, "] WARNING   →") {
+		t.Errorf("fixed width: %q", line)
+	}
+
+	// по умолчанию 7: WARNING и INFO выровнены, более длинное имя не обрезается
+	f = NewTextFormatter(nil, nil)
+	if a, b := arrow(f, core.Info), arrow(f, core.Warning); a != b {
+		t.Errorf("default width: INFO at %d, WARNING at %d", a, b)
+	}
+	if line := string(mustFormat(t, f, core.LogRecord{Level: critical, Message: "m"})); !strings.Contains(line, "] CRITICALITY →") {
+		t.Errorf("long name: %q", line)
+	}
+}
+
+func TestTextColorWholeLine(t *testing.T) {
+	style := &core.FormatStyle{
+		ColorWholeLine: true,
+		ColorLevel:     true, ColorKeys: true, ColorValues: true,
+		KeyColor: "\033[36m", ValueColor: "\033[37m", Reset: "\033[0m",
+	}
+	r := core.LogRecord{Level: core.Error, Message: "m", Fields: map[string]any{
+		"k": "v", "n": map[string]any{"a": 1},
+	}}
+	line := string(mustFormat(t, NewTextFormatter(style, nil), r))
+	if !strings.HasPrefix(line, core.Error.Color()) || !strings.HasSuffix(line, core.Error.Reset()) {
+		t.Fatalf("line %q", line)
+	}
+	// цвета уровня/ключей/значений не применяются поверх — единственная пара escape-кодов
+	if n := strings.Count(line, "\033["); n != 2 {
+		t.Errorf("%d escape codes in %q", n, line)
+	}
+
+	style.ColorWholeLine = false
+	line = string(mustFormat(t, NewTextFormatter(style, nil), r))
+	if strings.HasPrefix(line, core.Error.Color()) || !strings.Contains(line, core.Error.Color()+"ERROR") {
+		t.Errorf("without ColorWholeLine: %q", line)
+	}
+}
+
+func TestTextHumanizeNumbers(t *testing.T) {
+	cases := []struct {
+		v    any
+		want string
+	}{
+		{999, "999"},
+		{1000, "1k"},
+		{1_500_000, "1.5M"},
+		{999_950, "1M"}, // округление переходит на следующий суффикс
+		{int64(-1500), "-1.5k"},
+		{uint64(18_446_744_073_709_551_615), "18.4E"},
+		{1.5e6, "1500000"}, // только целые
+	}
+	f := NewTextFormatter(&core.FormatStyle{HumanizeNumbers: true}, nil)
+	for _, c := range cases {
+		line := string(mustFormat(t, f, core.LogRecord{Level: core.Info, Fields: map[string]any{"n": c.v}}))
+		if !strings.HasSuffix(line, " n="+c.want) {
+			t.Errorf("%v: %q, want n=%s", c.v, line, c.want)
+		}
+	}
+	if line := string(mustFormat(t, f, core.LogRecord{Level: core.Info, Fields: map[string]any{"n": []int{999, 1000}}})); !strings.HasSuffix(line, "n=[999, 1k]") {
+		t.Errorf("slice: %q", line)
+	}
+
+	// порог и суффиксы настраиваются
+	f = NewTextFormatter(&core.FormatStyle{HumanizeNumbers: true, HumanizeThreshold: 10_000, HumanizeSuffixes: []string{"K"}}, nil)
+	for v, want := range map[int]string{9999: "9999", 10_000: "10K", 2_000_000: "2000K"} {
+		if line := string(mustFormat(t, f, core.LogRecord{Level: core.Info, Fields: map[string]any{"n": v}})); !strings.HasSuffix(line, " n="+want) {
+			t.Errorf("custom %d: %q, want n=%s", v, line, want)
+		}
+	}
+
+	// JSON всегда точный
+	out := mustFormat(t, NewJsonFormatter(&core.FormatStyle{HumanizeNumbers: true}, nil), core.LogRecord{Level: core.Info, Fields: map[string]any{"n": 1_500_000}})
+	if !strings.Contains(string(out), `"n":1500000`) {
+		t.Errorf("json: %s", out)
+	}
+}
+
+func TestTextFieldSeparators(t *testing.T) {
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"a": 1, "b": "x y", "c": map[string]any{"d": 2, "e": 3},
+	}}
+	f := NewTextFormatter(nil, nil)
+	f.FieldSep = "\t"
+	line := string(mustFormat(t, f, r))
+	_, fields, ok := strings.Cut(line, " | ")
+	if !ok {
+		t.Fatalf("no fields: %q", line)
+	}
+	// вложенные значения не затрагиваются
+	if got := strings.Split(fields, "\t"); len(got) != 3 || got[0] != "a=1" || got[1] != `b="x y"` || got[2] != "c={d: 2, e: 3}" {
+		t.Errorf("tab-separated: %q", fields)
+	}
+
+	f.KeyValSep = ":"
+	if line := string(mustFormat(t, f, r)); !strings.HasSuffix(line, "a:1\tb:\"x y\"\tc:{d: 2, e: 3}") {
+		t.Errorf("custom key/value separator: %q", line)
+	}
+
+	// по умолчанию — пробел и "="
+	if line := string(mustFormat(t, NewTextFormatter(nil, nil), r)); !strings.HasSuffix(line, ` | a=1 b="x y" c={d: 2, e: 3}`) {
+		t.Errorf("default: %q", line)
+	}
+}
+
+func TestTextKeyPadWidth(t *testing.T) {
+	r := core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{
+		"id": 1, "user": "u", "ключ": 2, "very_long_key": 3, "nested": map[string]any{"a": 1},
+	}}
+	f := NewTextFormatter(nil, nil)
+	f.KeyPadWidth = 6
+	line := string(mustFormat(t, f, r))
+	_, fields, _ := strings.Cut(line, " | ")
+	// короткие ключи дополнены до 6 символов (кириллица — по символам),
+	// длинный не обрезан, вложенные ключи не выравниваются
+	want := `id    =1 nested={a: 1} user  ="u" very_long_key=3 ключ  =2`
+	if fields != want {
+		t.Errorf("fields %q, want %q", fields, want)
+	}
+
+	// значения встают в одну колонку в разных строках
+	col := func(key string, v any) int {
+		line := string(mustFormat(t, f, core.LogRecord{Level: core.Info, Message: "m", Fields: map[string]any{key: v}}))
+		return strings.Index(line, "=")
+	}
+	if a, b := col("id", 1), col("status", 2); a != b {
+		t.Errorf("value columns differ: %d vs %d", a, b)
+	}
+
+	// цвет ключа не входит в ширину: пробелы после reset
+	f = NewTextFormatter(&core.FormatStyle{ColorKeys: true, KeyColor: "\033[36m", Reset: "\033[0m"}, nil)
+	f.KeyPadWidth = 4
+	if line := string(mustFormat(t, f, core.LogRecord{Level: core.Info, Fields: map[string]any{"id": 1}})); !strings.HasSuffix(line, "\033[36mid\033[0m  =1") {
+		t.Errorf("colored: %q", line)
+	}
+}
@@ -0,0 +1,187 @@
+package formatter
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonotonicTimeFormatsLikeWallTime(t *testing.T) {
+	mono := time.Now() // с монотонной составляющей
+	wall := time.Unix(0, mono.UnixNano()).In(mono.Location())
+	if mono == wall {
+		t.Fatal("times compare equal with ==: no monotonic reading")
+	}
+	record := func(ts time.Time) core.LogRecord {
+		return core.LogRecord{Level: core.Info, Timestamp: ts, Message: "m", Fields: map[string]any{
+			"t":      ts,
+			"nested": map[string]any{"t": ts},
+			"struct": struct{ T time.Time }{ts},
+		}}
+	}
+
+	for _, prec := range []time.Duration{0, time.Millisecond} {
+		jf := NewJsonFormatter(nil, nil)
+		jf.TimePrecision = prec
+		tf := NewTextFormatter(nil, nil)
+		tf.TimePrecision = prec
+		for name, f := range map[string]core.FormatProcessor{"json": jf, "text": tf} {
+			a := string(mustFormat(t, f, record(mono)))
+			b := string(mustFormat(t, f, record(wall)))
+			if a != b {
+				t.Errorf("%s precision %v:\n%s\n%s", name, prec, a, b)
+			}
+			if strings.Contains(a, "m=+") {
+				t.Errorf("%s: monotonic reading in output: %s", name, a)
+			}
+		}
+	}
+}
+
+func TestTimePrecisionTruncates(t *testing.T) {
+	ts := time.Date(2025, 8, 14, 10, 0, 0, 123456789, time.UTC)
+	f := NewJsonFormatter(nil, nil)
+	f.TimePrecision = time.Millisecond
+	got := decodeJSON(t, mustFormat(t, f, core.LogRecord{Level: core.Info, Timestamp: ts, Fields: map[string]any{"t": ts}}))
+	for _, k := range []string{"ts", "t"} {
+		if got[k] != "2025-08-14T10:00:00.123Z" {
+			t.Errorf("%s = %v", k, got[k])
+		}
+	}
+}
+
+func TestTimePrecisionDigits(t *testing.T) {
+	// нулевые младшие разряды проверяют, что хвостовые нули не отбрасываются
+	ts := time.Date(2025, 8, 14, 10, 0, 0, 120000000, time.UTC)
+	cases := []struct {
+		prec time.Duration
+		want string
+	}{
+		{time.Second, "2025-08-14T10:00:00Z"},
+		{time.Millisecond, "2025-08-14T10:00:00.120Z"},
+		{time.Microsecond, "2025-08-14T10:00:00.120000Z"},
+		{time.Nanosecond, "2025-08-14T10:00:00.120000000Z"},
+		{0, "2025-08-14T10:00:00.12Z"}, // по умолчанию RFC3339Nano
+	}
+	for _, c := range cases {
+		f := NewJsonFormatter(nil, nil)
+		f.TimePrecision = c.prec
+		got := decodeJSON(t, mustFormat(t, f, core.LogRecord{Level: core.Info, Timestamp: ts, Fields: map[string]any{"t": ts}}))
+		for _, k := range []string{"ts", "t"} {
+			if got[k] != c.want {
+				t.Errorf("precision %v: %s = %v, want %s", c.prec, k, got[k], c.want)
+			}
+		}
+	}
+}
+
+func TestRelativeToStart(t *testing.T) {
+	jf := NewJsonFormatter(nil, nil)
+	jf.RelativeToStart = true
+	tf := NewTextFormatter(nil, nil)
+	tf.RelativeToStart = true
+	tf.StartTime = jf.StartTime
+	start := jf.StartTime
+
+	offsets := []time.Duration{0, 123 * time.Millisecond, 1500 * time.Millisecond, 61 * time.Second}
+	want := []string{"+0.000s", "+0.123s", "+1.500s", "+61.000s"}
+	prev := -1.0
+	for i, d := range offsets {
+		r := core.LogRecord{Level: core.Info, Timestamp: start.Add(d), Message: "m"}
+		ts, _ := decodeJSON(t, mustFormat(t, jf, r))["ts"].(string)
+		if ts != want[i] {
+			t.Errorf("json %v: ts = %q, want %q", d, ts, want[i])
+		}
+		secs, err := strconv.ParseFloat(strings.TrimSuffix(ts, "s"), 64)
+		if err != nil || secs <= prev {
+			t.Errorf("not increasing: %q after %v", ts, prev)
+		}
+		prev = secs
+		if line := string(mustFormat(t, tf, r)); !strings.HasPrefix(line, "["+want[i]+"]") {
+			t.Errorf("text %v: %q", d, line)
+		}
+	}
+
+	// настоящие записи подряд: смещения не убывают
+	prev = -1
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		ts, _ := decodeJSON(t, mustFormat(t, jf, core.LogRecord{Level: core.Info, Timestamp: time.Now()}))["ts"].(string)
+		secs, err := strconv.ParseFloat(strings.TrimSuffix(ts, "s"), 64)
+		if err != nil || secs <= prev {
+			t.Errorf("live record %d: %q after %v", i, ts, prev)
+		}
+		prev = secs
+	}
+
+	if ts := decodeJSON(t, mustFormat(t, jf, core.LogRecord{Level: core.Info, Timestamp: start.Add(-10 * time.Millisecond)}))["ts"]; ts != "-0.010s" {
+		t.Errorf("before start: %v", ts)
+	}
+}
+
+// TimeFunc перекрывает все встроенные опции времени, но только для ts.
+func TestTimeFuncOverridesTimestamp(t *testing.T) {
+	ts := time.Date(2024, 12, 30, 10, 0, 0, 0, time.UTC)
+	isoWeek := func(t time.Time) string {
+		y, w := t.ISOWeek()
+		return "W" + strconv.Itoa(y) + "-" + strconv.Itoa(w)
+	}
+	r := core.LogRecord{Level: core.Info, Timestamp: ts, Message: "m", Fields: map[string]any{"at": ts}}
+
+	jf := NewJsonFormatter(nil, nil)
+	jf.TimeFunc = isoWeek
+	jf.TimePrecision = time.Microsecond
+	jf.RelativeToStart = true
+	doc := decodeJSON(t, mustFormat(t, jf, r))
+	if doc["ts"] != "W2025-1" {
+		t.Errorf("json ts = %v", doc["ts"])
+	}
+	if doc["at"] == "W2025-1" {
+		t.Error("json: TimeFunc applied to a time.Time field")
+	}
+
+	tf := NewTextFormatter(nil, nil)
+	tf.TimeFunc = isoWeek
+	tf.TimestampLayout = time.RFC3339
+	tf.TimePrecision = time.Microsecond
+	tf.RelativeToStart = true
+	text := string(mustFormat(t, tf, r))
+	if !strings.HasPrefix(text, "[W2025-1] ") || strings.Count(text, "W2025-1") != 1 {
+		t.Errorf("text: %s", text)
+	}
+}
+
+func TestShortTimestamp(t *testing.T) {
+	ts := time.Date(2025, 8, 14, 10, 5, 7, 123456789, time.UTC)
+	r := core.LogRecord{Level: core.Info, Timestamp: ts, Message: "m", Fields: map[string]any{"at": ts}}
+	cases := []struct {
+		name  string
+		setup func(f *TextFormatter)
+		want  string
+	}{
+		{"default", func(*TextFormatter) {}, "[10:05:07.123] "},
+		{"precision", func(f *TextFormatter) { f.TimePrecision = time.Microsecond }, "[10:05:07.123456] "},
+		{"seconds", func(f *TextFormatter) { f.TimePrecision = time.Second }, "[10:05:07] "},
+		{"layout wins", func(f *TextFormatter) { f.TimestampLayout = time.Kitchen }, "[10:05AM] "},
+	}
+	for _, c := range cases {
+		f := NewTextFormatter(nil, nil)
+		f.ShortTimestamp = true
+		c.setup(f)
+		text := string(mustFormat(t, f, r))
+		if !strings.HasPrefix(text, c.want) {
+			t.Errorf("%s: %s", c.name, text)
+		}
+		// поля time.Time опция не затрагивает
+		if !strings.Contains(text, "2025-08-14") {
+			t.Errorf("%s: field lost its date: %s", c.name, text)
+		}
+	}
+
+	// без опции — полная дата
+	if text := string(mustFormat(t, NewTextFormatter(nil, nil), r)); !strings.HasPrefix(text, "[2025-08-14 10:05:07.123] ") {
+		t.Errorf("full: %s", text)
+	}
+}
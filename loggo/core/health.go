@@ -0,0 +1,119 @@
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHealthFullThreshold — сколько очередь может быть непрерывно полной,
+// прежде чем роут считается нездоровым.
+const DefaultHealthFullThreshold = 5 * time.Second
+
+// routeStats — счётчики роута для HealthReport.
+type routeStats struct {
+	enqueued  atomic.Uint64
+	processed atomic.Uint64
+	dropped   atomic.Uint64
+	errors    atomic.Uint64
+	abandoned atomic.Uint64 // брошены по DrainTimeout
+
+	fullSince atomic.Int64 // unix nano, 0 — очередь не упиралась в лимит
+	running   atomic.Int32 // запущенные и не вышедшие воркеры (с брошенными по DrainTimeout)
+}
+
+// markFull отмечает момент, с которого очередь заполнена (если ещё не отмечен).
+func (s *routeStats) markFull() {
+	s.fullSince.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// clearFull снимает отметку заполненной очереди, если воркер, выбрав запись
+// из q, освободил в ней место. Ждущие отправители дозаполняют буфер канала в
+// момент приёма, так что при затянувшемся переполнении len(q) остаётся равным
+// cap(q) и отметка сохраняется. С файлом переполнения роут свободен, лишь когда
+// тот вычитан.
+func (r *RouteProcessor) clearFull(q chan LogRecordRaw, spill *spillQueue) {
+	if r.stats.fullSince.Load() == 0 || len(q) >= cap(q) {
+		return
+	}
+	if spill != nil && spill.len() > 0 {
+		return
+	}
+	r.stats.fullSince.Store(0)
+}
+
+// RouteHealth — состояние одного роута.
+type RouteHealth struct {
+	Name        string
+	QueueLen    int
+	QueueCap    int
+	FullFor     time.Duration // сколько очередь непрерывно полна (0 — не полна)
+	WorkerAlive bool
+	Enqueued    uint64
+	Processed   uint64
+	Dropped     uint64
+	Errors      uint64
+	Abandoned   uint64 // записи, брошенные по DrainTimeout
+	Healthy     bool
+}
+
+// HealthReport — состояние всех роутов логгера.
+type HealthReport struct {
+	Healthy bool
+	Routes  []RouteHealth
+}
+
+// Health возвращает состояние роута: нездоров, если воркер завершился, хотя
+// роут не закрыт, или очередь полна дольше fullThreshold.
+func (r *RouteProcessor) Health(fullThreshold time.Duration) RouteHealth {
+	r.mu.RLock()
+	closed := r.closed
+	q := r.queue
+	r.mu.RUnlock()
+
+	h := RouteHealth{
+		Name:        r.Name,
+		QueueLen:    len(q),
+		QueueCap:    cap(q),
+		WorkerAlive: r.syncMode || r.stats.running.Load() > 0,
+		Enqueued:    r.stats.enqueued.Load(),
+		Processed:   r.stats.processed.Load(),
+		Dropped:     r.stats.dropped.Load(),
+		Errors:      r.stats.errors.Load(),
+		Abandoned:   r.stats.abandoned.Load(),
+	}
+	if since := r.stats.fullSince.Load(); since != 0 {
+		h.FullFor = time.Since(time.Unix(0, since))
+	}
+	h.Healthy = (h.WorkerAlive || closed) && h.FullFor <= fullThreshold
+	return h
+}
+
+// SetHealthThreshold задаёт порог заполненной очереди для Healthy/HealthReport
+// (по умолчанию DefaultHealthFullThreshold).
+func (l *Logger) SetHealthThreshold(d time.Duration) {
+	l.healthThreshold.Store(int64(d))
+}
+
+// HealthReport собирает состояние всех роутов.
+func (l *Logger) HealthReport() HealthReport {
+	threshold := time.Duration(l.healthThreshold.Load())
+	if threshold <= 0 {
+		threshold = DefaultHealthFullThreshold
+	}
+
+	rep := HealthReport{Healthy: true}
+	for _, r := range l.RoutesSnapshot() {
+		if r == nil {
+			continue
+		}
+		h := r.Health(threshold)
+		rep.Healthy = rep.Healthy && h.Healthy
+		rep.Routes = append(rep.Routes, h)
+	}
+	return rep
+}
+
+// Healthy сообщает, что все воркеры живы и ни одна очередь не забита дольше порога.
+func (l *Logger) Healthy() bool {
+	return l.HealthReport().Healthy
+}
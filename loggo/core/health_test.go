@@ -0,0 +1,65 @@
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealthUnhealthyWhileQueueStaysFull(t *testing.T) {
+	w := &memWriter{block: make(chan struct{})}
+	r := NewRouteProcessor(lineFormatter{}, w, Trace)
+	r.queue = make(chan LogRecordRaw, 2)
+	l := NewLogger(r)
+	l.SetHealthThreshold(100 * time.Millisecond)
+
+	if !l.Healthy() {
+		t.Fatal("idle logger unhealthy")
+	}
+
+	// медленный writer: запись раз в 5 мс — воркер выбирает записи, но
+	// производители тут же дозаполняют очередь
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	ticker := make(chan struct{})
+	go func() {
+		defer close(ticker)
+		for !stop.Load() {
+			time.Sleep(5 * time.Millisecond)
+			select {
+			case w.block <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				l.Log(info("x"))
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for l.Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("route never became unhealthy while its queue stayed full")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	rep := l.HealthReport()
+	if h := rep.Routes[0]; h.FullFor <= 100*time.Millisecond || !h.WorkerAlive || h.Processed == 0 {
+		t.Fatalf("route health %+v", h)
+	}
+
+	// производители остановились — очередь вычитывается, роут снова здоров
+	stop.Store(true)
+	<-ticker
+	close(w.block)
+	wg.Wait()
+	waitFor(t, l.Healthy)
+	l.Close()
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// lineFormatter выводит "msg k=v ..." с ключами по алфавиту.
+type lineFormatter struct{}
+
+func (lineFormatter) Format(r LogRecord) ([]byte, error) {
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(r.Message)
+	for _, k := range keys {
+		b.WriteString(" " + k + "=")
+		b.WriteString(r.Fields[k].(string))
+	}
+	return []byte(b.String()), nil
+}
+
+// memWriter запоминает записи в памяти; block, если задан, держит каждую запись.
+type memWriter struct {
+	mu      sync.Mutex
+	lines   []string
+	records []LogRecord
+	flushes int
+	block   chan struct{}
+}
+
+func (w *memWriter) Write(p []byte) error {
+	if w.block != nil {
+		<-w.block
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, string(p))
+	return nil
+}
+
+func (w *memWriter) WriteRecord(r LogRecord, formatted []byte) error {
+	w.mu.Lock()
+	w.records = append(w.records, r)
+	w.mu.Unlock()
+	return w.Write(formatted)
+}
+
+func (w *memWriter) Flush() error {
+	w.mu.Lock()
+	w.flushes++
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *memWriter) Lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.lines...)
+}
+
+func (w *memWriter) Records() []LogRecord {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]LogRecord(nil), w.records...)
+}
+
+// info — запись уровня Info с сообщением msg.
+func info(msg string) LogRecordRaw {
+	return LogRecordRaw{Level: Info, Message: []byte(msg)}
+}
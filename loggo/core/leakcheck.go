@@ -0,0 +1,27 @@
+package core
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// CheckGoroutines запоминает текущее число горутин и возвращает функцию проверки:
+// она ждёт до timeout, пока число горутин не вернётся к исходному, иначе — ошибка.
+// Предназначена для тестов: defer-проверка, что Close/Reset не оставили воркеров.
+func CheckGoroutines(timeout time.Duration) func() error {
+	before := runtime.NumGoroutine()
+	return func() error {
+		deadline := time.Now().Add(timeout)
+		for {
+			now := runtime.NumGoroutine()
+			if now <= before {
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("goroutine leak: before=%d after=%d", before, now)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
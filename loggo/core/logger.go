@@ -2,7 +2,16 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Logger управляет маршрутизацией логов и жизненным циклом воркеров.
@@ -13,8 +22,48 @@ type Logger struct {
 	mu     sync.RWMutex
 
 	routes []*RouteProcessor
+
+	seqEnabled atomic.Bool
+	seq        atomic.Uint64
+	clock      atomic.Pointer[func() time.Time]
+
+	// start — момент создания логгера, база для поля uptime (EnableUptime)
+	start         time.Time
+	uptimeEnabled atomic.Bool
+
+	callerMode atomic.Int32 // callerOff/callerLine/callerFunc, см. EnableCaller
+
+	healthThreshold atomic.Int64 // time.Duration
+	worst           atomic.Int64 // LogLevel, см. WorstLevel
+
+	// baseFields — сырые поля SetBaseFields (key\0value\0...), nil — нет
+	baseFields atomic.Pointer[[]byte]
+	// baseTags — метки SetBaseTags, nil — нет
+	baseTags atomic.Pointer[[]string]
+
+	// ExitOnException — после записи уровня Exception и выше логгер закрывается
+	// (очереди дописываются и сбрасываются) и процесс завершается os.Exit(1),
+	// как log.Fatal. Срабатывает и тогда, когда запись отсеяна порогами всех
+	// роутов: это управление процессом, а не вывод — как log.Fatal при
+	// выключенном выводе. Задавать до начала логирования.
+	ExitOnException bool
+	// PanicOnException — то же, но вместо выхода panic(сообщение), как log.Panic.
+	// Перед паникой очереди дописываются и writer'ы сбрасываются (Flush), так
+	// что после recover логгер продолжает работать. Как и ExitOnException,
+	// не зависит от порогов роутов. ExitOnException приоритетнее.
+	PanicOnException bool
 }
 
+// Режимы захвата места вызова (EnableCaller).
+const (
+	callerOff int32 = iota
+	callerLine
+	callerFunc
+)
+
+// exitFunc завершает процесс; подменяется в тестах.
+var exitFunc = os.Exit
+
 // NewLogger создаёт асинхронный логгер с переданными маршрутизаторами.
 func NewLogger(routes ...*RouteProcessor) *Logger {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -23,6 +72,7 @@ func NewLogger(routes ...*RouteProcessor) *Logger {
 		ctx:    ctx,
 		cancel: cancel,
 		routes: routes,
+		start:  time.Now(),
 	}
 
 	for _, r := range routes {
@@ -41,12 +91,362 @@ func (l *Logger) Close() {
 	l.wg.Wait()
 }
 
+// Reset останавливает воркеры (с дренажом очередей, как Close) и запускает их
+// заново с теми же роутами. Удобно в тестах и бенчмарках, чтобы переиспользовать
+// логгер вместо пересоздания. Счётчик seq и WorstLevel при этом сбрасываются.
+func (l *Logger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, r := range l.routes {
+		r.Close()
+	}
+	l.cancel()
+	l.wg.Wait()
+
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	l.seq.Store(0)
+	l.worst.Store(int64(Trace))
+	for _, r := range l.routes {
+		r.reopen()
+		r.Start(l.ctx, &l.wg)
+	}
+}
+
+// Flush дожидается записи всего, что было в очередях на момент вызова, и
+// сбрасывает writer'ы (RouteProcessor.Flush). Роуты не закрываются: записи из
+// других горутин продолжают приниматься и не теряются.
+func (l *Logger) Flush() {
+	for _, r := range l.RoutesSnapshot() {
+		if r != nil {
+			r.Flush()
+		}
+	}
+}
+
+// EnableSeq включает нумерацию записей: каждая принятая запись получает поле seq,
+// общее для всех роутов (1, 2, 3, ... без пропусков).
+func (l *Logger) EnableSeq(enabled bool) {
+	l.seqEnabled.Store(enabled)
+}
+
+// EnableUptime добавляет в каждую запись поле uptime — время с создания
+// логгера на момент вызова Log ("1m2.5s", как time.Duration.String()). Поле
+// uptime, переданное в самой записи или через SetBaseFields, приоритетнее.
+func (l *Logger) EnableUptime(enabled bool) {
+	l.uptimeEnabled.Store(enabled)
+}
+
+// StartTime возвращает момент создания логгера — базу для uptime.
+func (l *Logger) StartTime() time.Time {
+	return l.start
+}
+
+// withUptime ставит поле uptime перед остальными полями, чтобы они его перекрывали.
+func (l *Logger) withUptime(fields []byte) []byte {
+	if !l.uptimeEnabled.Load() {
+		return fields
+	}
+	// time.Since — по монотонным часам, не зависит от SetClock и перевода времени
+	up := appendRawField(nil, "uptime", time.Since(l.start).String())
+	if len(fields) == 0 || fields[len(fields)-1] != 0 {
+		return up
+	}
+	return append(up, fields...)
+}
+
+// EnableCaller включает захват места вызова для записей без Caller, переданных
+// через Log, TryLog, LogAt и LogContext, и для паник, пойманных Recover (там
+// это место паники): "file.go:42". withFunc добавляет имя функции через
+// runtime.FuncForPC — "pkg.Func (file.go:42)"; это заметно дороже, поэтому
+// отдельным флагом. Для записей через FFI место вызова передаёт хост
+// (Logger_LogCaller): Go-стек там указывает на экспорт, а не на код хоста.
+func (l *Logger) EnableCaller(enabled, withFunc bool) {
+	switch {
+	case !enabled:
+		l.callerMode.Store(callerOff)
+	case withFunc:
+		l.callerMode.Store(callerFunc)
+	default:
+		l.callerMode.Store(callerLine)
+	}
+}
+
+// caller возвращает место вызова на skip кадров выше вызывающего caller
+// в формате EnableCaller; пусто — захват выключен.
+func (l *Logger) caller(skip int) string {
+	mode := l.callerMode.Load()
+	if mode == callerOff {
+		return ""
+	}
+	pc, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return ""
+	}
+	var fn string
+	if mode == callerFunc {
+		if f := runtime.FuncForPC(pc); f != nil {
+			fn = f.Name()
+		}
+	}
+	return formatCaller(fn, file, line)
+}
+
+// formatCaller собирает "file.go:42" или, если известна функция,
+// "pkg.Func (file.go:42)".
+func formatCaller(fn, file string, line int) string {
+	loc := filepath.Base(file) + ":" + strconv.Itoa(line)
+	if fn == "" {
+		return loc
+	}
+	// "github.com/x/pkg.Func" -> "pkg.Func"
+	return fn[strings.LastIndexByte(fn, '/')+1:] + " (" + loc + ")"
+}
+
+// SetClock задаёт источник времени записей (по умолчанию time.Now).
+// Полезно для воспроизводимых тестов; nil возвращает time.Now.
+func (l *Logger) SetClock(clock func() time.Time) {
+	if clock == nil {
+		l.clock.Store(nil)
+		return
+	}
+	l.clock.Store(&clock)
+}
+
+// SetBaseFields задаёт поля, которые добавляются ко всем последующим записям,
+// например deploy_version после горячей перезагрузки. Заменяет прежний набор
+// целиком; nil или пустая map — убрать. Значения приводятся к строке через
+// fmt.Sprint. Поля, переданные в самой записи, приоритетнее базовых.
+// Безопасно вызывать во время логирования: набор подменяется атомарно.
+func (l *Logger) SetBaseFields(fields map[string]any) {
+	if len(fields) == 0 {
+		l.baseFields.Store(nil)
+		return
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var raw []byte
+	for _, k := range keys {
+		raw = appendRawField(raw, k, fmt.Sprint(fields[k]))
+	}
+	l.baseFields.Store(&raw)
+}
+
+// withBaseFields ставит базовые поля перед полями записи: при разборе
+// (rawToRecord) повторный ключ перекрывает ранний, так что поля вызова побеждают.
+func (l *Logger) withBaseFields(fields []byte) []byte {
+	base := l.baseFields.Load()
+	if base == nil {
+		return fields
+	}
+	if len(fields) == 0 || fields[len(fields)-1] != 0 {
+		// нет пар key\0value\0 (в том числе заглушка без полей)
+		return *base
+	}
+	merged := make([]byte, 0, len(*base)+len(fields))
+	merged = append(merged, *base...)
+	return append(merged, fields...)
+}
+
+// SetBaseTags задаёт метки, которые добавляются ко всем последующим записям
+// перед их собственными (LogRecordRaw.Tags). Заменяет прежний набор целиком;
+// без аргументов — убрать. Безопасно вызывать во время логирования.
+func (l *Logger) SetBaseTags(tags ...string) {
+	if len(tags) == 0 {
+		l.baseTags.Store(nil)
+		return
+	}
+	tags = mergeTags(nil, tags)
+	l.baseTags.Store(&tags)
+}
+
+// withBaseTags объединяет базовые метки с метками записи.
+func (l *Logger) withBaseTags(tags []string) []string {
+	base := l.baseTags.Load()
+	if base == nil {
+		if !validTags(tags) {
+			return mergeTags(nil, tags)
+		}
+		return tags
+	}
+	if len(tags) == 0 {
+		return *base
+	}
+	return mergeTags(*base, tags)
+}
+
+// mergeTags объединяет метки без повторов, сохраняя порядок первого появления.
+// Пустые метки и метки с NUL (разделитель меток в spill) отбрасываются.
+// Результат — новый срез.
+func mergeTags(base, tags []string) []string {
+	merged := make([]string, 0, len(base)+len(tags))
+	seen := make(map[string]struct{}, len(base)+len(tags))
+	for _, t := range append(base[:len(base):len(base)], tags...) {
+		if _, dup := seen[t]; dup || t == "" || strings.IndexByte(t, 0) >= 0 {
+			continue
+		}
+		seen[t] = struct{}{}
+		merged = append(merged, t)
+	}
+	return merged
+}
+
+// validTags сообщает, что среди меток нет тех, что отбросил бы mergeTags,
+// кроме повторов.
+func validTags(tags []string) bool {
+	for _, t := range tags {
+		if t == "" || strings.IndexByte(t, 0) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *Logger) now() time.Time {
+	if c := l.clock.Load(); c != nil {
+		return (*c)()
+	}
+	return time.Now()
+}
+
+// Log раздаёт запись во все роуты, чей порог уровня её пропускает.
+func (l *Logger) Log(record LogRecordRaw) {
+	l.emit(record, 1, false)
+}
+
+// emit — общая часть публичных методов записи: log плюс реакция на Exception
+// (ExitOnException/PanicOnException). skip — как у log.
+func (l *Logger) emit(record LogRecordRaw, skip int, try bool) bool {
+	if record.Level >= Exception {
+		defer l.onException(record.Message)
+	}
+	return l.log(record, skip+1, try)
+}
+
+// log раздаёт запись по роутам без реакции на Exception — так пишутся записи,
+// которые сами обрабатывают аварийную ситуацию, как logPanic. skip — сколько
+// кадров между log и кодом пользователя (для EnableCaller): 1, если log вызван
+// прямо из публичного метода. try — не ждать места в очередях (TryLog);
+// результат — приняла ли запись хоть один роут.
+func (l *Logger) log(record LogRecordRaw, skip int, try bool) bool {
+	l.observeLevel(record.Level)
+	if !l.AnyRouteShouldLog(record.Level) {
+		return false
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = l.now()
+	}
+	if record.Caller == "" {
+		record.Caller = l.caller(skip + 1)
+	}
+	record.Fields = l.withUptime(l.withBaseFields(record.Fields))
+	record.Tags = l.withBaseTags(record.Tags)
+	if l.seqEnabled.Load() {
+		record.Seq = l.seq.Add(1)
+	}
+	accepted := false
+	for _, r := range l.RoutesSnapshot() {
+		if r == nil || !r.ShouldLog(record.Level) {
+			continue
+		}
+		if !try {
+			r.Enqueue(record)
+			accepted = true
+		} else if r.TryEnqueue(record) {
+			accepted = true
+		}
+	}
+	return accepted
+}
+
+// LogAt пишет запись с заданным временем, например при импорте исторических
+// событий. Нулевое t — текущее время, как в Log. Отсеянная порогами запись
+// учитывается так же, как в Log (WorstLevel, ExitOnException).
+func (l *Logger) LogAt(t time.Time, level LogLevel, msg string, fields map[string]string) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var raw []byte
+	for _, k := range keys {
+		raw = appendRawField(raw, k, fields[k])
+	}
+	l.emit(LogRecordRaw{Level: level, Timestamp: t, Message: []byte(msg), Fields: raw}, 1, false)
+}
+
+// TryLog — как Log, но никогда не блокируется: в роуты с полной очередью запись
+// не попадает. Возвращает false, если её не принял ни один подходящий роут.
+// При включённом seq такая отвергнутая запись оставляет пропуск в нумерации.
+func (l *Logger) TryLog(record LogRecordRaw) bool {
+	return l.emit(record, 1, true)
+}
+
+// WorstLevel возвращает самый высокий уровень среди записей, переданных в
+// Log/TryLog/LogAt/LogContext (в том числе отсеянных порогами роутов); Trace —
+// если записей не было. Для кода выхода CLI:
+// os.Exit(core.ExitCode(log.WorstLevel())).
+func (l *Logger) WorstLevel() LogLevel {
+	return LogLevel(l.worst.Load())
+}
+
+// ExitCode сопоставляет уровень коду выхода процесса: 0 — ниже Error,
+// 1 — Error, 2 — Exception и выше.
+func ExitCode(level LogLevel) int {
+	switch {
+	case level >= Exception:
+		return 2
+	case level >= Error:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (l *Logger) observeLevel(level LogLevel) {
+	for {
+		cur := l.worst.Load()
+		if int64(level) <= cur || l.worst.CompareAndSwap(cur, int64(level)) {
+			return
+		}
+	}
+}
+
+// onException реализует ExitOnException/PanicOnException: сначала все роуты
+// дописывают очереди и сбрасывают writer'ы, чтобы последняя запись уцелела.
+func (l *Logger) onException(msg []byte) {
+	switch {
+	case l.ExitOnException:
+		l.Close()
+		exitFunc(1)
+	case l.PanicOnException:
+		l.Flush()
+		panic(string(msg))
+	}
+}
+
 func (l *Logger) RoutesSnapshot() []*RouteProcessor {
 	l.mu.RLock()
 	routes := append([]*RouteProcessor(nil), l.routes...)
 	l.mu.RUnlock()
 	return routes
 }
+
+// Enabled сообщает, попадёт ли запись уровня level хотя бы в один роут.
+// Позволяет не собирать дорогие поля впустую:
+//
+//	if log.Enabled(core.Debug) {
+//		log.Log(core.LogRecordRaw{Level: core.Debug, Fields: buildFields()})
+//	}
+func (l *Logger) Enabled(level LogLevel) bool {
+	return l.AnyRouteShouldLog(level)
+}
+
 func (l *Logger) AnyRouteShouldLog(level LogLevel) bool {
 	for _, r := range l.routes {
 		if r != nil && r.ShouldLog(level) {
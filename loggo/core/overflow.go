@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy определяет поведение Enqueue при заполненной очереди роута.
+type OverflowPolicy int
+
+const (
+	OverflowBlock OverflowPolicy = iota // ждать освобождения места (по умолчанию)
+	OverflowDrop                        // отбросить запись
+)
+
+const defaultDiagnosticsInterval = 10 * time.Second
+
+// dropStats считает отброшенные записи и не чаще раза в интервал сообщает о них.
+// Окно отчёта открывает первая потеря после предыдущего отчёта.
+type dropStats struct {
+	dropped     atomic.Uint64
+	windowStart atomic.Int64 // unix nano первой потери окна, 0 — окно не открыто
+}
+
+// record учитывает отброшенную запись и, если с начала окна прошёл interval,
+// закрывает окно и возвращает количество потерь в нём и его длительность.
+func (s *dropStats) record(interval time.Duration) (n uint64, window time.Duration, report bool) {
+	s.dropped.Add(1)
+
+	now := time.Now().UnixNano()
+	start := s.windowStart.Load()
+	if start == 0 {
+		// первая потеря открывает окно; отчёт — когда оно истечёт или при Close
+		s.windowStart.CompareAndSwap(0, now)
+		return 0, 0, false
+	}
+	if now-start < int64(interval) || !s.windowStart.CompareAndSwap(start, 0) {
+		return 0, 0, false
+	}
+	return s.dropped.Swap(0), time.Duration(now - start), true
+}
+
+// flush закрывает открытое окно досрочно: потери, о которых ещё не сообщалось.
+func (s *dropStats) flush() (n uint64, window time.Duration, report bool) {
+	start := s.windowStart.Swap(0)
+	if start == 0 {
+		return 0, 0, false
+	}
+	n = s.dropped.Swap(0)
+	return n, time.Since(time.Unix(0, start)), n > 0
+}
+
+// recordDrop учитывает запись, отброшенную из-за полной очереди. Результат —
+// для reportDrop: его вызывают уже без r.mu.
+func (r *RouteProcessor) recordDrop() (n uint64, window time.Duration, report bool) {
+	r.stats.dropped.Add(1)
+	r.stats.markFull()
+	interval := r.DiagnosticsInterval
+	if interval <= 0 {
+		interval = defaultDiagnosticsInterval
+	}
+	return r.drops.record(interval)
+}
+
+// reportDrop пишет диагностику напрямую в Diagnostics, минуя очередь роута,
+// поэтому переполненная очередь не может заблокировать самодиагностику.
+func (r *RouteProcessor) reportDrop(n uint64, window time.Duration, report bool) {
+	if !report || n == 0 || r.Diagnostics == nil {
+		return
+	}
+
+	name := r.Name
+	if name == "" {
+		name = fmt.Sprintf("%p", r)
+	}
+	msg := fmt.Sprintf("loggo: queue %s dropped %d records in last %s", name, n, window.Round(time.Millisecond))
+	_ = r.Diagnostics.Write([]byte(msg))
+}
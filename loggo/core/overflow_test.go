@@ -0,0 +1,81 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// dropWindow разбирает "loggo: queue <name> dropped N records in last T".
+func dropWindow(t *testing.T, line, name string, n string) time.Duration {
+	t.Helper()
+	prefix := "loggo: queue " + name + " dropped " + n + " records in last "
+	if !strings.HasPrefix(line, prefix) {
+		t.Fatalf("diagnostic %q, want prefix %q", line, prefix)
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(line, prefix))
+	if err != nil {
+		t.Fatalf("diagnostic %q: %v", line, err)
+	}
+	return d
+}
+
+func TestDropDiagnosticsThrottled(t *testing.T) {
+	w := &memWriter{block: make(chan struct{})}
+	diag := &memWriter{}
+	r := NewRouteProcessor(lineFormatter{}, w, Trace)
+	r.queue = make(chan LogRecordRaw, 1)
+	r.Overflow = OverflowDrop
+	r.Name = "main"
+	r.Diagnostics = diag
+	r.DiagnosticsInterval = 100 * time.Millisecond
+	l := NewLogger(r)
+
+	l.Log(info("0"))
+	waitFor(t, func() bool { return len(r.queue) == 0 }) // воркер держит запись в Write
+	l.Log(info("1"))                                     // очередь полна
+	for i := 0; i < 10; i++ {
+		l.Log(info("dropped"))
+	}
+	if lines := diag.Lines(); len(lines) != 0 {
+		t.Fatalf("report before the window elapsed: %q", lines)
+	}
+
+	time.Sleep(120 * time.Millisecond)
+	l.Log(info("dropped")) // окно истекло — один отчёт за всё окно
+	lines := diag.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("diagnostics %q, want one report", lines)
+	}
+	if d := dropWindow(t, lines[0], "main", "11"); d < 100*time.Millisecond {
+		t.Fatalf("window %s, want it to start at the first drop", d)
+	}
+
+	l.Log(info("dropped")) // открывает новое окно; о нём сообщит Close
+	close(w.block)
+	l.Close()
+	lines = diag.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("diagnostics %q, want a final report on Close", lines)
+	}
+	dropWindow(t, lines[1], "main", "1")
+	if got := r.Health(time.Hour).Dropped; got != 12 {
+		t.Fatalf("Dropped = %d, want 12", got)
+	}
+	if got := len(w.Lines()); got != 2 {
+		t.Fatalf("%d records written, want 2", got)
+	}
+}
+
+func TestDropDiagnosticsNothingDroppedNoReport(t *testing.T) {
+	diag := &memWriter{}
+	r := NewRouteProcessor(lineFormatter{}, &memWriter{}, Trace)
+	r.Overflow = OverflowDrop
+	r.Diagnostics = diag
+	l := NewLogger(r)
+	l.Log(info("a"))
+	l.Close()
+	if lines := diag.Lines(); len(lines) != 0 {
+		t.Fatalf("diagnostics %q, want none", lines)
+	}
+}
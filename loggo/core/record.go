@@ -1,6 +1,11 @@
 package core
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
 
 type LogLevel int
 
@@ -28,6 +33,9 @@ func (lvl LogLevel) Color() string {
 	case Exception:
 		return "\033[1;31m" // ярко-красный
 	default:
+		if info, ok := lookupLevel(lvl); ok && info.color != "" {
+			return info.color
+		}
 		return "\033[0m"
 	}
 }
@@ -51,19 +59,89 @@ func (l LogLevel) String() string {
 	case Exception:
 		return "EXCEPTION"
 	default:
+		if info, ok := lookupLevel(l); ok {
+			return info.name
+		}
 		return "UNKNOWN"
 	}
 }
 
+// ParseLevel — обратное к String: имя уровня (без учёта регистра, в том числе
+// зарегистрированное через RegisterLevel) в LogLevel.
+func ParseLevel(name string) (LogLevel, error) {
+	for _, l := range []LogLevel{Trace, Debug, Info, Warning, Error, Exception} {
+		if strings.EqualFold(name, l.String()) {
+			return l, nil
+		}
+	}
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	for l, info := range customLevels {
+		if strings.EqualFold(name, info.name) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+type levelInfo struct {
+	name  string
+	color string
+}
+
+var (
+	levelsMu     sync.RWMutex
+	customLevels = map[LogLevel]levelInfo{}
+	maxLevelName = len("EXCEPTION")
+)
+
+// RegisterLevel добавляет пользовательский уровень (например, NOTICE = 25) с
+// именем и ANSI-цветом. Встроенные уровни не переопределяются.
+func RegisterLevel(level LogLevel, name, color string) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	customLevels[level] = levelInfo{name: name, color: color}
+	if len(name) > maxLevelName {
+		maxLevelName = len(name)
+	}
+}
+
+// MaxLevelNameLen — длина самого длинного имени уровня, включая пользовательские.
+func MaxLevelNameLen() int {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return maxLevelName
+}
+
+func lookupLevel(level LogLevel) (levelInfo, bool) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	info, ok := customLevels[level]
+	return info, ok
+}
+
 type LogRecord struct {
 	Level     LogLevel
 	Timestamp time.Time
 	Message   string
 	Fields    map[string]interface{}
+	Seq       uint64 // 0 — нумерация выключена
+	Caller    string // место вызова ("file.go:42"); пусто — не выводится
+	// Tags — метки записи ("billing", "hot-path") отдельно от полей: для
+	// фильтрации ниже по потоку. nil — не выводятся.
+	Tags []string
 }
 
 type LogRecordRaw struct {
-	Level   LogLevel
-	Message []byte
-	Fields  []byte
+	Level     LogLevel
+	Timestamp time.Time // нулевое — проставит воркер роута при обработке
+	Message   []byte
+	Fields    []byte
+	Seq       uint64
+	Caller    string
+	Tags      []string // пустые метки и метки с NUL Logger отбрасывает
+
+	// barrier — служебная метка RouteProcessor.Flush, а не запись: воркер
+	// закрывает канал, дойдя до неё в очереди.
+	barrier chan struct{}
 }
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Recover перехватывает панику и пишет её на уровне Exception с полями panic и
+// stack, после чего сбрасывает очереди (Flush). Вызывать только через defer:
+//
+//	defer log.Recover()
+//
+// Паника дальше не распространяется; см. RecoverAndRepanic. ExitOnException и
+// PanicOnException на эту запись не срабатывают: паника уже обработана.
+func (l *Logger) Recover() {
+	if v := recover(); v != nil {
+		l.logPanic(v)
+	}
+}
+
+// RecoverAndRepanic — как Recover, но после записи паника продолжается с тем
+// же значением.
+func (l *Logger) RecoverAndRepanic() {
+	if v := recover(); v != nil {
+		l.logPanic(v)
+		panic(v)
+	}
+}
+
+func (l *Logger) logPanic(v any) {
+	var fields []byte
+	fields = appendRawField(fields, "panic", fmt.Sprint(v))
+	fields = appendRawField(fields, "stack", string(debug.Stack()))
+	l.log(LogRecordRaw{
+		Level:   Exception,
+		Message: []byte("panic recovered"),
+		Fields:  fields,
+		Caller:  l.panicCaller(),
+	}, 2, false)
+	l.Flush()
+}
+
+// panicCaller — место паники для EnableCaller: первый кадр вне пакета runtime
+// после runtime.gopanic (для паник рантайма вроде nil-разыменования выше него
+// ещё sigpanic и т.п.). Вызывать из logPanic.
+func (l *Logger) panicCaller() string {
+	mode := l.callerMode.Load()
+	if mode == callerOff {
+		return ""
+	}
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+	inPanic := false
+	for {
+		f, more := frames.Next()
+		switch {
+		case f.Function == "runtime.gopanic":
+			inPanic = true
+		case inPanic && !strings.HasPrefix(f.Function, "runtime."):
+			var fn string
+			if mode == callerFunc {
+				fn = f.Function
+			}
+			return formatCaller(fn, f.File, f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRecoverLogsPanicWithStack(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	defer l.Close()
+
+	func() {
+		defer l.Recover()
+		panic("boom")
+	}()
+
+	// Recover уже сбросил очереди — запись видна без Close
+	recs := w.Records()
+	if len(recs) != 1 {
+		t.Fatalf("got %d records, want 1", len(recs))
+	}
+	r := recs[0]
+	if r.Level != Exception || r.Message != "panic recovered" {
+		t.Fatalf("record %v %q", r.Level, r.Message)
+	}
+	if r.Fields["panic"] != "boom" {
+		t.Fatalf("panic field %q", r.Fields["panic"])
+	}
+	if stack, _ := r.Fields["stack"].(string); !strings.Contains(stack, "TestRecoverLogsPanicWithStack") {
+		t.Fatalf("stack field lacks the panicking frame:\n%s", stack)
+	}
+}
+
+func TestRecoverAndRepanic(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	defer l.Close()
+
+	defer func() {
+		if v := recover(); v != "again" {
+			t.Fatalf("recovered %v, want the original panic", v)
+		}
+		if len(w.Lines()) != 1 {
+			t.Fatalf("got %d lines, want 1", len(w.Lines()))
+		}
+	}()
+	defer l.RecoverAndRepanic()
+	panic("again")
+}
+
+func TestRecoverDoesNotTriggerPanicOnException(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	l.PanicOnException = true
+	defer l.Close()
+
+	func() {
+		defer func() {
+			if v := recover(); v != nil {
+				t.Fatalf("Recover re-panicked: %v", v)
+			}
+		}()
+		func() {
+			defer l.Recover()
+			panic("boom")
+		}()
+	}()
+	if len(w.Lines()) != 1 {
+		t.Fatalf("got %d lines, want 1", len(w.Lines()))
+	}
+}
+
+func TestRecoverKeepsConcurrentRecords(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+
+	const n, panics = 20000, 200
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				l.Log(info(fmt.Sprint(i)))
+			}
+		}()
+	}
+	for i := 0; i < panics; i++ {
+		func() {
+			defer l.Recover()
+			panic(i)
+		}()
+	}
+	wg.Wait()
+	l.Close()
+
+	if got, want := len(w.Lines()), 4*n+panics; got != want {
+		t.Fatalf("got %d lines, want %d", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResetDoesNotLeakGoroutines(t *testing.T) {
+	check := CheckGoroutines(time.Second)
+
+	w := &memWriter{}
+	l := NewLogger(
+		NewRouteProcessor(lineFormatter{}, w, Info),
+		NewRouteProcessor(lineFormatter{}, &memWriter{}, Error),
+	)
+	l.EnableSeq(true)
+	for i := 0; i < 5; i++ {
+		l.Log(info("a"))
+		l.Reset()
+	}
+	// после Reset логгер работает, записи до него дописаны, seq начат заново
+	l.Log(info("b"))
+	l.Close()
+
+	if got := len(w.Lines()); got != 6 {
+		t.Fatalf("%d lines, want 6", got)
+	}
+	if recs := w.Records(); recs[len(recs)-1].Seq != 1 {
+		t.Fatalf("seq after Reset = %d, want 1", recs[len(recs)-1].Seq)
+	}
+	if err := check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckGoroutinesReportsLeak(t *testing.T) {
+	check := CheckGoroutines(50 * time.Millisecond)
+	stop := make(chan struct{})
+	go func() { <-stop }()
+	if err := check(); err == nil {
+		t.Fatal("leaked goroutine not reported")
+	}
+	close(stop)
+}
@@ -1,20 +1,82 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// DefaultDrainProgressInterval — период OnDrainProgress по умолчанию.
+const DefaultDrainProgressInterval = time.Second
+
 // RouteProcessor связывает форматтер и writer, обрабатывает лог-события асинхронно.
 type RouteProcessor struct {
 	Formatter      FormatProcessor
 	Writer         WriteProcessor
 	LevelThreshold LogLevel
 
+	// Name используется в диагностике, например "queue <Name> dropped ...".
+	Name string
+	// Overflow задаёт поведение при заполненной очереди.
+	Overflow OverflowPolicy
+	// Diagnostics получает служебные сообщения о потерях (nil — молча).
+	Diagnostics WriteProcessor
+	// DiagnosticsInterval — не чаще одного сообщения о потерях за интервал.
+	DiagnosticsInterval time.Duration
+	// OnError вызывается при ошибке форматирования или записи. formatted —
+	// содержимое потерянной записи (nil, если упал форматтер), его можно
+	// повторить или сохранить в другом месте. Вызывается из воркера роута.
+	OnError func(err error, formatted []byte)
+	// FlushMinLevel — после записи такого уровня и выше у writer'а вызываются
+	// Flush и Sync (если есть), чтобы запись пережила падение процесса.
+	// Более низкие уровни остаются в буфере. Trace (0) — выключено.
+	FlushMinLevel LogLevel
+	// SpillDir включает сброс переполнения на диск (при OverflowBlock): вместо
+	// ожидания места в очереди записи пишутся во временный файл в этом каталоге
+	// и воспроизводятся по порядку, когда writer догонит. Задавать до Start.
+	SpillDir string
+	// SpillMaxBytes ограничивает объём невоспроизведённых записей в файле
+	// переполнения (0 — DefaultSpillMaxBytes); когда он заполнен, Enqueue
+	// блокируется до освобождения места в файле.
+	SpillMaxBytes int64
+	// FormatFallback — если форматтер вернул ошибку или запаниковал, вместо
+	// потери записи пишется минимальная строка {"level","ts","msg","format_error"}.
+	// OnError при этом всё равно вызывается.
+	FormatFallback bool
+	// OnDrainProgress вызывается при дренаже очереди на Close (и Reset
+	// логгера) с числом ещё не записанных записей: в начале, не чаще раза в
+	// DrainProgressInterval и в конце (0). Помогает отличить медленный дренаж
+	// от зависания. Вызывается из воркера роута.
+	OnDrainProgress func(name string, remaining int)
+	// DrainProgressInterval — период вызова OnDrainProgress (0 —
+	// DefaultDrainProgressInterval).
+	DrainProgressInterval time.Duration
+	// DrainTimeout ограничивает ожидание дренажа очереди этого роута при
+	// закрытии логгера (Close, а также Reset): не успел — оставшиеся
+	// записи бросаются, их число уходит в OnDrainAbandoned и RouteHealth.Abandoned.
+	// Зависшую запись в writer'е прервать нельзя: воркер дописывает её в фоне и
+	// завершается. Воркер, запущенный после Reset, пишет в Writer только после
+	// этого. 0 — ждать сколько нужно. Задавать до Start.
+	DrainTimeout time.Duration
+	// OnDrainAbandoned вызывается по истечении DrainTimeout с числом брошенных записей.
+	OnDrainAbandoned func(name string, abandoned int)
+
+	drops  dropStats
+	stats  routeStats
 	queue  chan LogRecordRaw
+	spill  *spillQueue // файл переполнения текущего запуска; nil — без SpillDir
 	closed bool
 	mu     sync.RWMutex
+	// queueClosed — очередь закрыта Close; читается воркером без mu (см. Start)
+	queueClosed atomic.Bool
+
+	syncMode bool       // синхронный режим: без очереди и воркера
+	syncMu   sync.Mutex // упорядочивает запись в синхронном режиме
+	// writeMu — в Writer пишет один воркер: брошенный по DrainTimeout может
+	// ещё дописывать зависшую запись, когда роут уже перезапущен
+	writeMu sync.Mutex
 }
 
 // NewRouteProcessor создаёт маршрутизатор логов с указанным форматтером и writer'ом.
@@ -27,40 +89,160 @@ func NewRouteProcessor(formatter FormatProcessor, writer WriteProcessor, level L
 	}
 }
 
+// NewSyncRouteProcessor создаёт синхронный маршрутизатор: Enqueue форматирует и
+// пишет запись сразу в вызывающей горутине (с Flush), без канала и воркера.
+// Порядок вывода совпадает с порядком вызовов — удобно для CLI и тестов.
+func NewSyncRouteProcessor(formatter FormatProcessor, writer WriteProcessor, level LogLevel) *RouteProcessor {
+	return &RouteProcessor{
+		Formatter:      formatter,
+		Writer:         writer,
+		LevelThreshold: level,
+		syncMode:       true,
+	}
+}
+
 // ShouldLog проверяет, подходит ли уровень события для этого роута.
 func (r *RouteProcessor) ShouldLog(level LogLevel) bool {
 	return level >= r.LevelThreshold
 }
 
 // Enqueue отправляет событие в очередь логирования (если не закрыто).
+// Отправка идёт под RLock: Close берёт Lock и закрывает канал только после того,
+// как все начатые отправки завершились, поэтому send в закрытый канал невозможен.
 func (r *RouteProcessor) Enqueue(record LogRecordRaw) {
 	r.mu.RLock()
-	closed := r.closed
-	q := r.queue
-	r.mu.RUnlock()
-	if closed {
+	if r.closed {
+		r.mu.RUnlock()
 		return
 	}
-	q <- record
+	r.stats.enqueued.Add(1)
+	if r.syncMode {
+		r.syncMu.Lock()
+		r.process(record)
+		r.flush()
+		r.syncMu.Unlock()
+		r.mu.RUnlock()
+		return
+	}
+	if r.Overflow == OverflowDrop {
+		select {
+		case r.queue <- record:
+			r.mu.RUnlock()
+		default:
+			// учёт — под RLock, чтобы итоговый отчёт Close его не пропустил
+			n, window, report := r.recordDrop()
+			r.mu.RUnlock()
+			r.reportDrop(n, window, report)
+		}
+		return
+	}
+	if r.spill != nil && r.spillEnqueue(record) {
+		r.mu.RUnlock()
+		return
+	}
+	// воркер читает очередь без блокировки, так что ожидание места под RLock
+	// не мешает ему, а лишь задерживает Close до окончания этой отправки
+	select {
+	case r.queue <- record:
+	default:
+		r.stats.markFull()
+		r.queue <- record
+	}
+	r.mu.RUnlock()
+}
+
+// TryEnqueue — неблокирующий Enqueue: при полной очереди (или пока не вычитан
+// файл переполнения SpillDir) сразу возвращает false, независимо от Overflow.
+// Такая запись считается потерянной, как при OverflowDrop: Dropped и отчёт в
+// Diagnostics. В синхронном режиме пишет запись сразу.
+func (r *RouteProcessor) TryEnqueue(record LogRecordRaw) bool {
+	r.mu.RLock()
+	if r.closed {
+		r.mu.RUnlock()
+		return false
+	}
+	r.stats.enqueued.Add(1)
+	if r.syncMode {
+		r.syncMu.Lock()
+		r.process(record)
+		r.flush()
+		r.syncMu.Unlock()
+		r.mu.RUnlock()
+		return true
+	}
+
+	var sent bool
+	if r.spill != nil {
+		sent = r.trySpillEnqueue(record)
+	} else {
+		select {
+		case r.queue <- record:
+			sent = true
+		default:
+		}
+	}
+	if sent {
+		r.mu.RUnlock()
+		return true
+	}
+	n, window, report := r.recordDrop()
+	r.mu.RUnlock()
+	r.reportDrop(n, window, report)
+	return false
 }
 
 // Start запускает обработку очереди в отдельной горутине.
 func (r *RouteProcessor) Start(ctx context.Context, wg *sync.WaitGroup) {
-	wg.Add(1)
+	if r.syncMode {
+		return
+	}
+	r.mu.Lock()
+	if r.SpillDir != "" {
+		// у каждого запуска свой файл: воркер на выходе закрывает только свой
+		r.spill = newSpillQueue(r.SpillDir, r.SpillMaxBytes)
+	}
+	q, spill := r.queue, r.spill
+	r.mu.Unlock()
+
+	var spillReady chan struct{}
+	if spill != nil {
+		spillReady = spill.ready
+	}
+
+	// abandoned — DrainTimeout истёк, остаток очереди этого воркера не пишется
+	abandoned := new(atomic.Bool)
+	// с DrainTimeout WaitGroup держит не воркер, а сторож awaitDrain
+	release := wg.Done
+	if r.DrainTimeout > 0 {
+		done := make(chan struct{})
+		release = func() { close(done) }
+		wg.Add(1)
+		go r.awaitDrain(ctx, wg, done, q, spill, abandoned)
+	} else {
+		wg.Add(1)
+	}
+	r.stats.running.Add(1)
 	go func() {
-		defer wg.Done()
-		defer r.drainQueue()
+		defer release()
+		defer r.stats.running.Add(-1)
+		defer r.drainQueue(q, spill, abandoned)
 
 		for {
 			select {
-			case rec, ok := <-r.queue:
-				if !ok {
+			case rec, ok := <-q:
+				if !ok || abandoned.Load() {
+					// брошенный по DrainTimeout остаток отбрасывает drainQueue
+					skipRecord(rec)
 					return
 				}
-				record := rawToRecord(rec)
-				if data, err := r.Formatter.Format(record); err == nil {
-					_ = r.Writer.Write(data)
+				r.clearFull(q, spill)
+				r.consume(rec, spill)
+				if r.OnDrainProgress != nil && r.queueClosed.Load() {
+					// остаток дописывает drainQueue с отчётом о прогрессе
+					return
 				}
+			case <-spillReady:
+				r.replaySpill(q, spill, false)
 			case <-ctx.Done():
 				// просто ждём закрытия очереди, drain сделает остальное
 				return
@@ -69,8 +251,65 @@ func (r *RouteProcessor) Start(ctx context.Context, wg *sync.WaitGroup) {
 	}()
 }
 
+// consume обрабатывает запись из очереди воркера со своим файлом переполнения
+// spill. На метке Flush всё, что было в очереди до неё, уже записано; файл
+// переполнения старше записей, пришедших после метки, — его дописываем тоже.
+func (r *RouteProcessor) consume(rec LogRecordRaw, spill *spillQueue) {
+	if rec.barrier == nil {
+		r.process(rec)
+		return
+	}
+	if spill != nil {
+		r.replaySpill(nil, spill, true)
+	}
+	r.flush()
+	close(rec.barrier)
+}
+
+// process форматирует и пишет одну запись; ошибки отдаются в OnError.
+func (r *RouteProcessor) process(rec LogRecordRaw) {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+
+	record := rawToRecord(rec)
+	data, err := r.format(record)
+	if err != nil {
+		r.reportError(err, nil)
+		if !r.FormatFallback {
+			return
+		}
+		data = fallbackFormat(record, err)
+	}
+	if rw, ok := r.Writer.(RecordWriteProcessor); ok {
+		err = rw.WriteRecord(record, data)
+	} else {
+		err = r.Writer.Write(data)
+	}
+	if err != nil {
+		r.reportError(err, data)
+		return
+	}
+	if r.FlushMinLevel > Trace && record.Level >= r.FlushMinLevel {
+		if err := r.flushDurable(); err != nil {
+			r.reportError(err, data)
+			return
+		}
+	}
+	r.stats.processed.Add(1)
+}
+
+func (r *RouteProcessor) reportError(err error, formatted []byte) {
+	r.stats.errors.Add(1)
+	if r.OnError != nil {
+		r.OnError(err, formatted)
+	}
+}
+
+// rawToRecord разбирает сырую запись. Без полей (nil, пусто или заглушка без
+// пар key\0value\0) Fields остаётся nil: map не аллоцируется, а форматтеры
+// выводят такую запись так же, как с пустой map.
 func rawToRecord(rec LogRecordRaw) LogRecord {
-	fields := make(map[string]interface{})
+	var fields map[string]interface{}
 
 	if len(rec.Fields) > 0 {
 		b := rec.Fields
@@ -85,6 +324,10 @@ func rawToRecord(rec LogRecordRaw) LogRecord {
 					key = part
 					isKey = false
 				} else {
+					if fields == nil {
+						// по паре на два нуля — map сразу нужного размера
+						fields = make(map[string]interface{}, bytes.Count(b, []byte{0})/2)
+					}
 					fields[key] = part
 					isKey = true
 				}
@@ -98,37 +341,191 @@ func rawToRecord(rec LogRecordRaw) LogRecord {
 		msg = string(rec.Message)
 	}
 
+	ts := rec.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
 	return LogRecord{
 		Level:     rec.Level,
-		Timestamp: time.Now(),
+		Timestamp: ts,
 		Message:   msg,
 		Fields:    fields,
+		Seq:       rec.Seq,
+		Caller:    rec.Caller,
+		Tags:      rec.Tags,
+	}
+}
+
+// drainQueue считывает остатки очереди q и вызывает Flush(). Очередь и файл
+// переполнения передаются явно: после брошенного по DrainTimeout дренажа роут
+// может быть уже открыт заново с новыми, а этот воркер ещё дописывает старые.
+func (r *RouteProcessor) drainQueue(q chan LogRecordRaw, spill *spillQueue, abandoned *atomic.Bool) {
+	if r.OnDrainProgress == nil {
+		for rec := range q {
+			if abandoned.Load() {
+				skipRecord(rec)
+				continue
+			}
+			r.consume(rec, spill)
+		}
+	} else {
+		r.drainWithProgress(q, spill, abandoned)
+	}
+	if spill != nil {
+		if !abandoned.Load() {
+			r.replaySpill(q, spill, true)
+		}
+		spill.close()
+	}
+	if abandoned.Load() {
+		// о брошенном остатке уже сообщил awaitDrain
+		return
+	}
+	if r.OnDrainProgress != nil {
+		r.OnDrainProgress(r.Name, 0)
+	}
+
+	r.flush()
+}
+
+// drainWithProgress — дренаж очереди с вызовами OnDrainProgress. Очередь уже
+// закрыта, так что len(q) только убывает.
+func (r *RouteProcessor) drainWithProgress(q chan LogRecordRaw, spill *spillQueue, abandoned *atomic.Bool) {
+	interval := r.DrainProgressInterval
+	if interval <= 0 {
+		interval = DefaultDrainProgressInterval
+	}
+	r.OnDrainProgress(r.Name, len(q))
+	last := time.Now()
+	for rec := range q {
+		if abandoned.Load() {
+			skipRecord(rec)
+			continue
+		}
+		r.consume(rec, spill)
+		if now := time.Now(); now.Sub(last) >= interval {
+			last = now
+			r.OnDrainProgress(r.Name, len(q))
+		}
 	}
 }
 
-// drainQueue считывает остатки очереди и вызывает Flush().
-func (r *RouteProcessor) drainQueue() {
-	for rec := range r.queue {
-		record := rawToRecord(rec)
-		if data, err := r.Formatter.Format(record); err == nil {
-			_ = r.Writer.Write(data)
+// awaitDrain держит WaitGroup логгера вместо воркера роута с DrainTimeout:
+// отпускает, когда воркер завершился, или через DrainTimeout после начала
+// закрытия (отмены ctx) — тогда остаток очереди помечается брошенным.
+func (r *RouteProcessor) awaitDrain(ctx context.Context, wg *sync.WaitGroup, done <-chan struct{},
+	q chan LogRecordRaw, spill *spillQueue, abandoned *atomic.Bool) {
+	defer wg.Done()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+	timer := time.NewTimer(r.DrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		abandoned.Store(true)
+		n := len(q)
+		if spill != nil {
+			n += spill.len()
+		}
+		r.stats.abandoned.Add(uint64(n))
+		if r.OnDrainAbandoned != nil {
+			r.OnDrainAbandoned(r.Name, n)
 		}
 	}
+}
+
+// skipRecord отбрасывает запись брошенного дренажа; ожидающий метку Flush
+// при этом отпускается.
+func skipRecord(rec LogRecordRaw) {
+	if rec.barrier != nil {
+		close(rec.barrier)
+	}
+}
+
+// Flush дожидается, пока воркер допишет всё, что было поставлено в очередь до
+// вызова (и файл переполнения), и сбрасывает writer. В отличие от Close очередь
+// не закрывается: Enqueue из других горутин продолжает работать. Не вызывать
+// из самого воркера (OnError, writer'а) — это взаимоблокировка.
+func (r *RouteProcessor) Flush() {
+	r.mu.RLock()
+	if r.closed {
+		r.mu.RUnlock()
+		return
+	}
+	if r.syncMode {
+		r.syncMu.Lock()
+		r.flush()
+		r.syncMu.Unlock()
+		r.mu.RUnlock()
+		return
+	}
+	done := make(chan struct{})
+	r.queue <- LogRecordRaw{barrier: done}
+	r.mu.RUnlock()
+	<-done
+}
 
+func (r *RouteProcessor) flush() {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
 	if f, ok := r.Writer.(FlushableWriter); ok {
 		_ = f.Flush()
 	}
 }
 
-// Close завершает работу: закрывает очередь (если ещё нет).
-func (r *RouteProcessor) Close() {
+// flushDurable сбрасывает буфер writer'а и, если он умеет, данные на диск.
+func (r *RouteProcessor) flushDurable() error {
+	if f, ok := r.Writer.(FlushableWriter); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if s, ok := r.Writer.(SyncableWriter); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// reopen пересоздаёт очередь закрытого роута перед повторным Start.
+func (r *RouteProcessor) reopen() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if !r.closed {
+		return
+	}
+	if !r.syncMode {
+		r.queue = make(chan LogRecordRaw, cap(r.queue))
+	}
+	r.queueClosed.Store(false)
+	r.closed = false
+}
+
+// Close завершает работу: закрывает очередь (если ещё нет) и сообщает в
+// Diagnostics о потерях, отчёт о которых ещё не выходил.
+func (r *RouteProcessor) Close() {
+	r.mu.Lock()
 	if r.closed {
+		r.mu.Unlock()
 		return
 	}
 
-	close(r.queue)
 	r.closed = true
+	if r.syncMode {
+		// все записи уже сделаны и сброшены в Enqueue
+		r.mu.Unlock()
+		return
+	}
+	close(r.queue)
+	r.queueClosed.Store(true)
+	// новых потерь не будет: сообщаем о тех, чьё окно ещё не истекло
+	n, window, report := r.drops.flush()
+	r.mu.Unlock()
+	r.reportDrop(n, window, report)
 }
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSeqGapFreeUnderConcurrency(t *testing.T) {
+	const goroutines, perG = 8, 200
+	a, b := &memWriter{}, &memWriter{}
+	l := NewLogger(
+		NewRouteProcessor(lineFormatter{}, a, Info),
+		NewRouteProcessor(lineFormatter{}, b, Info),
+	)
+	l.EnableSeq(true)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perG; i++ {
+				l.Log(LogRecordRaw{Level: Info, Message: []byte(strconv.Itoa(g))})
+			}
+		}(g)
+	}
+	wg.Wait()
+	l.Close()
+
+	for name, w := range map[string]*memWriter{"a": a, "b": b} {
+		recs := w.Records()
+		if len(recs) != goroutines*perG {
+			t.Fatalf("%s: %d records", name, len(recs))
+		}
+		seen := make([]bool, len(recs)+1)
+		last := map[string]uint64{}
+		for _, r := range recs {
+			if r.Seq == 0 || r.Seq > uint64(len(recs)) || seen[r.Seq] {
+				t.Fatalf("%s: seq %d out of range or repeated", name, r.Seq)
+			}
+			seen[r.Seq] = true
+			// в пределах одной горутины номера строго растут
+			if r.Seq <= last[r.Message] {
+				t.Fatalf("%s: goroutine %s: seq %d after %d", name, r.Message, r.Seq, last[r.Message])
+			}
+			last[r.Message] = r.Seq
+		}
+	}
+
+	// номер общий для роутов: одна и та же запись в обоих
+	ra, rb := a.Records(), b.Records()
+	bySeq := map[uint64]string{}
+	for _, r := range ra {
+		bySeq[r.Seq] = r.Message
+	}
+	for _, r := range rb {
+		if bySeq[r.Seq] != r.Message {
+			t.Fatalf("seq %d: %q in a, %q in b", r.Seq, bySeq[r.Seq], r.Message)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultSpillMaxBytes — предельный размер файла переполнения по умолчанию.
+const DefaultSpillMaxBytes = 64 << 20
+
+var errSpillFull = errors.New("spill file is full")
+
+// spillQueue — FIFO-очередь записей во временном файле. Пока в ней что-то есть,
+// новые записи роута идут сюда же (а при заполненном файле ждут места в нём),
+// иначе нарушится порядок: воркер сначала дочитывает канал, затем
+// воспроизводит файл. Прочитанное начало файла вырезается по мере чтения
+// (compact), так что maxBytes ограничивает именно невоспроизведённые записи.
+type spillQueue struct {
+	dir      string
+	maxBytes int64
+	ready    chan struct{} // сигнал воркеру: в файле есть записи
+
+	mu       sync.Mutex
+	space    *sync.Cond // на mu: прочитана запись, ждущие Enqueue проверяют место
+	file     *os.File
+	writeOff int64
+	readOff  int64
+	count    int
+}
+
+func newSpillQueue(dir string, maxBytes int64) *spillQueue {
+	if maxBytes <= 0 {
+		maxBytes = DefaultSpillMaxBytes
+	}
+	s := &spillQueue{dir: dir, maxBytes: maxBytes, ready: make(chan struct{}, 1)}
+	s.space = sync.NewCond(&s.mu)
+	return s
+}
+
+// pending сообщает, есть ли невоспроизведённые записи. Вызывать под mu.
+func (s *spillQueue) pending() bool {
+	return s.count > 0
+}
+
+// len — число невоспроизведённых записей.
+func (s *spillQueue) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// push дописывает запись в файл. Вызывать под mu.
+func (s *spillQueue) push(rec LogRecordRaw) error {
+	data := encodeSpillRecord(rec)
+	if s.writeOff-s.readOff+int64(len(data)) > s.maxBytes {
+		return errSpillFull
+	}
+	if s.writeOff+int64(len(data)) > s.maxBytes {
+		// место есть только за счёт уже прочитанного начала
+		if err := s.compact(); err != nil {
+			return err
+		}
+	}
+	if s.file == nil {
+		f, err := os.CreateTemp(s.dir, "loggo-spill-*.bin")
+		if err != nil {
+			return err
+		}
+		s.file = f
+	}
+	if _, err := s.file.WriteAt(data, s.writeOff); err != nil {
+		return err
+	}
+	s.writeOff += int64(len(data))
+	s.count++
+	s.signal()
+	return nil
+}
+
+// pop читает самую старую запись; ok == false — очередь пуста.
+func (s *spillQueue) pop() (rec LogRecordRaw, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count == 0 {
+		return LogRecordRaw{}, false, nil
+	}
+	var size [4]byte
+	if _, err := s.file.ReadAt(size[:], s.readOff); err != nil {
+		s.reset()
+		return LogRecordRaw{}, false, err
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(size[:]))
+	if _, err := s.file.ReadAt(data, s.readOff+4); err != nil && !errors.Is(err, io.EOF) {
+		s.reset()
+		return LogRecordRaw{}, false, err
+	}
+	s.readOff += 4 + int64(len(data))
+	s.count--
+	if s.count == 0 {
+		s.reset()
+	} else if s.readOff >= s.maxBytes/2 {
+		// прочитанная половина файла больше не нужна; ошибку увидит push
+		_ = s.compact()
+	}
+	s.space.Broadcast()
+	rec, err = decodeSpillRecord(data)
+	return rec, err == nil, err
+}
+
+// compact переносит непрочитанные записи в начало файла и обрезает его.
+// Вызывать под mu.
+func (s *spillQueue) compact() error {
+	if s.readOff == 0 {
+		return nil
+	}
+	buf := make([]byte, 64<<10)
+	for off := s.readOff; off < s.writeOff; {
+		n, err := s.file.ReadAt(buf[:min(int64(len(buf)), s.writeOff-off)], off)
+		if n > 0 {
+			if _, werr := s.file.WriteAt(buf[:n], off-s.readOff); werr != nil {
+				return werr
+			}
+			off += int64(n)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+	}
+	s.writeOff -= s.readOff
+	s.readOff = 0
+	return s.file.Truncate(s.writeOff)
+}
+
+// reset обрезает файл, когда он опустел (или повреждён). Вызывать под mu.
+func (s *spillQueue) reset() {
+	s.space.Broadcast()
+	s.count = 0
+	s.readOff = 0
+	s.writeOff = 0
+	if s.file != nil {
+		_ = s.file.Truncate(0)
+	}
+}
+
+// close удаляет временный файл; оставшиеся записи теряются.
+func (s *spillQueue) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
+
+func (s *spillQueue) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// encodeSpillRecord: [len u32][level i64][ts i64][seq u64][msgLen u32][msg]
+// [callerLen u32][caller][tagsLen u32][tag\0tag\0...][fields].
+func encodeSpillRecord(rec LogRecordRaw) []byte {
+	ts := int64(math.MinInt64) // нулевое время
+	if !rec.Timestamp.IsZero() {
+		ts = rec.Timestamp.UnixNano()
+	}
+	var tags []byte
+	for _, t := range rec.Tags {
+		tags = append(append(tags, t...), 0)
+	}
+	n := 8 + 8 + 8 + 4 + len(rec.Message) + 4 + len(rec.Caller) + 4 + len(tags) + len(rec.Fields)
+	b := make([]byte, 4, 4+n)
+	binary.LittleEndian.PutUint32(b, uint32(n))
+	b = binary.LittleEndian.AppendUint64(b, uint64(rec.Level))
+	b = binary.LittleEndian.AppendUint64(b, uint64(ts))
+	b = binary.LittleEndian.AppendUint64(b, rec.Seq)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(rec.Message)))
+	b = append(b, rec.Message...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(rec.Caller)))
+	b = append(b, rec.Caller...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(tags)))
+	b = append(b, tags...)
+	return append(b, rec.Fields...)
+}
+
+func decodeSpillRecord(b []byte) (LogRecordRaw, error) {
+	if len(b) < 24 {
+		return LogRecordRaw{}, errors.New("spill: truncated record")
+	}
+	rec := LogRecordRaw{
+		Level: LogLevel(int64(binary.LittleEndian.Uint64(b[0:]))),
+		Seq:   binary.LittleEndian.Uint64(b[16:]),
+	}
+	if ts := int64(binary.LittleEndian.Uint64(b[8:])); ts != math.MinInt64 {
+		rec.Timestamp = time.Unix(0, ts)
+	}
+	rest := b[24:]
+	msg, rest, ok := cutSpillBytes(rest)
+	if !ok {
+		return LogRecordRaw{}, errors.New("spill: truncated record")
+	}
+	caller, rest, ok := cutSpillBytes(rest)
+	if !ok {
+		return LogRecordRaw{}, errors.New("spill: truncated record")
+	}
+	tags, rest, ok := cutSpillBytes(rest)
+	if !ok {
+		return LogRecordRaw{}, errors.New("spill: truncated record")
+	}
+	rec.Message = msg
+	rec.Caller = string(caller)
+	for len(tags) > 0 {
+		i := bytes.IndexByte(tags, 0)
+		if i < 0 {
+			return LogRecordRaw{}, errors.New("spill: malformed tags")
+		}
+		rec.Tags = append(rec.Tags, string(tags[:i]))
+		tags = tags[i+1:]
+	}
+	if len(rest) > 0 {
+		rec.Fields = rest
+	}
+	return rec, nil
+}
+
+// cutSpillBytes отрезает от b значение с префиксом длины u32.
+func cutSpillBytes(b []byte) (v, rest []byte, ok bool) {
+	if len(b) < 4 {
+		return nil, nil, false
+	}
+	n := int(binary.LittleEndian.Uint32(b))
+	if 4+n > len(b) {
+		return nil, nil, false
+	}
+	return b[4 : 4+n], b[4+n:], true
+}
+
+// spillEnqueue ставит запись в канал, если в нём есть место и файл пуст, иначе
+// в файл. Пока в файле есть записи, в канал ничего не идёт: при заполненном
+// (или недоступном) файле вызов ждёт, пока воркер его вычитает. false — файл
+// пуст, но запись в него не влезла: вызывающий должен ждать места в канале,
+// порядок при этом не нарушится. Вызывать под r.mu.RLock.
+func (r *RouteProcessor) spillEnqueue(record LogRecordRaw) bool {
+	s := r.spill
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for {
+		if !s.pending() {
+			select {
+			case r.queue <- record:
+				return true
+			default:
+			}
+		}
+		r.stats.markFull()
+		err := s.push(record)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, errSpillFull) {
+			r.reportError(err, nil)
+		}
+		if !s.pending() {
+			return false
+		}
+		// в файле записи старше этой — ждём, пока воркер освободит место
+		s.space.Wait()
+	}
+}
+
+// trySpillEnqueue — неблокирующий вариант для TryEnqueue: false, если в файле
+// есть записи (встать в канал перед ними нельзя) или канал полон.
+func (r *RouteProcessor) trySpillEnqueue(record LogRecordRaw) bool {
+	s := r.spill
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending() {
+		return false
+	}
+	select {
+	case r.queue <- record:
+		return true
+	default:
+		return false
+	}
+}
+
+// replaySpill воспроизводит записи из файла, пока канал пуст: записи канала
+// старше файловых. Если канал непуст, повторно сигналит и уступает ему.
+func (r *RouteProcessor) replaySpill(q chan LogRecordRaw, spill *spillQueue, all bool) {
+	for {
+		if !all && len(q) > 0 {
+			spill.signal()
+			return
+		}
+		rec, ok, err := spill.pop()
+		if err != nil {
+			r.reportError(err, nil)
+			continue
+		}
+		if !ok {
+			r.clearFull(q, spill)
+			return
+		}
+		r.process(rec)
+	}
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSpillQueueCompactsAsRead(t *testing.T) {
+	const maxBytes = 1024
+	s := newSpillQueue(t.TempDir(), maxBytes)
+	defer s.close()
+
+	push := func(i int) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := s.push(info(strconv.Itoa(i))); err != nil {
+			t.Fatalf("push %d: %v", i, err)
+		}
+	}
+	next := 0
+	pop := func() {
+		rec, ok, err := s.pop()
+		if err != nil || !ok {
+			t.Fatalf("pop: ok=%v err=%v", ok, err)
+		}
+		if got := string(rec.Message); got != strconv.Itoa(next) {
+			t.Fatalf("pop = %s, want %d", got, next)
+		}
+		next++
+	}
+
+	// постоянный хвост из пяти записей: файл ни разу не пустеет, но прочитанное
+	// начало вырезается, и суммарно записанное многократно превышает лимит
+	for i := 0; i < 5; i++ {
+		push(i)
+	}
+	for i := 5; i < 2000; i++ {
+		push(i)
+		pop()
+		info, err := s.file.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > maxBytes {
+			t.Fatalf("spill file grew to %d bytes, limit %d", info.Size(), maxBytes)
+		}
+	}
+	for s.len() > 0 {
+		pop()
+	}
+	if next != 2000 {
+		t.Fatalf("read %d records, want 2000", next)
+	}
+}
+
+func TestSpillKeepsOrderWithSlowWriter(t *testing.T) {
+	w := &memWriter{block: make(chan struct{})}
+	r := NewRouteProcessor(lineFormatter{}, w, Trace)
+	r.queue = make(chan LogRecordRaw, 4)
+	r.SpillDir = t.TempDir()
+	// в файл влезает лишь несколько десятков записей: Enqueue упрётся в лимит
+	r.SpillMaxBytes = 2048
+	l := NewLogger(r)
+
+	const n = 3000
+	time.AfterFunc(50*time.Millisecond, func() { close(w.block) })
+	for i := 0; i < n; i++ {
+		l.Log(info(fmt.Sprint(i)))
+	}
+	l.Close()
+
+	lines := w.Lines()
+	if len(lines) != n {
+		t.Fatalf("got %d records, want %d", len(lines), n)
+	}
+	for i, line := range lines {
+		if line != fmt.Sprint(i) {
+			t.Fatalf("record %d is %q: order broken", i, line)
+		}
+	}
+	if h := r.Health(time.Hour); h.Errors != 0 {
+		t.Fatalf("route errors: %d", h.Errors)
+	}
+}
+
+func TestSpillTryEnqueueDoesNotOvertake(t *testing.T) {
+	w := &memWriter{block: make(chan struct{})}
+	r := NewRouteProcessor(lineFormatter{}, w, Trace)
+	r.queue = make(chan LogRecordRaw, 1)
+	r.SpillDir = t.TempDir()
+	l := NewLogger(r)
+
+	// воркер держит первую запись, вторая — в канале, остальные в файле
+	for i := 0; i < 10; i++ {
+		l.Log(info(fmt.Sprint(i)))
+	}
+	if l.TryLog(info("try")) {
+		t.Fatal("TryLog accepted a record ahead of spilled ones")
+	}
+	close(w.block)
+	l.Close()
+
+	lines := w.Lines()
+	for i, line := range lines {
+		if line != fmt.Sprint(i) {
+			t.Fatalf("record %d is %q: order broken", i, line)
+		}
+	}
+}
@@ -4,8 +4,25 @@ type FormatStyle struct {
 	ColorKeys   bool
 	ColorValues bool
 	ColorLevel  bool
+	// ColorWholeLine окрашивает всю строку цветом уровня; ColorKeys/ColorValues/
+	// ColorLevel при этом не применяются, чтобы Reset не обрывал цвет строки.
+	ColorWholeLine bool
 
 	KeyColor   string // ANSI
 	ValueColor string
 	Reset      string
+
+	// Токены для bool/null в текстовом выводе (пусто — "true"/"false"/"null").
+	// JSON всегда остаётся строгим и их игнорирует.
+	TrueToken  string
+	FalseToken string
+	NullToken  string
+
+	// HumanizeNumbers выводит в тексте целые с SI-суффиксами: 1500000 → 1.5M.
+	// JSON всегда пишет точные числа.
+	HumanizeNumbers bool
+	// HumanizeThreshold — с какого модуля значения применять суффиксы (0 — 1000).
+	HumanizeThreshold uint64
+	// HumanizeSuffixes — суффиксы для 10^3, 10^6, ... (nil — k, M, G, T, P, E).
+	HumanizeSuffixes []string
 }
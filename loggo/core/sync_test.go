@@ -0,0 +1,37 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSyncRoutesKeepCallOrder(t *testing.T) {
+	shared := &memWriter{}
+	l := NewLogger(
+		NewSyncRouteProcessor(lineFormatter{}, shared, Info),
+		NewSyncRouteProcessor(prefixFormatter{"E:"}, shared, Error),
+	)
+	defer l.Close()
+
+	l.Log(info("1"))
+	l.Log(LogRecordRaw{Level: Error, Message: []byte("2")})
+	l.Log(info("3"))
+	l.Log(LogRecordRaw{Level: Error, Message: []byte("4")})
+
+	// записано сразу, без Close/Flush, в порядке вызовов и роутов
+	want := []string{"1", "2", "E:2", "3", "4", "E:4"}
+	if got := shared.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if shared.flushes != 6 {
+		t.Errorf("%d flushes, want one per write", shared.flushes)
+	}
+}
+
+// prefixFormatter — lineFormatter с префиксом.
+type prefixFormatter struct{ prefix string }
+
+func (f prefixFormatter) Format(r LogRecord) ([]byte, error) {
+	b, err := lineFormatter{}.Format(r)
+	return append([]byte(f.prefix), b...), err
+}
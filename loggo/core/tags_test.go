@@ -0,0 +1,36 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagsMergeWithBaseTags(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	defer l.Close()
+
+	l.Log(LogRecordRaw{Level: Info, Tags: []string{"a", "", "a\x00b", "b"}})
+	l.SetBaseTags("svc", "hot-path", "svc")
+	l.Log(LogRecordRaw{Level: Info, Tags: []string{"billing", "svc", "x\x00"}})
+	l.Log(info("no own tags"))
+	l.SetBaseTags()
+	l.Log(info("no tags"))
+	l.Flush()
+
+	want := [][]string{
+		{"a", "b"},
+		{"svc", "hot-path", "billing"},
+		{"svc", "hot-path"},
+		nil,
+	}
+	recs := w.Records()
+	if len(recs) != len(want) {
+		t.Fatalf("%d records, want %d", len(recs), len(want))
+	}
+	for i, r := range recs {
+		if !reflect.DeepEqual(r.Tags, want[i]) {
+			t.Errorf("record %d tags = %q, want %q", i, r.Tags, want[i])
+		}
+	}
+}
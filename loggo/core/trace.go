@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"strings"
+)
+
+// TraceParent — разобранный заголовок W3C traceparent:
+// "00-<trace-id 32 hex>-<parent-id 16 hex>-<flags 2 hex>".
+type TraceParent struct {
+	Version string
+	TraceID string
+	SpanID  string
+	Flags   string
+}
+
+type traceParentKey struct{}
+
+// ParseTraceParent разбирает заголовок traceparent; ok=false, если он некорректен
+// (не тот формат, не-hex, нулевые trace-id/parent-id, версия ff).
+func ParseTraceParent(header string) (tp TraceParent, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return TraceParent{}, false
+	}
+	tp = TraceParent{Version: parts[0], TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}
+
+	switch {
+	case !isLowerHex(tp.Version, 2) || tp.Version == "ff":
+		return TraceParent{}, false
+	case tp.Version == "00" && len(parts) != 4:
+		return TraceParent{}, false
+	case !isLowerHex(tp.TraceID, 32) || isAllZero(tp.TraceID):
+		return TraceParent{}, false
+	case !isLowerHex(tp.SpanID, 16) || isAllZero(tp.SpanID):
+		return TraceParent{}, false
+	case !isLowerHex(tp.Flags, 2):
+		return TraceParent{}, false
+	}
+	return tp, true
+}
+
+// ContextWithTraceParent кладёт в контекст разобранный traceparent.
+func ContextWithTraceParent(ctx context.Context, tp TraceParent) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, tp)
+}
+
+// TraceParentFromContext возвращает traceparent, положенный ContextWithTraceParent.
+func TraceParentFromContext(ctx context.Context) (TraceParent, bool) {
+	tp, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return tp, ok
+}
+
+// W3CTraceExtractor добавляет trace_id и span_id из traceparent в контексте.
+// Включается явно: RegisterContextExtractor(W3CTraceExtractor).
+func W3CTraceExtractor(ctx context.Context) map[string]string {
+	tp, ok := TraceParentFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	return map[string]string{"trace_id": tp.TraceID, "span_id": tp.SpanID}
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isAllZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+const validTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	tp, ok := ParseTraceParent(" " + validTraceParent + "\n")
+	if !ok || tp.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tp.SpanID != "00f067aa0ba902b7" || tp.Flags != "01" {
+		t.Fatalf("valid: %+v, %v", tp, ok)
+	}
+	// будущие версии могут дописывать поля
+	if _, ok := ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+		t.Error("future version with extra field rejected")
+	}
+
+	for _, bad := range []string{
+		"",
+		"garbage",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",       // нет flags
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xx", // лишнее поле в версии 00
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", // верхний регистр
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",  // короткий trace-id
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902bz-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1",
+	} {
+		if tp, ok := ParseTraceParent(bad); ok {
+			t.Errorf("ParseTraceParent(%q) = %+v, want rejected", bad, tp)
+		}
+	}
+}
+
+func TestW3CTraceExtractor(t *testing.T) {
+	extractorsMu.Lock()
+	saved := extractors
+	extractors = nil
+	extractorsMu.Unlock()
+	t.Cleanup(func() {
+		extractorsMu.Lock()
+		extractors = saved
+		extractorsMu.Unlock()
+	})
+	RegisterContextExtractor(W3CTraceExtractor)
+
+	w := &memWriter{}
+	l := NewLogger(NewSyncRouteProcessor(lineFormatter{}, w, Info))
+
+	tp, _ := ParseTraceParent(validTraceParent)
+	ctx := ContextWithTraceParent(context.Background(), tp)
+	l.LogContext(ctx, info("valid"))
+
+	// некорректный заголовок не разбирается и в контекст не попадает — полей нет
+	if _, ok := ParseTraceParent("00-zz-00f067aa0ba902b7-01"); ok {
+		t.Fatal("malformed traceparent parsed")
+	}
+	l.LogContext(context.Background(), info("malformed"))
+
+	// поле вызывающего не перезаписывается
+	own := info("own")
+	own.Fields = []byte("trace_id\x00mine\x00")
+	l.LogContext(ctx, own)
+	l.Close()
+
+	want := []string{
+		"valid span_id=00f067aa0ba902b7 trace_id=4bf92f3577b34da6a3ce929d0e0e4736",
+		"malformed",
+		"own span_id=00f067aa0ba902b7 trace_id=mine",
+	}
+	got := w.Lines()
+	if len(got) != len(want) {
+		t.Fatalf("lines %q", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTryLogNeverBlocks(t *testing.T) {
+	w := &memWriter{block: make(chan struct{})}
+	diag := &memWriter{}
+	r := NewRouteProcessor(lineFormatter{}, w, Trace)
+	r.queue = make(chan LogRecordRaw, 1)
+	r.Name = "main"
+	r.Diagnostics = diag
+	l := NewLogger(r)
+
+	l.Log(info("0"))
+	waitFor(t, func() bool { return len(r.queue) == 0 }) // воркер держит запись в Write
+	if !l.TryLog(info("1")) {
+		t.Fatal("TryLog rejected a record with room in the queue")
+	}
+
+	start := time.Now()
+	if l.TryLog(info("rejected")) {
+		t.Fatal("TryLog accepted a record into a full queue")
+	}
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("TryLog took %s", d)
+	}
+
+	// а Log при OverflowBlock ждёт места
+	logged := make(chan struct{})
+	go func() {
+		l.Log(info("2"))
+		close(logged)
+	}()
+	select {
+	case <-logged:
+		t.Fatal("Log returned while the queue was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(w.block)
+	<-logged
+	l.Close()
+
+	h := r.Health(time.Hour)
+	if h.Dropped != 1 || h.Enqueued != 4 || h.Processed != 3 {
+		t.Fatalf("stats %+v, want 1 dropped of 4", h)
+	}
+	if lines := diag.Lines(); len(lines) != 1 {
+		t.Fatalf("diagnostics %q, want one drop report", lines)
+	}
+	dropWindow(t, diag.Lines()[0], "main", "1")
+	if got := w.Lines(); len(got) != 3 || got[0] != "0" || got[1] != "1" || got[2] != "2" {
+		t.Fatalf("written %q", got)
+	}
+}
+
+func TestTryLogAcceptedByAnyRoute(t *testing.T) {
+	full := &memWriter{block: make(chan struct{})}
+	free := &memWriter{}
+	rFull := NewRouteProcessor(lineFormatter{}, full, Trace)
+	rFull.queue = make(chan LogRecordRaw, 1)
+	rFree := NewRouteProcessor(lineFormatter{}, free, Trace)
+	l := NewLogger(rFull, rFree)
+
+	l.Log(info("0"))
+	waitFor(t, func() bool { return len(rFull.queue) == 0 })
+	l.Log(info("1"))
+	if !l.TryLog(info("2")) {
+		t.Fatal("TryLog must report acceptance by the free route")
+	}
+	close(full.block)
+	l.Close()
+	if got := rFull.Health(time.Hour).Dropped; got != 1 {
+		t.Fatalf("full route Dropped = %d, want 1", got)
+	}
+	if got := len(free.Lines()); got != 3 {
+		t.Fatalf("free route wrote %d records, want 3", got)
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUptimeIncreases(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	l.EnableUptime(true)
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		l.Log(info("m"))
+	}
+	l.Flush()
+	l.Close()
+
+	var prev time.Duration
+	for i, r := range w.Records() {
+		d, err := time.ParseDuration(r.Fields["uptime"].(string))
+		if err != nil {
+			t.Fatalf("record %d: uptime %v: %v", i, r.Fields["uptime"], err)
+		}
+		if d <= prev || d > time.Since(l.StartTime()) {
+			t.Errorf("record %d: uptime %s after %s", i, d, prev)
+		}
+		prev = d
+	}
+	if prev == 0 {
+		t.Fatal("no records")
+	}
+}
+
+func TestUptimeOverridesAndDisabled(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Trace))
+	l.Log(info("off"))
+	l.EnableUptime(true)
+	l.Log(LogRecordRaw{Level: Info, Message: []byte("own"), Fields: appendRawField(nil, "uptime", "mine")})
+	l.SetBaseFields(map[string]any{"uptime": "base"})
+	l.Log(info("base"))
+	l.Flush()
+	l.Close()
+
+	recs := w.Records()
+	if len(recs) != 3 {
+		t.Fatalf("%d records", len(recs))
+	}
+	if _, ok := recs[0].Fields["uptime"]; ok {
+		t.Error("uptime emitted while disabled")
+	}
+	if recs[1].Fields["uptime"] != "mine" || recs[2].Fields["uptime"] != "base" {
+		t.Errorf("uptime = %v, %v; want mine, base", recs[1].Fields["uptime"], recs[2].Fields["uptime"])
+	}
+}
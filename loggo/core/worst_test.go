@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWorstLevelTracksMixedLevels(t *testing.T) {
+	w := &memWriter{}
+	// порог Error: Info и Warning отсеяны, но учитываются
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Error))
+	defer l.Close()
+
+	if got := l.WorstLevel(); got != Trace {
+		t.Fatalf("WorstLevel without records = %v, want Trace", got)
+	}
+	l.Log(info("a"))
+	l.Log(LogRecordRaw{Level: Debug})
+	if got := l.WorstLevel(); got != Info {
+		t.Fatalf("WorstLevel = %v, want Info", got)
+	}
+	l.LogAt(time.Now(), Warning, "filtered", nil)
+	if got := l.WorstLevel(); got != Warning {
+		t.Fatalf("WorstLevel after filtered LogAt = %v, want Warning", got)
+	}
+	l.LogContext(context.Background(), LogRecordRaw{Level: Warning})
+	l.TryLog(LogRecordRaw{Level: Error})
+	l.Log(info("b"))
+	if got := l.WorstLevel(); got != Error {
+		t.Fatalf("WorstLevel = %v, want Error", got)
+	}
+	if code := ExitCode(l.WorstLevel()); code != 1 {
+		t.Fatalf("ExitCode(Error) = %d, want 1", code)
+	}
+
+	l.Reset()
+	if got := l.WorstLevel(); got != Trace {
+		t.Fatalf("WorstLevel after Reset = %v, want Trace", got)
+	}
+}
+
+func TestLogAtFilteredExceptionExits(t *testing.T) {
+	w := &memWriter{}
+	l := NewLogger(NewRouteProcessor(lineFormatter{}, w, Exception+1))
+	l.ExitOnException = true
+	code, _ := stubExit(t, w)
+
+	l.LogAt(time.Time{}, Exception, "fatal", nil)
+	if *code != 1 {
+		t.Fatalf("exit code %d, want 1", *code)
+	}
+}
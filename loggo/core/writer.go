@@ -10,3 +10,18 @@ type FlushableWriter interface {
 	Write([]byte) error
 	Flush() error
 }
+
+// SyncableWriter — writer, умеющий сбросить данные на устройство (fsync).
+type SyncableWriter interface {
+	Sync() error
+}
+
+// RecordWriteProcessor — writer, которому кроме готовых байтов нужна сама запись
+// (уровень, поля): шардирование, разделение по уровням, severity для syslog и т.п.
+// RouteProcessor предпочитает WriteRecord, если writer его реализует; Write
+// остаётся для вызовов без записи. Обёртки над writer'ами должны передавать
+// запись дальше, иначе вложенный writer её потеряет.
+type RecordWriteProcessor interface {
+	WriteProcessor
+	WriteRecord(record LogRecord, formatted []byte) error
+}
@@ -0,0 +1,144 @@
+package writer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"strconv"
+	"unicode/utf8"
+)
+
+// chunkPrefix открывает каждую строку-фрагмент ChunkWriter'а.
+const chunkPrefix = "~chunk:"
+
+// ChunkWriter режет записи длиннее лимита на фрагменты вместо обрезки:
+// каждый фрагмент — отдельная строка
+//
+//	~chunk:<id>:<index>:<total>:<часть записи>
+//
+// где id — общий случайный идентификатор записи (16 hex), index — номер
+// фрагмента с 1, total — их число. Части режутся по границам символов UTF-8.
+// Собрать запись обратно можно через ParseChunk. Записи в пределах лимита
+// проходят как есть. Лимит, как у MaxLineWriter, включает '\n'.
+type ChunkWriter struct {
+	next     core.WriteProcessor
+	maxBytes int
+}
+
+// NewChunkWriter оборачивает next разбиением записей длиннее maxBytes (вместе
+// с '\n'). maxBytes должен вмещать заголовок фрагмента и хотя бы один символ;
+// иначе записи проходят без разбиения.
+func NewChunkWriter(next core.WriteProcessor, maxBytes int) *ChunkWriter {
+	return &ChunkWriter{next: next, maxBytes: maxBytes}
+}
+
+func (w *ChunkWriter) Write(p []byte) error {
+	return w.write(p, w.next.Write)
+}
+
+func (w *ChunkWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	return w.write(formatted, func(p []byte) error { return writeRecordTo(w.next, r, p) })
+}
+
+func (w *ChunkWriter) Flush() error {
+	if f, ok := w.next.(core.FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (w *ChunkWriter) write(p []byte, write func([]byte) error) error {
+	if len(p) <= w.maxBytes-1 {
+		return write(p)
+	}
+	chunks, ok := w.split(p)
+	if !ok {
+		return write(p)
+	}
+	var errs []error
+	for _, c := range chunks {
+		errs = append(errs, write(c))
+	}
+	return errors.Join(errs...)
+}
+
+// split режет p на фрагменты с заголовками; ok=false — лимит не вмещает заголовок.
+func (w *ChunkWriter) split(p []byte) ([][]byte, bool) {
+	id := newChunkID()
+	// заголовок зависит от числа фрагментов, а оно — от длины заголовка:
+	// уточняем, пока число не перестанет меняться
+	total := 2
+	for {
+		parts, ok := splitUTF8(p, w.maxBytes-1-chunkHeaderLen(id, total))
+		if !ok {
+			return nil, false
+		}
+		if len(parts) == total || len(strconv.Itoa(len(parts))) == len(strconv.Itoa(total)) {
+			total = len(parts)
+			out := make([][]byte, total)
+			for i, part := range parts {
+				out[i] = append(fmt.Appendf(nil, "%s%s:%d:%d:", chunkPrefix, id, i+1, total), part...)
+			}
+			return out, true
+		}
+		total = len(parts)
+	}
+}
+
+// chunkHeaderLen — наибольшая длина заголовка при total фрагментах.
+func chunkHeaderLen(id string, total int) int {
+	digits := len(strconv.Itoa(total))
+	return len(chunkPrefix) + len(id) + 1 + digits + 1 + digits + 1
+}
+
+// splitUTF8 делит p на части не длиннее size байт, не разрывая символы.
+// Невалидный UTF-8 (серия байтов продолжения длиннее символа) режется ровно
+// по size.
+func splitUTF8(p []byte, size int) ([][]byte, bool) {
+	if size < utf8.UTFMax {
+		return nil, false
+	}
+	var parts [][]byte
+	for len(p) > 0 {
+		cut := min(size, len(p))
+		back := 0
+		for cut < len(p) && back < utf8.UTFMax && !utf8.RuneStart(p[cut-back]) {
+			back++
+		}
+		if back < utf8.UTFMax {
+			cut -= back
+		}
+		parts = append(parts, p[:cut])
+		p = p[cut:]
+	}
+	return parts, true
+}
+
+func newChunkID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ParseChunk разбирает строку-фрагмент ChunkWriter'а. ok=false — строка не
+// фрагмент (обычная запись). Для сборки фрагменты с одним id склеиваются по
+// index от 1 до total.
+func ParseChunk(line []byte) (id string, index, total int, data []byte, ok bool) {
+	rest, found := bytes.CutPrefix(line, []byte(chunkPrefix))
+	if !found {
+		return "", 0, 0, nil, false
+	}
+	fields := bytes.SplitN(rest, []byte(":"), 4)
+	if len(fields) != 4 {
+		return "", 0, 0, nil, false
+	}
+	index, err1 := strconv.Atoi(string(fields[1]))
+	total, err2 := strconv.Atoi(string(fields[2]))
+	if err1 != nil || err2 != nil || index < 1 || index > total {
+		return "", 0, 0, nil, false
+	}
+	return string(fields[0]), index, total, fields[3], true
+}
@@ -0,0 +1,102 @@
+package writer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reassemble собирает записи из строк ChunkWriter'а; обычные строки — как есть.
+func reassemble(t *testing.T, lines []string) []string {
+	t.Helper()
+	var out []string
+	parts := map[string][][]byte{}
+	for _, l := range lines {
+		id, index, total, data, ok := ParseChunk([]byte(l))
+		if !ok {
+			out = append(out, l)
+			continue
+		}
+		if parts[id] == nil {
+			parts[id] = make([][]byte, total)
+		}
+		if len(parts[id]) != total {
+			t.Fatalf("fragment %q: total %d, want %d", l, total, len(parts[id]))
+		}
+		parts[id][index-1] = data
+		if index == total {
+			out = append(out, string(bytes.Join(parts[id], nil)))
+			delete(parts, id)
+		}
+	}
+	if len(parts) != 0 {
+		t.Fatalf("incomplete records: %d", len(parts))
+	}
+	return out
+}
+
+func TestChunkWriterSplitsAndReassembles(t *testing.T) {
+	const limit = 64
+	mem := &memWriter{}
+	w := NewChunkWriter(mem, limit)
+
+	short := "short record"
+	long := strings.Repeat("привет, мир! ", 20)
+	for _, s := range []string{short, long} {
+		if err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := mem.Lines()
+	if lines[0] != short {
+		t.Fatalf("short record changed: %q", lines[0])
+	}
+	if len(lines) < 3 {
+		t.Fatalf("long record not split: %d lines", len(lines))
+	}
+	for _, l := range lines[1:] {
+		if !strings.HasPrefix(l, chunkPrefix) {
+			t.Fatalf("fragment without tag: %q", l)
+		}
+		if len(l)+1 > limit {
+			t.Fatalf("fragment %d bytes exceeds limit %d", len(l)+1, limit)
+		}
+	}
+	got := reassemble(t, lines)
+	if len(got) != 2 || got[0] != short || got[1] != long {
+		t.Fatalf("reassembled %q", got)
+	}
+}
+
+func TestChunkWriterInvalidUTF8(t *testing.T) {
+	const limit = 40
+	mem := &memWriter{}
+	w := NewChunkWriter(mem, limit)
+	payload := bytes.Repeat([]byte{0x80}, 3*limit)
+
+	done := make(chan error, 1)
+	go func() { done <- w.Write(payload) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ChunkWriter hangs on invalid UTF-8")
+	}
+
+	got := reassemble(t, mem.Lines())
+	if len(got) != 1 || got[0] != string(payload) {
+		t.Fatalf("invalid UTF-8 payload not preserved: %q", got)
+	}
+}
+
+func TestParseChunkRejectsPlainLines(t *testing.T) {
+	for _, l := range []string{"plain", "~chunk:id:0:1:x", "~chunk:id:2:1:x", "~chunk:id:1"} {
+		if _, _, _, _, ok := ParseChunk([]byte(l)); ok {
+			t.Errorf("ParseChunk(%q) ok", l)
+		}
+	}
+}
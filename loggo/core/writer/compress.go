@@ -0,0 +1,72 @@
+package writer
+
+import (
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"funchooooza-ossh/loggo/core/compressor"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CompressorFactory создаёт компрессор для сжатия ротированных файлов.
+type CompressorFactory func() core.Compressor
+
+var (
+	compressorsMu sync.RWMutex
+	compressors   = map[Compress]CompressorFactory{
+		Gz: func() core.Compressor { return &compressor.GzipCompressor{} },
+	}
+)
+
+// RegisterCompressor регистрирует способ сжатия, доступный в NewFileWriter по
+// имени (например "zst"). Повторная регистрация заменяет прежнюю; gz
+// зарегистрирован заранее. Регистрировать до создания FileWriter'ов.
+func RegisterCompressor(name Compress, factory CompressorFactory) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[name] = factory
+}
+
+func lookupCompressor(name Compress) (CompressorFactory, bool) {
+	compressorsMu.RLock()
+	defer compressorsMu.RUnlock()
+	factory, ok := compressors[name]
+	return factory, ok
+}
+
+// CompressFromExtension определяет способ сжатия по расширению бэкапов: ищет
+// зарегистрированный компрессор, чей Extension() совпадает с ext (регистр не
+// важен, точка в начале необязательна): ".gz" → Gz, ".zst" → "zst" и т.п.
+// "" → Null. Если расширение совпадает у нескольких компрессоров, берётся
+// тот, чьё имя равно расширению без точки, иначе — первый по имени.
+// Неизвестное расширение — ошибка.
+func CompressFromExtension(ext string) (Compress, error) {
+	if ext == "" {
+		return Null, nil
+	}
+	want := strings.ToLower(ext)
+	if !strings.HasPrefix(want, ".") {
+		want = "." + want
+	}
+
+	compressorsMu.RLock()
+	var names []Compress
+	for name, factory := range compressors {
+		if strings.ToLower(factory().Extension()) == want {
+			names = append(names, name)
+		}
+	}
+	compressorsMu.RUnlock()
+
+	if len(names) == 0 {
+		return Null, fmt.Errorf("unknown backup extension %q: no registered compressor produces it", ext)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	for _, name := range names {
+		if name == Compress(want[1:]) {
+			return name, nil
+		}
+	}
+	return names[0], nil
+}
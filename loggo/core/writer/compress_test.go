@@ -0,0 +1,315 @@
+package writer
+
+import (
+	"compress/gzip"
+	"funchooooza-ossh/loggo/core"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// extCompressor — компрессор-заглушка с заданным расширением.
+type extCompressor struct{ ext string }
+
+func (extCompressor) Compress(src, dst string) error { return nil }
+func (c extCompressor) Extension() string            { return c.ext }
+
+// recordingCompressor запоминает вызовы Compress вместо сжатия.
+type recordingCompressor struct {
+	mu    sync.Mutex
+	calls [][2]string
+}
+
+func (c *recordingCompressor) Compress(src, dst string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, [2]string{src, dst})
+	return nil
+}
+
+func (c *recordingCompressor) Extension() string { return ".fake" }
+
+func TestRegisteredCompressorUsedOnRotate(t *testing.T) {
+	rc := &recordingCompressor{}
+	RegisterCompressor("fake", func() core.Compressor { return rc })
+	t.Cleanup(func() {
+		compressorsMu.Lock()
+		delete(compressors, "fake")
+		compressorsMu.Unlock()
+	})
+
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)}
+	c := Compress("fake")
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 0, RotateDaily, &c, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Write([]byte("before")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	if err := fw.Write([]byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := "/logs/app.log.2024-03-02T01-00-00"
+	if len(rc.calls) != 1 || rc.calls[0] != [2]string{rotated, rotated + ".fake"} {
+		t.Fatalf("Compress calls = %q", rc.calls)
+	}
+	if _, ok := fs.content(rotated); ok {
+		t.Error("source of compressed backup not removed")
+	}
+
+	unknown := Compress("xz")
+	if _, err := NewFileWriterFS("/logs/b.log", 0, 0, "", &unknown, fs, clock.Now); err == nil {
+		t.Error("unregistered compressor accepted")
+	}
+}
+
+func TestCompressFromExtension(t *testing.T) {
+	RegisterCompressor("zst", func() core.Compressor { return extCompressor{".zst"} })
+	// имя не совпадает с расширением — ищется по Extension()
+	RegisterCompressor("brotli", func() core.Compressor { return extCompressor{".br"} })
+	t.Cleanup(func() {
+		compressorsMu.Lock()
+		delete(compressors, "zst")
+		delete(compressors, "brotli")
+		compressorsMu.Unlock()
+	})
+
+	cases := []struct {
+		ext  string
+		want Compress
+	}{
+		{"", Null},
+		{".gz", Gz},
+		{"gz", Gz},
+		{".GZ", Gz},
+		{".zst", "zst"},
+		{".br", "brotli"},
+	}
+	for _, c := range cases {
+		got, err := CompressFromExtension(c.ext)
+		if err != nil || got != c.want {
+			t.Errorf("CompressFromExtension(%q) = %q, %v; want %q", c.ext, got, err, c.want)
+		}
+	}
+
+	for _, ext := range []string{".xz", ".brotli"} {
+		_, err := CompressFromExtension(ext)
+		if err == nil || !strings.Contains(err.Error(), ext) {
+			t.Errorf("CompressFromExtension(%q) error = %v, want unknown extension error", ext, err)
+		}
+	}
+}
+
+func TestNewFileWriterExtOverride(t *testing.T) {
+	if _, err := NewFileWriterExt(t.TempDir()+"/a.log", 1, 1, "", ".xz", nil); err == nil {
+		t.Fatal("unknown extension accepted")
+	}
+	c := Null
+	fw, err := NewFileWriterExt(t.TempDir()+"/a.log", 1, 1, "", ".xz", &c)
+	if err != nil {
+		t.Fatalf("explicit compress must override extension: %v", err)
+	}
+	fw.Close()
+}
+
+// gateCompressor держит каждое сжатие до закрытия release и считает,
+// сколько сжатий шло одновременно.
+type gateCompressor struct {
+	release chan struct{}
+	started chan struct{}
+
+	mu     sync.Mutex
+	active int
+	peak   int
+	done   int
+}
+
+func newGateCompressor() *gateCompressor {
+	return &gateCompressor{release: make(chan struct{}), started: make(chan struct{}, 64)}
+}
+
+func (c *gateCompressor) Compress(src, dst string) error {
+	c.mu.Lock()
+	c.active++
+	if c.active > c.peak {
+		c.peak = c.active
+	}
+	c.mu.Unlock()
+	c.started <- struct{}{}
+
+	<-c.release
+
+	c.mu.Lock()
+	c.active--
+	c.done++
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *gateCompressor) Extension() string { return ".gate" }
+
+// registerGate регистрирует gc под именем "gate" на время теста.
+func registerGate(t *testing.T, gc *gateCompressor) Compress {
+	t.Helper()
+	RegisterCompressor("gate", func() core.Compressor { return gc })
+	t.Cleanup(func() {
+		compressorsMu.Lock()
+		delete(compressors, "gate")
+		compressorsMu.Unlock()
+	})
+	return Compress("gate")
+}
+
+// rotateN пишет запись и переводит часы на сутки вперёд n раз: каждая
+// следующая запись ротирует файл.
+func rotateN(t *testing.T, fw *FileWriter, clock *fakeClock, n int) {
+	t.Helper()
+	for i := 0; i <= n; i++ {
+		if err := fw.Write([]byte("line")); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(25 * time.Hour)
+	}
+}
+
+func TestCompressConcurrencyBounded(t *testing.T) {
+	gc := newGateCompressor()
+	c := registerGate(t, gc)
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 0, RotateDaily, &c, newMemFS(), clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.CompressConcurrency = 2
+
+	const rotations = 6
+	rotateN(t, fw, clock, rotations)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-gc.started:
+		case <-time.After(time.Second):
+			t.Fatalf("only %d compressions started", i)
+		}
+	}
+	select {
+	case <-gc.started:
+		t.Fatal("third compression started while two are running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(gc.release)
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	if gc.done != rotations || gc.peak != 2 {
+		t.Errorf("done = %d, peak = %d; want %d, 2", gc.done, gc.peak, rotations)
+	}
+}
+
+func TestCompressConcurrencyDefault(t *testing.T) {
+	gc := newGateCompressor()
+	c := registerGate(t, gc)
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 0, RotateDaily, &c, newMemFS(), clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotateN(t, fw, clock, 4)
+	time.Sleep(50 * time.Millisecond)
+	close(gc.release)
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gc.peak > DefaultCompressConcurrency || gc.done != 4 {
+		t.Errorf("done = %d, peak = %d", gc.done, gc.peak)
+	}
+}
+
+// Close сразу после ротации: .gz уже полный и распаковывается целиком.
+func TestCloseWaitsForCompression(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	c := Gz
+	fw, err := NewFileWriterFS(path, 0, 0, RotateDaily, &c, nil, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := strings.Repeat("some log line with payload\n", 20000)
+	if err := fw.Write([]byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(25 * time.Hour)
+	if err := fw.Write([]byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, _ := filepath.Glob(path + ".*.gz")
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v", backups)
+	}
+	f, err := os.Open(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("truncated backup: %v", err)
+	}
+	if string(got) != payload+"\n" {
+		t.Errorf("backup holds %d bytes, want %d", len(got), len(payload)+1)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(backups[0], ".gz")); !os.IsNotExist(err) {
+		t.Errorf("uncompressed source left: %v", err)
+	}
+}
+
+func TestCloseCompressWaitTimeout(t *testing.T) {
+	gc := newGateCompressor()
+	c := registerGate(t, gc)
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 0, RotateDaily, &c, newMemFS(), clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.CompressWaitTimeout = 20 * time.Millisecond
+	rotateN(t, fw, clock, 1)
+
+	start := time.Now()
+	err = fw.Close()
+	if err == nil || !strings.Contains(err.Error(), "compression still running") {
+		t.Fatalf("Close error = %v", err)
+	}
+	if el := time.Since(start); el > time.Second {
+		t.Errorf("Close took %s", el)
+	}
+
+	// сжатие продолжается в фоне и завершается после release
+	close(gc.release)
+	fw.compressWG.Wait()
+	if gc.done != 1 {
+		t.Errorf("done = %d", gc.done)
+	}
+}
@@ -0,0 +1,224 @@
+package writer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewDatedFileWriter создаёт writer, который пишет прямо в файлы с датой в
+// имени: pattern "logs/app-%Y-%m-%d.json" даёт logs/app-2025-08-14.json, а с
+// полуночи — новый файл. Поддерживаются %Y, %m, %d, %H и %%; шаблон
+// действует только на имя файла, не на каталог. maxBackups > 0 оставляет столько
+// самых новых файлов по шаблону (по дате, разобранной из имени), кроме активного.
+// maxSizeMB и compress работают как в NewFileWriter — для самого датированного файла.
+func NewDatedFileWriter(pattern string, maxSizeMB int64, maxBackups int, compress *Compress) (*FileWriter, error) {
+	return NewDatedFileWriterFS(pattern, maxSizeMB, maxBackups, compress, nil, nil)
+}
+
+// NewDatedFileWriterFS — как NewDatedFileWriter, но с подменяемыми файловой
+// системой и часами (nil — os и time.Now), см. NewFileWriterFS.
+func NewDatedFileWriterFS(pattern string, maxSizeMB int64, maxBackups int, compress *Compress, fsys FileSystem, now func() time.Time) (*FileWriter, error) {
+	if strings.Contains(filepath.Dir(pattern), "%") {
+		return nil, fmt.Errorf("date pattern %q: directory must not contain date tokens", pattern)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	t := now()
+	fw, err := newFileWriter(formatDatePattern(pattern, t), maxSizeMB, maxBackups, "", compress, fsys, now)
+	if err != nil {
+		return nil, err
+	}
+	fw.datePattern = pattern
+	fw.nextDateCheck = nextHour(t)
+	return fw, nil
+}
+
+// formatDatePattern подставляет дату t в strftime-подобный шаблон.
+func formatDatePattern(pattern string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i+1 == len(pattern) {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case 'Y':
+			b.WriteString(strconv.Itoa(t.Year()))
+		case 'm':
+			writeTwoDigits(&b, int(t.Month()))
+		case 'd':
+			writeTwoDigits(&b, t.Day())
+		case 'H':
+			writeTwoDigits(&b, t.Hour())
+		case '%':
+			b.WriteByte('%')
+		default:
+			// неизвестный токен — как есть
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
+
+func writeTwoDigits(b *strings.Builder, n int) {
+	b.WriteByte(byte('0' + n/10))
+	b.WriteByte(byte('0' + n%10))
+}
+
+// datePatternGlob — маска filepath.Match для имён файлов по шаблону (токены — *).
+func datePatternGlob(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '%' && i+1 < len(pattern) {
+			i++
+			switch pattern[i] {
+			case 'Y', 'm', 'd', 'H':
+				b.WriteByte('*')
+			case '%':
+				b.WriteByte('%')
+			default:
+				b.WriteByte('%')
+				b.WriteByte(pattern[i])
+			}
+			continue
+		}
+		if strings.IndexByte(`*?[\`, c) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// parseDatePattern разбирает дату из имени файла name по шаблону pattern (без
+// каталога); rest — остаток имени после шаблона. Недостающие поля — минимальные.
+func parseDatePattern(pattern, name string) (t time.Time, rest string, ok bool) {
+	year, month, day, hour := 1, 1, 1, 0
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '%' && i+1 < len(pattern) {
+			i++
+			var width int
+			var dst *int
+			switch pattern[i] {
+			case 'Y':
+				width, dst = 4, &year
+			case 'm':
+				width, dst = 2, &month
+			case 'd':
+				width, dst = 2, &day
+			case 'H':
+				width, dst = 2, &hour
+			case '%':
+				if !strings.HasPrefix(name, "%") {
+					return time.Time{}, "", false
+				}
+				name = name[1:]
+				continue
+			default:
+				if !strings.HasPrefix(name, pattern[i-1:i+1]) {
+					return time.Time{}, "", false
+				}
+				name = name[2:]
+				continue
+			}
+			if len(name) < width {
+				return time.Time{}, "", false
+			}
+			n, err := strconv.Atoi(name[:width])
+			if err != nil || n < 0 {
+				return time.Time{}, "", false
+			}
+			*dst = n
+			name = name[width:]
+			continue
+		}
+		if name == "" || name[0] != c {
+			return time.Time{}, "", false
+		}
+		name = name[1:]
+	}
+	return time.Date(year, time.Month(month), day, hour, 0, 0, 0, time.UTC), name, true
+}
+
+// nextHour — начало следующего часа: самый мелкий токен шаблона — %H, так что
+// имя файла может смениться только на границе часа.
+func nextHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+}
+
+// rollDate переключает запись на новый датированный файл, если дата в имени
+// сменилась. Вызывать под mu.
+func (fw *FileWriter) rollDate(now time.Time) error {
+	if now.Before(fw.nextDateCheck) {
+		return nil
+	}
+	fw.nextDateCheck = nextHour(now)
+	name := formatDatePattern(fw.datePattern, now)
+	if name == fw.path {
+		return nil
+	}
+
+	_ = fw.closeActive()
+	if fw.Index {
+		_ = fw.writeIndex(fw.path)
+		fw.index = fileIndex{}
+	}
+	fw.path = name
+	if err := fw.openActive(); err != nil {
+		return err
+	}
+	fw.cleanupBackups()
+	return nil
+}
+
+// cleanupDated оставляет maxBackups самых новых файлов по шаблону (вместе с их
+// бэкапами по размеру), не считая активного.
+func (fw *FileWriter) cleanupDated() {
+	dir := filepath.Dir(fw.datePattern)
+	glob := datePatternGlob(filepath.Base(fw.datePattern)) + "*"
+
+	names, err := fw.fs.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var files []backup
+	for _, name := range names {
+		if ok, _ := filepath.Match(glob, name); !ok ||
+			name == filepath.Base(fw.activePath()) || strings.HasSuffix(name, indexExt) {
+			continue
+		}
+		at, rest, ok := parseDatePattern(filepath.Base(fw.datePattern), name)
+		if !ok {
+			continue
+		}
+		f := backup{path: filepath.Join(dir, name), at: at}
+		// ".2025-08-14T10-00-00[.gz]" — бэкап по размеру; ".gz" и "" — сам файл
+		f.sub, f.rotated = backupTime(rest, ".")
+		files = append(files, f)
+	}
+	removeOldest(files, fw.maxBackups, func(f string) {
+		_ = fw.fs.Remove(f)
+		if fw.Index {
+			_ = fw.fs.Remove(fw.datedIndexPath(f))
+		}
+	})
+}
+
+// datedIndexPath — сайдкар .idx для файла f: без расширения сжатия.
+func (fw *FileWriter) datedIndexPath(f string) string {
+	if fw.compressor != nil {
+		f = strings.TrimSuffix(f, fw.compressor.Extension())
+	}
+	if fw.compress == GzInline {
+		f = strings.TrimSuffix(f, ".gz")
+	}
+	return f + indexExt
+}
@@ -0,0 +1,114 @@
+package writer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDatedFileWriterRollsAtMidnight(t *testing.T) {
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2025, 8, 14, 23, 30, 0, 0, time.UTC)}
+	fw, err := NewDatedFileWriterFS("/logs/app-%Y-%m-%d.json", 0, 0, nil, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Write([]byte("day1")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if err := fw.Write([]byte("day2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.content("/logs/app-2025-08-14.json"); got != "day1\n" {
+		t.Errorf("day 1 file %q", got)
+	}
+	if got, _ := fs.content("/logs/app-2025-08-15.json"); got != "day2\n" {
+		t.Errorf("day 2 file %q", got)
+	}
+}
+
+func TestDatedCleanupKeepsNewestByDate(t *testing.T) {
+	fs := newMemFS()
+	// по имени "31-12-2024" новее "02-01-2025" — по дате наоборот
+	for _, name := range []string{
+		"/logs/app-31-12-2024.json",
+		"/logs/app-01-01-2025.json",
+		"/logs/app-01-01-2025.json.2025-01-01T12-00-00",
+		"/logs/app-xx-yy-zzzz.json", // не по шаблону — не трогаем
+	} {
+		if _, err := fs.OpenFile(name, os.O_CREATE, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock := &fakeClock{now: time.Date(2025, 1, 2, 23, 30, 0, 0, time.UTC)}
+	fw, err := NewDatedFileWriterFS("/logs/app-%d-%m-%Y.json", 0, 2, nil, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if err := fw.Write([]byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{
+		"/logs/app-03-01-2025.json":                     true, // активный
+		"/logs/app-02-01-2025.json":                     true,
+		"/logs/app-01-01-2025.json":                     true, // файл дня новее своего бэкапа
+		"/logs/app-01-01-2025.json.2025-01-01T12-00-00": false,
+		"/logs/app-31-12-2024.json":                     false,
+		"/logs/app-xx-yy-zzzz.json":                     true,
+	} {
+		if _, ok := fs.content(name); ok != want {
+			t.Errorf("%s exists = %v, want %v", name, ok, want)
+		}
+	}
+}
+
+func TestCleanupBackupsByRotationTime(t *testing.T) {
+	fs := newMemFS()
+	for _, name := range []string{
+		"/logs/app.log.2024-03-01T10-00-00.gz",
+		"/logs/app.log.2024-03-01T09-00-00",
+		"/logs/app.log.old", // не бэкап ротации
+	} {
+		if _, err := fs.OpenFile(name, os.O_CREATE, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)}
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 2, RotateDaily, nil, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	if err := fw.Write([]byte("y")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]bool{
+		"/logs/app.log.2024-03-02T01-00-00":    true,
+		"/logs/app.log.2024-03-01T10-00-00.gz": true,
+		"/logs/app.log.2024-03-01T09-00-00":    false,
+		"/logs/app.log.old":                    true,
+	} {
+		if _, ok := fs.content(name); ok != want {
+			t.Errorf("%s exists = %v, want %v", name, ok, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"sync"
+	"time"
+)
+
+// DedupWriter схлопывает подряд идущие одинаковые записи, как syslog:
+// повторы не пишутся, вместо них выводится "last message repeated N times" —
+// при появлении другой строки, по таймеру или при Flush.
+type DedupWriter struct {
+	next     core.WriteProcessor
+	interval time.Duration
+
+	mu       sync.Mutex
+	last     []byte
+	repeated int
+	timer    *time.Timer
+}
+
+// NewDedupWriter оборачивает writer. interval > 0 — не дольше этого времени
+// держать счётчик повторов до вывода сводки; 0 — только по новой строке/Flush.
+func NewDedupWriter(next core.WriteProcessor, interval time.Duration) *DedupWriter {
+	return &DedupWriter{next: next, interval: interval}
+}
+
+func (w *DedupWriter) Write(p []byte) error {
+	return w.write(p, nil)
+}
+
+// WriteRecord сохраняет запись для вложенного record-aware writer'а.
+func (w *DedupWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	return w.write(formatted, &r)
+}
+
+func (w *DedupWriter) write(p []byte, r *core.LogRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.last != nil && bytes.Equal(p, w.last) {
+		w.repeated++
+		if w.repeated == 1 && w.interval > 0 {
+			w.timer = time.AfterFunc(w.interval, w.onTimer)
+		}
+		return nil
+	}
+
+	if err := w.flushRepeated(); err != nil {
+		return err
+	}
+	w.last = append(w.last[:0], p...)
+	if r != nil {
+		return writeRecordTo(w.next, *r, p)
+	}
+	return w.next.Write(p)
+}
+
+// Flush выводит сводку о повторах и сбрасывает вложенный writer.
+func (w *DedupWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flushRepeated(); err != nil {
+		return err
+	}
+	if f, ok := w.next.(core.FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (w *DedupWriter) onTimer() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_ = w.flushRepeated()
+}
+
+// flushRepeated пишет сводку, если были повторы. Вызывать под mu.
+func (w *DedupWriter) flushRepeated() error {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.repeated == 0 {
+		return nil
+	}
+	n := w.repeated
+	w.repeated = 0
+	return w.next.Write([]byte(fmt.Sprintf("last message repeated %d times", n)))
+}
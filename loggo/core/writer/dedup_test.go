@@ -0,0 +1,52 @@
+package writer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDedupWriterCollapsesRun(t *testing.T) {
+	mem := &memWriter{}
+	w := NewDedupWriter(mem, 0)
+	for _, line := range []string{"a", "b", "b", "b", "c", "c", "a"} {
+		if err := w.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"a", "b", "last message repeated 2 times",
+		"c", "last message repeated 1 times", "a",
+	}
+	if got := mem.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestDedupWriterTimerFlushesSummary(t *testing.T) {
+	mem := &memWriter{}
+	w := NewDedupWriter(mem, 20*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if err := w.Write([]byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(mem.Lines()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	want := []string{"x", "last message repeated 2 times"}
+	if got := mem.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// Flush после таймера не повторяет сводку
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mem.Lines(); len(got) != 2 {
+		t.Errorf("after Flush: %q", got)
+	}
+}
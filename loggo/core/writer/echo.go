@@ -0,0 +1,60 @@
+package writer
+
+import (
+	"errors"
+	"funchooooza-ossh/loggo/core"
+	"io"
+)
+
+// EchoWriter пишет всё в основной writer и дублирует записи уровня >= min в
+// echo — например, всё идёт в файл, а предупреждения и ошибки ещё и в консоль.
+// Уровень известен только через WriteRecord; обычный Write не дублируется.
+type EchoWriter struct {
+	next core.WriteProcessor
+	echo core.WriteProcessor
+	min  core.LogLevel
+}
+
+// NewEchoWriter оборачивает next копированием записей уровня >= min в echo.
+func NewEchoWriter(next, echo core.WriteProcessor, min core.LogLevel) *EchoWriter {
+	return &EchoWriter{next: next, echo: echo, min: min}
+}
+
+// WithStderrEcho — NewEchoWriter с копией в stderr.
+func WithStderrEcho(w core.WriteProcessor, minLevel core.LogLevel) *EchoWriter {
+	return NewEchoWriter(w, NewStderrWriter(), minLevel)
+}
+
+func (w *EchoWriter) Write(p []byte) error {
+	return w.next.Write(p)
+}
+
+func (w *EchoWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	err := writeRecordTo(w.next, r, formatted)
+	if r.Level >= w.min {
+		// ошибка копии не отменяет запись в основной writer
+		err = errors.Join(err, writeRecordTo(w.echo, r, formatted))
+	}
+	return err
+}
+
+func (w *EchoWriter) Flush() error {
+	var errs []error
+	for _, t := range []core.WriteProcessor{w.next, w.echo} {
+		if f, ok := t.(core.FlushableWriter); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close закрывает основной writer и копию, если они это умеют.
+func (w *EchoWriter) Close() error {
+	var errs []error
+	for _, t := range []core.WriteProcessor{w.next, w.echo} {
+		if c, ok := t.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,41 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"testing"
+)
+
+func TestEchoWriterCopiesHighLevels(t *testing.T) {
+	primary, echo := &memWriter{}, &recordWriter{}
+	w := NewEchoWriter(primary, echo, core.Warning)
+
+	if err := w.WriteRecord(core.LogRecord{Level: core.Info}, []byte("info")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRecord(core.LogRecord{Level: core.Error}, []byte("error")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := primary.Lines(); len(got) != 2 || got[0] != "info" || got[1] != "error" {
+		t.Errorf("primary %q", got)
+	}
+	if got := echo.Lines(); len(got) != 1 || got[0] != "error" {
+		t.Errorf("echo %q", got)
+	}
+	if len(echo.records) != 1 || echo.records[0].Level != core.Error {
+		t.Errorf("echo records %v", echo.records)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if primary.flushes != 1 || echo.flushes != 1 {
+		t.Errorf("flushes: primary %d, echo %d", primary.flushes, echo.flushes)
+	}
+	if !primary.closed || !echo.closed {
+		t.Errorf("closed: primary %v, echo %v", primary.closed, echo.closed)
+	}
+}
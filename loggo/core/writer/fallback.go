@@ -0,0 +1,74 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"io"
+)
+
+// FallbackWriter пишет в основной writer, а если тот вернул ошибку — в
+// запасной (например, файл, а при сбое — stderr), чтобы запись не потерялась
+// молча. Ошибка основного отдаётся в OnError; наружу возвращается ошибка,
+// только если запись не удалась и в запасной.
+type FallbackWriter struct {
+	// OnError получает ошибки основного writer'а, после которых запись ушла в
+	// запасной. Вызывается из воркера роута.
+	OnError func(err error)
+
+	primary   core.WriteProcessor
+	secondary core.WriteProcessor
+}
+
+// NewFallbackWriter создаёт FallbackWriter поверх primary с запасным secondary.
+func NewFallbackWriter(primary, secondary core.WriteProcessor) *FallbackWriter {
+	return &FallbackWriter{primary: primary, secondary: secondary}
+}
+
+func (w *FallbackWriter) Write(p []byte) error {
+	err := w.primary.Write(p)
+	if err == nil {
+		return nil
+	}
+	return w.fallback(err, func(next core.WriteProcessor) error { return next.Write(p) })
+}
+
+func (w *FallbackWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	err := writeRecordTo(w.primary, r, formatted)
+	if err == nil {
+		return nil
+	}
+	return w.fallback(err, func(next core.WriteProcessor) error { return writeRecordTo(next, r, formatted) })
+}
+
+func (w *FallbackWriter) fallback(primaryErr error, write func(core.WriteProcessor) error) error {
+	if err := write(w.secondary); err != nil {
+		return errors.Join(primaryErr, fmt.Errorf("fallback writer: %w", err))
+	}
+	if w.OnError != nil {
+		w.OnError(primaryErr)
+	}
+	return nil
+}
+
+// Flush сбрасывает оба writer'а.
+func (w *FallbackWriter) Flush() error {
+	var errs []error
+	for _, t := range []core.WriteProcessor{w.primary, w.secondary} {
+		if f, ok := t.(core.FlushableWriter); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close закрывает оба writer'а, если они это умеют.
+func (w *FallbackWriter) Close() error {
+	var errs []error
+	for _, t := range []core.WriteProcessor{w.primary, w.secondary} {
+		if c, ok := t.(io.Closer); ok {
+			errs = append(errs, c.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
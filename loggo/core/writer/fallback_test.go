@@ -0,0 +1,73 @@
+package writer
+
+import (
+	"errors"
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"testing"
+)
+
+func TestFallbackWriterRoutesOnPrimaryError(t *testing.T) {
+	boom := errors.New("disk full")
+	primary := &memWriter{}
+	secondary := &recordWriter{}
+	w := NewFallbackWriter(primary, secondary)
+	var reported []error
+	w.OnError = func(err error) { reported = append(reported, err) }
+
+	if err := w.Write([]byte("ok")); err != nil {
+		t.Fatal(err)
+	}
+	primary.err = boom
+	if err := w.Write([]byte("lost?")); err != nil {
+		t.Fatalf("Write = %v, want nil after fallback", err)
+	}
+	r := core.LogRecord{Level: core.Error, Message: "rec"}
+	if err := w.WriteRecord(r, []byte("rec")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := primary.Lines(); !reflect.DeepEqual(got, []string{"ok"}) {
+		t.Errorf("primary = %q", got)
+	}
+	if got := secondary.Lines(); !reflect.DeepEqual(got, []string{"lost?", "rec"}) {
+		t.Errorf("secondary = %q", got)
+	}
+	if len(secondary.records) != 1 || secondary.records[0].Message != "rec" {
+		t.Errorf("secondary records = %v", secondary.records)
+	}
+	if len(reported) != 2 || !errors.Is(reported[0], boom) {
+		t.Errorf("OnError got %v", reported)
+	}
+}
+
+func TestFallbackWriterBothFail(t *testing.T) {
+	primaryErr, secondaryErr := errors.New("primary"), errors.New("secondary")
+	w := NewFallbackWriter(&memWriter{err: primaryErr}, &memWriter{err: secondaryErr})
+	called := false
+	w.OnError = func(error) { called = true }
+
+	err := w.Write([]byte("x"))
+	if !errors.Is(err, primaryErr) || !errors.Is(err, secondaryErr) {
+		t.Errorf("Write = %v, want both errors", err)
+	}
+	if called {
+		t.Error("OnError called although the record was lost")
+	}
+}
+
+func TestFallbackWriterFlushCloseBoth(t *testing.T) {
+	primary, secondary := &memWriter{}, &memWriter{}
+	w := NewFallbackWriter(primary, secondary)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for name, m := range map[string]*memWriter{"primary": primary, "secondary": secondary} {
+		if m.flushes != 1 || !m.closed {
+			t.Errorf("%s: flushes = %d, closed = %v", name, m.flushes, m.closed)
+		}
+	}
+}
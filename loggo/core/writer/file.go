@@ -2,9 +2,10 @@ package writer
 
 import (
 	"bufio"
+	"compress/gzip"
 	"fmt"
 	"funchooooza-ossh/loggo/core"
-	"funchooooza-ossh/loggo/core/compressor"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -26,9 +27,36 @@ type Compress string
 const (
 	Gz   Compress = "gz"
 	Null Compress = ""
+	// GzInline пишет активный файл сразу как gzip-поток (<path>.gz), без сжатия
+	// после ротации и без временного двойного расхода диска. maxSizeMB в этом
+	// режиме, как и в остальных, — размер файла на диске, то есть сжатых данных;
+	// файл может превысить его на содержимое буферов bufio и gzip.
+	GzInline Compress = "gz-inline"
 )
 
+// DefaultCompressConcurrency — одновременных сжатий ротированных файлов по умолчанию.
+const DefaultCompressConcurrency = 2
+
 type FileWriter struct {
+	// Index включает индекс: при ротации рядом с файлом пишется сайдкар
+	// <файл>.idx с диапазоном времени записей и их количеством (JSON). Время
+	// известно только для записей через WriteRecord. Задавать до первой записи.
+	Index bool
+	// Header — строка, которая пишется первой в каждый новый файл (в том числе
+	// после ротации), например `{"_meta":"lightloggo","encoding":"utf-8"}`.
+	// При дописывании в непустой существующий файл не пишется. Задавать до
+	// первой записи.
+	Header []byte
+	// CompressConcurrency — сколько ротированных файлов сжимается одновременно
+	// (0 — DefaultCompressConcurrency). Остальные ждут в очереди, так что частая
+	// ротация не забивает CPU и диск. Задавать до первой записи.
+	CompressConcurrency int
+	// CompressWaitTimeout ограничивает ожидание фоновых сжатий в Close (0 —
+	// ждать до конца). По истечении Close возвращает ошибку, а сжатие
+	// продолжается в фоне — бэкап может остаться недописанным, если процесс
+	// сразу завершится.
+	CompressWaitTimeout time.Duration
+
 	path       string
 	maxSizeMB  int64
 	maxBackups int
@@ -36,88 +64,331 @@ type FileWriter struct {
 	compress   Compress
 	compressor core.Compressor
 
+	fs  FileSystem
+	now func() time.Time
+
 	mu     sync.Mutex
-	file   *os.File
+	file   File
+	gz     *gzip.Writer // только в режиме GzInline
 	writer *bufio.Writer
-	size   int64
+	size   int64     // байт в активном файле на диске (при GzInline — дошедших до файла)
+	fresh  bool      // в активный файл ещё ничего не писалось: нужен Header
+	index  fileIndex // записи активного файла, при Index
 
 	rotateInterval RotateInterval
 	nextRotateTime time.Time
+
+	compressSem chan struct{}  // слоты CompressConcurrency, создаётся при первой ротации
+	compressWG  sync.WaitGroup // фоновые сжатия; Close дожидается их
+
+	// datePattern — режим NewDatedFileWriter: path выводится из шаблона и
+	// меняется со сменой даты; проверка — не раньше nextDateCheck
+	datePattern   string
+	nextDateCheck time.Time
 }
 
 // NewFileWriter создаёт новый лог-файл с опциями ротации и сжатия.
 func NewFileWriter(path string, maxSizeMB int64, maxBackups int, interval RotateInterval, compress *Compress) (*FileWriter, error) {
+	return NewFileWriterFS(path, maxSizeMB, maxBackups, interval, compress, nil, nil)
+}
+
+// NewFileWriterFS — как NewFileWriter, но с подменяемыми файловой системой и
+// часами (nil — os и time.Now): для детерминированных тестов ротации. Сжатие
+// ротированных файлов компрессором работает только с настоящей ФС.
+func NewFileWriterFS(path string, maxSizeMB int64, maxBackups int, interval RotateInterval, compress *Compress, fsys FileSystem, now func() time.Time) (*FileWriter, error) {
+	return newFileWriter(path, maxSizeMB, maxBackups, interval, compress, fsys, now)
+}
+
+func newFileWriter(path string, maxSizeMB int64, maxBackups int, interval RotateInterval, compress *Compress, fsys FileSystem, now func() time.Time) (*FileWriter, error) {
+	if fsys == nil {
+		fsys = osFS{}
+	}
+	if now == nil {
+		now = time.Now
+	}
+
 	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := fsys.MkdirAll(dir, 0755); err != nil {
 		return nil, err
 	}
 
 	var comp core.Compressor
-	compressVal := ""
-
-	if compress != nil {
-		switch *compress {
-		case Gz:
-			compressVal = "gz"
-			comp = &compressor.GzipCompressor{}
-		// можно добавить другие варианты позже
-		default:
-			return nil, fmt.Errorf("unsupported compression: %s", *compress)
+	compressVal := Null
+
+	if compress != nil && *compress != Null {
+		compressVal = *compress
+		if *compress != GzInline {
+			factory, ok := lookupCompressor(*compress)
+			if !ok {
+				return nil, fmt.Errorf("unsupported compression: %s", *compress)
+			}
+			comp = factory()
 		}
 	}
 
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
+	fw := &FileWriter{
+		path:           path,
+		maxSizeMB:      maxSizeMB,
+		maxBackups:     maxBackups,
+		compress:       compressVal,
+		compressor:     comp,
+		fs:             fsys,
+		now:            now,
+		rotateInterval: interval,
+		nextRotateTime: nextRotation(now(), interval),
+	}
+	if err := fw.openActive(); err != nil {
 		return nil, err
 	}
+	return fw, nil
+}
+
+// activePath — путь текущего файла: в режиме GzInline к нему добавляется ".gz".
+func (fw *FileWriter) activePath() string {
+	if fw.compress == GzInline {
+		return fw.path + ".gz"
+	}
+	return fw.path
+}
 
-	info, statErr := f.Stat()
-	if statErr != nil {
+// openActive открывает (или создаёт) активный файл и собирает цепочку writer'ов:
+// bufio → [gzip] → файл.
+func (fw *FileWriter) openActive() error {
+	f, err := fw.fs.OpenFile(fw.activePath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
 		f.Close()
-		return nil, statErr
+		return err
 	}
 
-	now := time.Now()
-	return &FileWriter{
-		path:           path,
-		maxSizeMB:      maxSizeMB,
-		maxBackups:     maxBackups,
-		compress:       Compress(compressVal),
-		compressor:     comp,
-		file:           f,
-		writer:         bufio.NewWriter(f),
-		size:           info.Size(),
-		rotateInterval: interval,
-		nextRotateTime: nextRotation(now, interval),
-	}, nil
+	fw.file = f
+	fw.size = info.Size()
+	fw.fresh = fw.size == 0
+	if fw.compress == GzInline {
+		// дописывание в существующий .gz даёт многочленный поток — он валиден;
+		// size растёт по мере того, как gzip отдаёт сжатые байты файлу
+		fw.gz = gzip.NewWriter(countingWriter{w: f, n: &fw.size})
+		fw.writer = bufio.NewWriter(fw.gz)
+	} else {
+		fw.gz = nil
+		fw.writer = bufio.NewWriter(f)
+	}
+	return nil
+}
+
+// closeActive сбрасывает буферы, финализирует gzip-поток и закрывает файл.
+func (fw *FileWriter) closeActive() error {
+	err := fw.writer.Flush()
+	if fw.gz != nil {
+		if cerr := fw.gz.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if cerr := fw.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 func (fw *FileWriter) Write(p []byte) error {
+	return fw.write(p, time.Time{})
+}
+
+func (fw *FileWriter) write(p []byte, ts time.Time) error {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
 
-	if fw.shouldRotateByTime(time.Now()) || fw.shouldRotateBySize(len(p)) {
-		if err := fw.rotate(); err != nil {
-			return err
+	now := fw.now()
+	if fw.datePattern != "" {
+		if err := fw.rollDate(now); err != nil {
+			return &WriteError{Data: p, Err: err}
+		}
+	}
+	if fw.shouldRotateByTime(now) || fw.shouldRotateBySize(len(p)) {
+		if lost, err := fw.rotate(); err != nil {
+			return &WriteError{Data: p, Lost: lost, Err: err}
 		}
 	}
 
+	if len(fw.Header) > 0 && fw.fresh {
+		// файл новый: заголовок идёт перед первой записью
+		n, err := fw.writer.Write(append(fw.Header[:len(fw.Header):len(fw.Header)], '\n'))
+		if err != nil {
+			return &WriteError{Data: p, Lost: fw.recoverWrite(n), Err: err}
+		}
+		fw.written(n)
+	}
+
 	n, err := fw.writer.Write(append(p, '\n'))
-	fw.size += int64(n)
-	return err
+	if err != nil {
+		return &WriteError{Data: p, Lost: fw.recoverWrite(n), Err: err}
+	}
+	fw.written(n)
+	if fw.Index {
+		fw.index.observe(ts)
+	}
+	return nil
+}
+
+// written учитывает n байт, принятых bufio. При GzInline size считает
+// countingWriter под gzip: размер сжатых данных заранее неизвестен.
+func (fw *FileWriter) written(n int) {
+	fw.fresh = false
+	if fw.gz == nil {
+		fw.size += int64(n)
+	}
+}
+
+// countingWriter прибавляет к *n число байт, записанных в w.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// WriteError — запись не попала в файл; Data содержит её целиком для повтора.
+// Lost — сколько байт более ранних записей пропало из буфера вместе с ней:
+// Write для них уже вернул nil, и повторить их нельзя.
+type WriteError struct {
+	Data []byte
+	Lost int
+	Err  error
+}
+
+func (e *WriteError) Error() string {
+	if e.Lost > 0 {
+		return fmt.Sprintf("file writer: record of %d bytes lost, with %d buffered bytes of earlier records: %v",
+			len(e.Data), e.Lost, e.Err)
+	}
+	return fmt.Sprintf("file writer: record of %d bytes lost: %v", len(e.Data), e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// recoverWrite сбрасывает «залипшую» ошибку bufio.Writer и сверяет size с
+// реальным размером файла: частично записанный буфер не должен искажать учёт.
+// accepted — сколько байт текущей записи bufio успел принять. Недописанный
+// остаток буфера при этом теряется (bufio не отдаёт его после ошибки);
+// возвращается, сколько из него приходится на более ранние записи.
+func (fw *FileWriter) recoverWrite(accepted int) int {
+	// неотправленный хвост буфера: ранние записи, затем принятая часть текущей
+	lost := max(0, fw.writer.Buffered()-accepted)
+	if fw.gz != nil {
+		fw.writer.Reset(fw.gz)
+	} else {
+		fw.writer.Reset(fw.file)
+	}
+	if info, err := fw.file.Stat(); err == nil {
+		fw.size = info.Size()
+	}
+	return lost
 }
 
 func (fw *FileWriter) Flush() error {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
-	return fw.writer.Flush()
+	return fw.flushBuffers()
 }
 
-func (fw *FileWriter) Close() error {
+// flushBuffers отдаёт файлу буфер bufio и, при GzInline, данные gzip. Вызывать под mu.
+func (fw *FileWriter) flushBuffers() error {
+	if err := fw.writer.Flush(); err != nil {
+		return err
+	}
+	if fw.gz != nil {
+		// sync flush: всё записанное можно распаковать, но поток ещё не закрыт
+		return fw.gz.Flush()
+	}
+	return nil
+}
+
+// Sync сбрасывает буферы и вызывает fsync активного файла.
+func (fw *FileWriter) Sync() error {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
-	_ = fw.writer.Flush()
-	return fw.file.Close()
+	if err := fw.flushBuffers(); err != nil {
+		return err
+	}
+	return fw.file.Sync()
+}
+
+// Close закрывает активный файл и дожидается фонового сжатия ротированных.
+func (fw *FileWriter) Close() error {
+	fw.mu.Lock()
+	err := fw.closeActive()
+	fw.mu.Unlock()
+
+	if werr := fw.waitCompress(); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// waitCompress дожидается фоновых сжатий с учётом CompressWaitTimeout.
+func (fw *FileWriter) waitCompress() error {
+	if fw.CompressWaitTimeout <= 0 {
+		fw.compressWG.Wait()
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		fw.compressWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(fw.CompressWaitTimeout):
+		return fmt.Errorf("file writer: compression still running after %s", fw.CompressWaitTimeout)
+	}
+}
+
+// compressAsync сжимает ротированный файл в фоне, не более CompressConcurrency
+// одновременно. Вызывать под mu.
+func (fw *FileWriter) compressAsync(src string) {
+	if fw.compressSem == nil {
+		n := fw.CompressConcurrency
+		if n <= 0 {
+			n = DefaultCompressConcurrency
+		}
+		fw.compressSem = make(chan struct{}, n)
+	}
+	sem := fw.compressSem
+
+	fw.compressWG.Add(1)
+	go func() {
+		defer fw.compressWG.Done()
+		sem <- struct{}{}
+		defer func() { <-sem }()
+
+		dst := src + fw.compressor.Extension()
+		_ = fw.compressor.Compress(src, dst)
+		_ = fw.fs.Remove(src)
+	}()
+}
+
+// NewFileWriterExt — как NewFileWriter, но способ сжатия выводится из
+// расширения бэкапов backupExt (".gz", ".zst", "" — без сжатия).
+// compress != nil переопределяет выведенное значение.
+func NewFileWriterExt(path string, maxSizeMB int64, maxBackups int, interval RotateInterval, backupExt string, compress *Compress) (*FileWriter, error) {
+	if compress == nil {
+		c, err := CompressFromExtension(backupExt)
+		if err != nil {
+			return nil, err
+		}
+		compress = &c
+	}
+	return NewFileWriter(path, maxSizeMB, maxBackups, interval, compress)
 }
 
 // --- rotation logic ---
@@ -146,75 +417,144 @@ func (fw *FileWriter) shouldRotateByTime(now time.Time) bool {
 	return now.After(fw.nextRotateTime)
 }
 
+// shouldRotateBySize сообщает, что запись длиной incoming не поместится в
+// maxSizeMB. При GzInline её сжатый размер неизвестен, поэтому ротация — когда
+// лимит уже достигнут.
 func (fw *FileWriter) shouldRotateBySize(incoming int) bool {
+	if fw.gz != nil {
+		incoming = 0
+	}
 	return fw.maxSizeMB > 0 && fw.size+int64(incoming) > fw.maxSizeMB*1024*1024
 }
 
-func (fw *FileWriter) rotate() error {
-	fw.writer.Flush()
-	fw.file.Close()
+// rotate переименовывает активный файл в бэкап и открывает новый. Возвращает,
+// сколько байт ранних записей пропало из буфера, если сбросить его не удалось.
+func (fw *FileWriter) rotate() (int, error) {
+	// буфер — до закрытия: если он не ушёл на диск, файл остаётся активным (не
+	// переименовывается и не переоткрывается), ротация повторится со следующей
+	// записью, а пропавшие из буфера байты ранних записей отдаются в lost
+	if err := fw.flushBuffers(); err != nil {
+		return fw.recoverWrite(0), err
+	}
+	// данные уже в файле; ошибку закрытия (хвост gzip, close) отдаём после ротации
+	closeErr := fw.closeActive()
 
-	timestamp := time.Now().Format("2006-01-02T15-04-05")
-	rotatedName := fw.path + "." + timestamp
-	if err := os.Rename(fw.path, rotatedName); err != nil {
-		return err
+	now := fw.now()
+	if fw.rotateInterval != "" {
+		fw.nextRotateTime = nextRotation(now, fw.rotateInterval)
+	}
+	timestamp := now.Format(backupTimeLayout)
+	stem := fw.path + "." + timestamp
+	rotatedName := stem
+	if fw.compress == GzInline {
+		rotatedName += ".gz"
+	}
+	if err := fw.fs.Rename(fw.activePath(), rotatedName); err != nil {
+		return 0, err
+	}
+	if fw.Index {
+		_ = fw.writeIndex(stem)
+		fw.index = fileIndex{}
 	}
 
 	if fw.compressor != nil {
-		go func(src string) {
-			dst := src + fw.compressor.Extension()
-			_ = fw.compressor.Compress(src, dst)
-			_ = os.Remove(src)
-		}(rotatedName)
+		fw.compressAsync(rotatedName)
 	}
 
-	f, err := os.OpenFile(fw.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return err
+	if err := fw.openActive(); err != nil {
+		return 0, err
 	}
-	fw.file = f
-	fw.writer = bufio.NewWriter(f)
-	fw.size = 0
 
 	fw.cleanupBackups()
 
-	return nil
+	return 0, closeErr
 }
 
 func (fw *FileWriter) cleanupBackups() {
 	if fw.maxBackups <= 0 {
 		return
 	}
+	if fw.datePattern != "" {
+		fw.cleanupDated()
+		return
+	}
 
 	dir := filepath.Dir(fw.path)
 	prefix := filepath.Base(fw.path) + "."
 
-	files, err := os.ReadDir(dir)
+	names, err := fw.fs.ReadDir(dir)
 	if err != nil {
 		return
 	}
 
-	var backups []string
+	var backups []backup
 
-	for _, f := range files {
-		name := f.Name()
+	for _, name := range names {
 
-		// Ищем только те, что начинаются с basename+"."
-		if strings.HasPrefix(name, prefix) {
-			fullPath := filepath.Join(dir, name)
-			backups = append(backups, fullPath)
+		// Ищем только те, что начинаются с basename+"." (активный .gz и индексы — не бэкапы)
+		if strings.HasPrefix(name, prefix) && name != filepath.Base(fw.activePath()) &&
+			!strings.HasSuffix(name, indexExt) {
+			// чужие файлы с тем же префиксом, но без времени ротации, не трогаем
+			at, ok := backupTime(name, prefix)
+			if !ok {
+				continue
+			}
+			backups = append(backups, backup{path: filepath.Join(dir, name), at: at})
 		}
 	}
 
-	if len(backups) <= fw.maxBackups {
-		return
+	removeOldest(backups, fw.maxBackups, func(f string) {
+		_ = fw.fs.Remove(f)
+		if fw.Index {
+			_ = fw.fs.Remove(backupStem(f, prefix) + indexExt)
+		}
+	})
+}
+
+// backupTimeLayout — время ротации в имени бэкапа: app.json.2025-08-14T10-00-00.
+const backupTimeLayout = "2006-01-02T15-04-05"
+
+// backupTime разбирает время ротации из имени бэкапа prefix+время[+расширение].
+func backupTime(name, prefix string) (time.Time, bool) {
+	rest := strings.TrimPrefix(name, prefix)
+	if len(rest) < len(backupTimeLayout) {
+		return time.Time{}, false
 	}
+	t, err := time.Parse(backupTimeLayout, rest[:len(backupTimeLayout)])
+	return t, err == nil
+}
 
-	// Сортируем по имени (в имени уже заложен timestamp)
-	sort.Strings(backups)
+// backup — файл-кандидат на удаление со временем из его имени.
+type backup struct {
+	path string
+	at   time.Time // время ротации или, для датированных файлов, дата из имени
+	// rotated — бэкап по размеру внутри датированного периода (время — sub);
+	// сам файл периода новее всех своих бэкапов
+	rotated bool
+	sub     time.Time
+}
 
-	// Удаляем самые старые
-	for _, f := range backups[:len(backups)-fw.maxBackups] {
-		_ = os.Remove(f)
+func (b backup) before(o backup) bool {
+	if !b.at.Equal(o.at) {
+		return b.at.Before(o.at)
+	}
+	if b.rotated != o.rotated {
+		return b.rotated
+	}
+	if !b.sub.Equal(o.sub) {
+		return b.sub.Before(o.sub)
+	}
+	return b.path < o.path
+}
+
+// removeOldest удаляет из files всё, кроме keep самых новых по времени из
+// имени (сортировка по самому имени неверна для шаблонов вроде %d-%m-%Y).
+func removeOldest(files []backup, keep int, remove func(string)) {
+	if len(files) <= keep {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].before(files[j]) })
+	for _, f := range files[:len(files)-keep] {
+		remove(f.path)
 	}
 }
@@ -0,0 +1,211 @@
+package writer
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileWriterWriteErrorAfterRotate(t *testing.T) {
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)}
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 0, RotateDaily, nil, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fw.Write([]byte("before")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	// ротация при следующей записи, сама запись остаётся в буфере
+	if err := fw.Write([]byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.content("/logs/app.log.2024-03-02T01-00-00"); got != "before\n" {
+		t.Fatalf("rotated file %q", got)
+	}
+
+	diskFull := errors.New("disk full")
+	fs.setWriteErr(diskFull)
+	// запись больше буфера bufio — ошибка всплывает сразу
+	big := []byte(strings.Repeat("x", 8192))
+	err = fw.Write(big)
+
+	var we *WriteError
+	if !errors.As(err, &we) || !errors.Is(err, diskFull) {
+		t.Fatalf("err = %v, want WriteError wrapping disk full", err)
+	}
+	if len(we.Data) != len(big) {
+		t.Fatalf("WriteError.Data has %d bytes, want %d", len(we.Data), len(big))
+	}
+	if we.Lost != len("after\n") {
+		t.Fatalf("WriteError.Lost = %d, want %d", we.Lost, len("after\n"))
+	}
+	if fw.size != 0 {
+		t.Fatalf("size = %d after failed write, want 0", fw.size)
+	}
+
+	// после восстановления диска writer продолжает работать, учёт размера верен
+	fs.setWriteErr(nil)
+	if err := fw.Write([]byte("recovered")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := fs.content("/logs/app.log")
+	if got != "recovered\n" {
+		t.Fatalf("active file %q", got)
+	}
+	if fw.size != int64(len(got)) {
+		t.Fatalf("size = %d, want %d", fw.size, len(got))
+	}
+
+	// сброс буфера при ротации не удался: ранние записи из буфера учтены в
+	// Lost, а файл не переименован и остаётся активным
+	if err := fw.Write([]byte("pending")); err != nil {
+		t.Fatal(err)
+	}
+	fs.setWriteErr(diskFull)
+	clock.Advance(25 * time.Hour)
+	err = fw.Write([]byte("next"))
+	if !errors.As(err, &we) || !errors.Is(err, diskFull) {
+		t.Fatalf("rotation err = %v, want WriteError wrapping disk full", err)
+	}
+	if string(we.Data) != "next" || we.Lost != len("pending\n") {
+		t.Fatalf("WriteError Data = %q, Lost = %d; want next, %d", we.Data, we.Lost, len("pending\n"))
+	}
+	rotated := "/logs/app.log.2024-03-03T02-00-00"
+	if _, ok := fs.content(rotated); ok {
+		t.Fatal("half-flushed file rotated")
+	}
+	if got, _ := fs.content("/logs/app.log"); got != "recovered\n" {
+		t.Fatalf("active file %q after failed rotation", got)
+	}
+
+	// ротация повторяется со следующей записью
+	fs.setWriteErr(nil)
+	if err := fw.Write([]byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.content(rotated); got != "recovered\n" {
+		t.Fatalf("rotated file %q", got)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.content("/logs/app.log"); got != "next\n" {
+		t.Fatalf("active file %q", got)
+	}
+}
+
+func TestFileWriterDailyRotation(t *testing.T) {
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)}
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 2, RotateDaily, nil, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(advance time.Duration, line string) {
+		t.Helper()
+		clock.Advance(advance)
+		if err := fw.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(0, "d1a")
+	write(13*time.Hour, "d1b") // 23:00 того же дня — без ротации
+	if names, _ := fs.ReadDir("/logs"); len(names) != 1 {
+		t.Fatalf("rotated within a day: %q", names)
+	}
+	write(2*time.Hour, "d2a") // 01:00 следующего дня
+	write(5*time.Hour, "d2b")
+	write(24*time.Hour, "d3")
+	write(24*time.Hour, "d4") // третий бэкап — самый старый удаляется
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"/logs/app.log.2024-03-03T06-00-00": "d2a\nd2b\n",
+		"/logs/app.log.2024-03-04T06-00-00": "d3\n",
+		"/logs/app.log":                     "d4\n",
+	} {
+		if got, ok := fs.content(name); !ok || got != want {
+			t.Errorf("%s = %q (exists %v), want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := fs.content("/logs/app.log.2024-03-02T01-00-00"); ok {
+		t.Error("oldest backup not removed")
+	}
+	if names, _ := fs.ReadDir("/logs"); len(names) != 3 {
+		t.Errorf("files %q", names)
+	}
+}
+
+func TestFileWriterHeaderOncePerFreshFile(t *testing.T) {
+	const header = `{"_meta":"lightloggo","encoding":"utf-8"}`
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC)}
+	open := func() *FileWriter {
+		t.Helper()
+		fw, err := NewFileWriterFS("/logs/app.log", 0, 0, RotateDaily, nil, fs, clock.Now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Header = []byte(header)
+		return fw
+	}
+	write := func(fw *FileWriter, lines ...string) {
+		t.Helper()
+		for _, line := range lines {
+			if err := fw.Write([]byte(line)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	fw := open()
+	write(fw, "a", "b")
+	clock.Advance(2 * time.Hour) // ротация: новый файл снова с заголовком
+	write(fw, "c")
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// дописывание в непустой файл — без заголовка
+	fw = open()
+	write(fw, "d")
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"/logs/app.log.2024-03-02T01-00-00": header + "\na\nb\n",
+		"/logs/app.log":                     header + "\nc\nd\n",
+	} {
+		if got, _ := fs.content(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	// существующий пустой файл считается новым
+	if _, err := fs.OpenFile("/logs/empty.log", os.O_CREATE, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fw, err := NewFileWriterFS("/logs/empty.log", 0, 0, "", nil, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Header = []byte(header)
+	write(fw, "e")
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := fs.content("/logs/empty.log"); got != header+"\ne\n" {
+		t.Errorf("empty file: %q", got)
+	}
+}
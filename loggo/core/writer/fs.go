@@ -0,0 +1,55 @@
+package writer
+
+import (
+	"io"
+	"os"
+)
+
+// FileSystem — операции с файловой системой, которые нужны FileWriter'у.
+// По умолчанию — пакет os; подмена позволяет тестировать ротацию и очистку
+// бэкапов без диска (см. NewFileWriterFS).
+type FileSystem interface {
+	MkdirAll(path string, perm os.FileMode) error
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Rename(oldpath, newpath string) error
+	// ReadDir возвращает имена записей каталога.
+	ReadDir(dir string) ([]string, error)
+	Remove(name string) error
+}
+
+// File — открытый файл FileSystem; *os.File ему удовлетворяет.
+type File interface {
+	io.Writer
+	io.Closer
+	Stat() (os.FileInfo, error)
+	Sync() error
+}
+
+// osFS — FileSystem поверх пакета os.
+type osFS struct{}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err // не *os.File(nil) в интерфейсе
+	}
+	return f, nil
+}
+
+func (osFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+func (osFS) ReadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(entries))
+	for i, e := range entries {
+		names[i] = e.Name()
+	}
+	return names, nil
+}
+
+func (osFS) Remove(name string) error { return os.Remove(name) }
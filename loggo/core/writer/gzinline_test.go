@@ -0,0 +1,114 @@
+package writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gunzip распаковывает (в том числе многочленный) gzip-поток.
+func gunzip(t *testing.T, data string) string {
+	t.Helper()
+	zr, err := gzip.NewReader(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("not gzip: %v", err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("invalid gzip: %v", err)
+	}
+	return string(out)
+}
+
+func TestGzInlineRotatesByCompressedSize(t *testing.T) {
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	c := GzInline
+	fw, err := NewFileWriterFS("/logs/app.log", 1, 0, "", &c, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Header = []byte("# header")
+
+	// плохо сжимаемые строки: ~10 МБ несжатых, ~2.5 МБ сжатых данных
+	var want []string
+	for i := 0; len(want) < 80000; i++ {
+		sum := sha256.Sum256([]byte(strconv.Itoa(i)))
+		line := hex.EncodeToString(sum[:]) + hex.EncodeToString(sum[:])
+		want = append(want, line)
+		if err := fw.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Second) // у бэкапов разные имена
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	names, _ := fs.ReadDir("/logs")
+	if len(names) < 3 {
+		t.Fatalf("files %v, want several rotations", names)
+	}
+	var got []string
+	const limit = 1024 * 1024
+	for _, name := range names {
+		data, _ := fs.content("/logs/" + name)
+		if name != "app.log.gz" && len(data) > limit+128*1024 {
+			t.Errorf("%s: %d bytes on disk, limit %d", name, len(data), limit)
+		}
+		if name != "app.log.gz" && len(data) < limit/2 {
+			// учёт по несжатым данным ротировал бы на ~1 МБ несжатых
+			t.Errorf("%s: only %d bytes on disk, rotated too early", name, len(data))
+		}
+		lines := strings.Split(strings.TrimSuffix(gunzip(t, data), "\n"), "\n")
+		if lines[0] != "# header" {
+			t.Errorf("%s: first line %q, want header", name, lines[0])
+		}
+		got = append(got, lines[1:]...)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("decompressed %d lines, want %d in order", len(got), len(want))
+	}
+}
+
+func TestGzInlineReopenKeepsSizeAndHeader(t *testing.T) {
+	fs := newMemFS()
+	c := GzInline
+	open := func() *FileWriter {
+		fw, err := NewFileWriterFS("/logs/app.log", 1, 0, "", &c, fs, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Header = []byte("# header")
+		return fw
+	}
+
+	fw := open()
+	_ = fw.Write([]byte("one"))
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	onDisk, _ := fs.content("/logs/app.log.gz")
+
+	fw = open()
+	if fw.size != int64(len(onDisk)) {
+		t.Fatalf("size after reopen = %d, want on-disk %d", fw.size, len(onDisk))
+	}
+	_ = fw.Write([]byte("two"))
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := fs.content("/logs/app.log.gz")
+	if got := gunzip(t, data); got != "# header\none\ntwo\n" {
+		t.Fatalf("content %q", got)
+	}
+	if fw.size != int64(len(data)) || !bytes.HasPrefix([]byte(data), []byte(onDisk)) {
+		t.Fatalf("size = %d, want %d", fw.size, len(data))
+	}
+}
@@ -0,0 +1,198 @@
+package writer
+
+import (
+	"bytes"
+	"funchooooza-ossh/loggo/core"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memWriter запоминает записи в памяти.
+type memWriter struct {
+	mu      sync.Mutex
+	lines   []string
+	records []core.LogRecord
+	err     error
+	flushes int
+	closed  bool
+}
+
+func (w *memWriter) Write(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.lines = append(w.lines, string(p))
+	return nil
+}
+
+func (w *memWriter) Flush() error {
+	w.mu.Lock()
+	w.flushes++
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *memWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *memWriter) Lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.lines...)
+}
+
+// recordWriter — memWriter, принимающий записи через WriteRecord.
+type recordWriter struct {
+	memWriter
+}
+
+func (w *recordWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	w.mu.Lock()
+	w.records = append(w.records, r)
+	w.mu.Unlock()
+	return w.Write(formatted)
+}
+
+// memFS — FileSystem в памяти. writeErr, если задана, возвращается из Write
+// любого файла.
+type memFS struct {
+	mu       sync.Mutex
+	files    map[string]*memFile
+	writeErr error
+}
+
+func newMemFS() *memFS {
+	return &memFS{files: map[string]*memFile{}}
+}
+
+func (fs *memFS) MkdirAll(string, os.FileMode) error { return nil }
+
+func (fs *memFS) OpenFile(name string, flag int, _ os.FileMode) (File, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.files[name]
+	if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, os.ErrNotExist
+		}
+		f = &memFile{fs: fs, name: name}
+		fs.files[name] = f
+	}
+	return f, nil
+}
+
+func (fs *memFS) Rename(oldpath, newpath string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.files[oldpath]
+	if !ok {
+		return os.ErrNotExist
+	}
+	delete(fs.files, oldpath)
+	f.name = newpath
+	fs.files[newpath] = f
+	return nil
+}
+
+func (fs *memFS) ReadDir(dir string) ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var names []string
+	for name := range fs.files {
+		if filepath.Dir(name) == dir {
+			names = append(names, filepath.Base(name))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (fs *memFS) Remove(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.files[name]; !ok {
+		return os.ErrNotExist
+	}
+	delete(fs.files, name)
+	return nil
+}
+
+// content возвращает содержимое файла; ok == false — файла нет.
+func (fs *memFS) content(name string) (string, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.files[name]
+	if !ok {
+		return "", false
+	}
+	return f.data.String(), true
+}
+
+func (fs *memFS) setWriteErr(err error) {
+	fs.mu.Lock()
+	fs.writeErr = err
+	fs.mu.Unlock()
+}
+
+type memFile struct {
+	fs   *memFS
+	name string
+	data bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	if f.fs.writeErr != nil {
+		return 0, f.fs.writeErr
+	}
+	return f.data.Write(p)
+}
+
+func (f *memFile) Close() error { return nil }
+func (f *memFile) Sync() error  { return nil }
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	return memInfo{name: filepath.Base(f.name), size: int64(f.data.Len())}, nil
+}
+
+type memInfo struct {
+	name string
+	size int64
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() os.FileMode  { return 0644 }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return false }
+func (i memInfo) Sys() any           { return nil }
+
+// fakeClock — подменяемые часы для FileWriter.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
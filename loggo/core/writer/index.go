@@ -0,0 +1,65 @@
+package writer
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// indexExt — расширение индекса ротированного файла.
+const indexExt = ".idx"
+
+// fileIndex — сводка по записям активного файла для сайдкара .idx.
+type fileIndex struct {
+	From  time.Time `json:"from,omitzero"`
+	To    time.Time `json:"to,omitzero"`
+	Count int64     `json:"count"`
+}
+
+func (ix *fileIndex) observe(ts time.Time) {
+	ix.Count++
+	if ts.IsZero() {
+		return
+	}
+	if ix.From.IsZero() || ts.Before(ix.From) {
+		ix.From = ts
+	}
+	if ts.After(ix.To) {
+		ix.To = ts
+	}
+}
+
+// WriteRecord пишет запись как Write и, при включённом Index, учитывает её
+// время в индексе активного файла.
+func (fw *FileWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	return fw.write(formatted, r.Timestamp)
+}
+
+// writeIndex сохраняет индекс ротированного файла рядом с ним:
+// <path>.<timestamp>.idx (без расширения сжатия). Вызывать под mu.
+func (fw *FileWriter) writeIndex(stem string) error {
+	data, err := json.Marshal(fw.index)
+	if err != nil {
+		return err
+	}
+	f, err := fw.fs.OpenFile(stem+indexExt, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// backupStem — имя бэкапа без расширений после timestamp'а (.gz, .idx):
+// по нему бэкап и его индекс удаляются вместе.
+func backupStem(path, prefix string) string {
+	dir, name := filepath.Split(path)
+	rest, _, _ := strings.Cut(strings.TrimPrefix(name, prefix), ".")
+	return filepath.Join(dir, prefix+rest)
+}
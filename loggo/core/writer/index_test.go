@@ -0,0 +1,56 @@
+package writer
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"testing"
+	"time"
+)
+
+func TestIndexSidecarRange(t *testing.T) {
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)}
+	fw, err := NewFileWriterFS("/logs/app.log", 0, 0, RotateDaily, nil, fs, clock.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Index = true
+
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// порядок времени записей не совпадает с порядком записи
+	for _, off := range []time.Duration{time.Minute, 0, 3 * time.Hour, time.Hour} {
+		if err := fw.WriteRecord(core.LogRecord{Timestamp: base.Add(off)}, []byte("r")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fw.Write([]byte("no timestamp")); err != nil { // считается, но не двигает диапазон
+		t.Fatal(err)
+	}
+	clock.Advance(3 * time.Hour)
+	if err := fw.WriteRecord(core.LogRecord{Timestamp: clock.Now()}, []byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, ok := fs.content("/logs/app.log.2024-03-02T01-00-00.idx")
+	if !ok {
+		t.Fatal("no sidecar")
+	}
+	var ix fileIndex
+	if err := json.Unmarshal([]byte(raw), &ix); err != nil {
+		t.Fatalf("sidecar %q: %v", raw, err)
+	}
+	want := fileIndex{From: base, To: base.Add(3 * time.Hour), Count: 5}
+	if !ix.From.Equal(want.From) || !ix.To.Equal(want.To) || ix.Count != want.Count {
+		t.Errorf("index %+v, want %+v", ix, want)
+	}
+	// индекс нового файла начинается заново, а сайдкар пишется только при ротации
+	if fw.index.Count != 1 {
+		t.Errorf("active index count = %d", fw.index.Count)
+	}
+	if _, ok := fs.content("/logs/app.log.idx"); ok {
+		t.Error("sidecar for the active file")
+	}
+}
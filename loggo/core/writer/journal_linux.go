@@ -0,0 +1,166 @@
+//go:build linux
+
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"funchooooza-ossh/loggo/core"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultJournalSocket — сокет нативного протокола journald.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournalWriter отправляет записи в systemd journal по нативному протоколу:
+// MESSAGE, PRIORITY и поля записи (ключи в верхнем регистре) как поля журнала.
+// Если сокет недоступен, записи уходят в fallback (например, StdoutWriter).
+type JournalWriter struct {
+	socketPath string
+	fallback   core.WriteProcessor
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// NewJournalWriter создаёт JournalWriter; socketPath == "" — DefaultJournalSocket,
+// fallback == nil — при недоступном журнале возвращается ошибка.
+func NewJournalWriter(socketPath string, fallback core.WriteProcessor) *JournalWriter {
+	if socketPath == "" {
+		socketPath = DefaultJournalSocket
+	}
+	return &JournalWriter{socketPath: socketPath, fallback: fallback}
+}
+
+// Write отправляет строку без полей с PRIORITY=6 (info).
+func (w *JournalWriter) Write(p []byte) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "PRIORITY", "6")
+	writeJournalField(&b, "MESSAGE", string(p))
+	return w.send(b.Bytes(), p)
+}
+
+func (w *JournalWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(journalPriority(r.Level)))
+	writeJournalField(&b, "MESSAGE", r.Message)
+	writeJournalField(&b, "LOGGO_LEVEL", r.Level.String())
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := journalFieldName(k)
+		if name == "" {
+			continue
+		}
+		writeJournalField(&b, name, fmt.Sprint(r.Fields[k]))
+	}
+	return w.send(b.Bytes(), formatted)
+}
+
+func (w *JournalWriter) send(datagram, formatted []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: w.socketPath, Net: "unixgram"})
+		if err != nil {
+			return w.fallbackWrite(formatted, err)
+		}
+		w.conn = conn
+	}
+	if _, err := w.conn.Write(datagram); err != nil {
+		// journald мог перезапуститься — переподключимся при следующей записи
+		_ = w.conn.Close()
+		w.conn = nil
+		return w.fallbackWrite(formatted, err)
+	}
+	return nil
+}
+
+func (w *JournalWriter) fallbackWrite(formatted []byte, cause error) error {
+	if w.fallback == nil {
+		return fmt.Errorf("journal writer: %w", cause)
+	}
+	return w.fallback.Write(formatted)
+}
+
+func (w *JournalWriter) Flush() error {
+	if f, ok := w.fallback.(core.FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (w *JournalWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+// journalPriority переводит уровень в syslog-приоритет журнала.
+func journalPriority(level core.LogLevel) int {
+	switch {
+	case level >= core.Exception:
+		return 2 // crit
+	case level >= core.Error:
+		return 3 // err
+	case level >= core.Warning:
+		return 4 // warning
+	case level >= core.Info:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// journalFieldName приводит ключ к виду, допустимому для журнала:
+// A-Z, 0-9 и '_', не начинается с '_' или цифры. Пустая строка — ключ пропускается.
+func journalFieldName(key string) string {
+	var sb strings.Builder
+	for _, c := range strings.ToUpper(key) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			sb.WriteRune(c)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	name := strings.TrimLeft(sb.String(), "_0123456789")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// writeJournalField пишет поле в формате протокола: KEY=value\n, а значения с
+// переводом строки — как KEY\n<uint64 LE длина><value>\n.
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	b.WriteString(key)
+	if strings.IndexByte(value, '\n') == -1 {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b.Write(size[:])
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
@@ -0,0 +1,69 @@
+//go:build linux
+
+package writer
+
+import (
+	"encoding/binary"
+	"funchooooza-ossh/loggo/core"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalWriterWireFormat(t *testing.T) {
+	dir, err := os.MkdirTemp("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram unavailable: %v", err)
+	}
+	defer conn.Close()
+
+	w := NewJournalWriter(path, nil)
+	defer w.Close()
+	r := core.LogRecord{Level: core.Error, Message: "disk full", Fields: map[string]any{
+		"user-id": 7,
+		"trace":   "a\nb",
+		"_skip":   "x", // ведущий '_' — служебные поля журнала, отбрасывается
+	}}
+	if err := w.WriteRecord(r, []byte("formatted")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], 3)
+	want := "PRIORITY=3\nMESSAGE=disk full\nLOGGO_LEVEL=ERROR\n" +
+		"SKIP=x\n" +
+		"TRACE\n" + string(size[:]) + "a\nb\n" +
+		"USER_ID=7\n"
+	if got := string(buf[:n]); got != want {
+		t.Errorf("datagram\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestJournalWriterFallsBack(t *testing.T) {
+	mem := &memWriter{}
+	w := NewJournalWriter(filepath.Join(t.TempDir(), "missing"), mem)
+	if err := w.WriteRecord(core.LogRecord{Level: core.Info, Message: "m"}, []byte("line")); err != nil {
+		t.Fatal(err)
+	}
+	if got := mem.Lines(); len(got) != 1 || got[0] != "line" {
+		t.Errorf("fallback got %q", got)
+	}
+
+	if err := NewJournalWriter(filepath.Join(t.TempDir(), "missing"), nil).Write([]byte("x")); err == nil {
+		t.Error("no error without fallback")
+	}
+}
@@ -0,0 +1,68 @@
+package writer
+
+import (
+	"errors"
+	"funchooooza-ossh/loggo/core"
+	"sort"
+)
+
+// LevelWriter выбирает writer по уровню записи: например, ERROR и выше —
+// в отдельный файл, остальное — в stdout. Нужна запись, поэтому реализует
+// core.RecordWriteProcessor; обычный Write уходит в fallback.
+type LevelWriter struct {
+	fallback core.WriteProcessor
+	targets  []levelTarget // по убыванию min
+}
+
+type levelTarget struct {
+	min    core.LogLevel
+	writer core.WriteProcessor
+}
+
+// NewLevelWriter создаёт LevelWriter; fallback получает записи ниже всех порогов.
+func NewLevelWriter(fallback core.WriteProcessor) *LevelWriter {
+	return &LevelWriter{fallback: fallback}
+}
+
+// Add направляет записи уровня >= min в writer (побеждает наибольший подходящий порог).
+// Настраивать до запуска логгера: Add не потокобезопасен.
+func (w *LevelWriter) Add(min core.LogLevel, writer core.WriteProcessor) *LevelWriter {
+	w.targets = append(w.targets, levelTarget{min: min, writer: writer})
+	sort.SliceStable(w.targets, func(i, j int) bool { return w.targets[i].min > w.targets[j].min })
+	return w
+}
+
+func (w *LevelWriter) Write(p []byte) error {
+	return w.fallback.Write(p)
+}
+
+func (w *LevelWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	return writeRecordTo(w.pick(r.Level), r, formatted)
+}
+
+func (w *LevelWriter) pick(level core.LogLevel) core.WriteProcessor {
+	for _, t := range w.targets {
+		if level >= t.min {
+			return t.writer
+		}
+	}
+	return w.fallback
+}
+
+func (w *LevelWriter) Flush() error {
+	var errs []error
+	for _, t := range append(w.targets, levelTarget{writer: w.fallback}) {
+		if f, ok := t.writer.(core.FlushableWriter); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeRecordTo передаёт запись дальше, сохраняя её для record-aware writer'ов.
+func writeRecordTo(w core.WriteProcessor, r core.LogRecord, formatted []byte) error {
+	if rw, ok := w.(core.RecordWriteProcessor); ok {
+		return rw.WriteRecord(r, formatted)
+	}
+	return w.Write(formatted)
+}
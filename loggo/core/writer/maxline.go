@@ -0,0 +1,63 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"unicode/utf8"
+)
+
+// DefaultTruncateMarker дописывается к обрезанной MaxLineWriter'ом записи.
+const DefaultTruncateMarker = "…[truncated]"
+
+// MaxLineWriter ограничивает длину строки в байтах: записи длиннее обрезаются
+// по границе символа UTF-8, и в конец ставится Marker. Нужно для транспортов
+// (некоторые syslog-релеи), которые теряют или режут длинные строки. Перевод
+// строки, который добавляют writer'ы, входит в лимит.
+type MaxLineWriter struct {
+	// Marker — метка обрезки; пусто — без метки. Если лимит меньше метки,
+	// запись обрезается без неё.
+	Marker string
+
+	next     core.WriteProcessor
+	maxBytes int
+}
+
+// NewMaxLineWriter оборачивает next лимитом maxBytes на строку вместе с '\n';
+// maxBytes <= 1 — без ограничения.
+func NewMaxLineWriter(next core.WriteProcessor, maxBytes int) *MaxLineWriter {
+	return &MaxLineWriter{Marker: DefaultTruncateMarker, next: next, maxBytes: maxBytes}
+}
+
+func (w *MaxLineWriter) Write(p []byte) error {
+	return w.next.Write(w.truncate(p))
+}
+
+func (w *MaxLineWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	return writeRecordTo(w.next, r, w.truncate(formatted))
+}
+
+func (w *MaxLineWriter) Flush() error {
+	if f, ok := w.next.(core.FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// truncate возвращает p, если он укладывается в лимит, иначе обрезанную копию.
+func (w *MaxLineWriter) truncate(p []byte) []byte {
+	limit := w.maxBytes - 1 // место под '\n'
+	if w.maxBytes <= 1 || len(p) <= limit {
+		return p
+	}
+	marker := w.Marker
+	if len(marker) > limit {
+		marker = ""
+	}
+	cut := limit - len(marker)
+	// не разрываем многобайтный символ
+	for cut > 0 && !utf8.RuneStart(p[cut]) {
+		cut--
+	}
+	out := make([]byte, 0, cut+len(marker))
+	out = append(out, p[:cut]...)
+	return append(out, marker...)
+}
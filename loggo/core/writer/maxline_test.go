@@ -0,0 +1,72 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestMaxLineWriterBoundaries(t *testing.T) {
+	const limit = 16 // 15 байт записи + '\n'
+	a := func(n int) string { return strings.Repeat("a", n) }
+	cases := []struct {
+		name, in, want string
+	}{
+		{"under", a(14), a(14)},
+		{"at", a(15), a(15)},
+		{"over", a(16), a(14) + "~"},
+		{"two-byte rune at cut", a(13) + "я" + "b", a(13) + "~"},
+		{"two-byte rune fits", a(12) + "я" + "bb", a(12) + "я" + "~"},
+		{"four-byte rune at cut", a(11) + "😀" + "bb", a(11) + "~"},
+		{"multibyte at limit", a(13) + "я", a(13) + "я"},
+	}
+	for _, c := range cases {
+		next := &memWriter{}
+		w := NewMaxLineWriter(next, limit)
+		w.Marker = "~"
+		if err := w.Write([]byte(c.in)); err != nil {
+			t.Fatal(err)
+		}
+		if got := next.Lines()[0]; got != c.want {
+			t.Errorf("%s: %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	// любая обрезка — валидный UTF-8 в пределах лимита, включая '\n'
+	mixed := "ab€цd😀е" + strings.Repeat("ж", 10)
+	for n := 2; n <= len(mixed)+2; n++ {
+		next := &recordWriter{}
+		w := NewMaxLineWriter(next, n)
+		if err := w.WriteRecord(core.LogRecord{Message: "m"}, []byte(mixed)); err != nil {
+			t.Fatal(err)
+		}
+		got := next.Lines()[0]
+		if len(got)+1 > n || !utf8.ValidString(got) {
+			t.Errorf("limit %d: %q (%d bytes)", n, got, len(got))
+		}
+		if len(next.records) != 1 {
+			t.Errorf("limit %d: record not forwarded", n)
+		}
+	}
+
+	// метка длиннее лимита не ставится; лимит <= 1 — без ограничения
+	next := &memWriter{}
+	if err := NewMaxLineWriter(next, 4).Write([]byte("abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewMaxLineWriter(next, 0).Write([]byte(a(100))); err != nil {
+		t.Fatal(err)
+	}
+	if got := next.Lines(); got[0] != "abc" || got[1] != a(100) {
+		t.Errorf("got %q", got)
+	}
+	// метка по умолчанию
+	next = &memWriter{}
+	if err := NewMaxLineWriter(next, 32).Write([]byte(a(40))); err != nil {
+		t.Fatal(err)
+	}
+	if got := next.Lines()[0]; got != a(31-len(DefaultTruncateMarker))+DefaultTruncateMarker {
+		t.Errorf("default marker: %q", got)
+	}
+}
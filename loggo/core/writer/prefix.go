@@ -0,0 +1,68 @@
+package writer
+
+import (
+	"bytes"
+	"funchooooza-ossh/loggo/core"
+	"io"
+)
+
+// PrefixWriter ставит постоянный префикс перед каждой строкой записи — например,
+// метку сервиса, когда несколько процессов пишут в один пайп. Записи с
+// переводами строк внутри (многострочный текст, стектрейсы) получают префикс
+// на каждой строке, завершающий '\n' нового не добавляет. Префикс ломает JSON
+// и другие машинные форматы, поэтому writer — для текстового и консольного
+// вывода.
+type PrefixWriter struct {
+	next   core.WriteProcessor
+	prefix []byte
+}
+
+// NewPrefixWriter оборачивает next префиксом prefix; пустой префикс — записи
+// проходят как есть.
+func NewPrefixWriter(next core.WriteProcessor, prefix []byte) *PrefixWriter {
+	return &PrefixWriter{next: next, prefix: bytes.Clone(prefix)}
+}
+
+func (w *PrefixWriter) Write(p []byte) error {
+	return w.next.Write(w.apply(p))
+}
+
+func (w *PrefixWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	return writeRecordTo(w.next, r, w.apply(formatted))
+}
+
+func (w *PrefixWriter) Flush() error {
+	if f, ok := w.next.(core.FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close закрывает вложенный writer, если он это умеет.
+func (w *PrefixWriter) Close() error {
+	if c, ok := w.next.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// apply возвращает копию p с префиксом в начале и после каждого внутреннего '\n';
+// пустая запись остаётся пустой.
+func (w *PrefixWriter) apply(p []byte) []byte {
+	if len(w.prefix) == 0 || len(p) == 0 {
+		return p
+	}
+	lines := bytes.Count(p, []byte{'\n'})
+	out := make([]byte, 0, len(p)+len(w.prefix)*(lines+1))
+	out = append(out, w.prefix...)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 || i == len(p)-1 {
+			// последняя строка; хвостовой '\n' остаётся без префикса
+			return append(out, p...)
+		}
+		out = append(out, p[:i+1]...)
+		out = append(out, w.prefix...)
+		p = p[i+1:]
+	}
+}
@@ -0,0 +1,57 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"testing"
+)
+
+func TestPrefixWriterPrefixesEachLine(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"one", "[svc] one"},
+		{"one\n", "[svc] one\n"},
+		{"one\ntwo\n", "[svc] one\n[svc] two\n"},
+		{"one\n\ntwo", "[svc] one\n[svc] \n[svc] two"},
+		{"\n", "[svc] \n"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		m := &memWriter{}
+		w := NewPrefixWriter(m, []byte("[svc] "))
+		if err := w.Write([]byte(c.in)); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Lines()[0]; got != c.want {
+			t.Errorf("Write(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestPrefixWriterEmptyPrefix(t *testing.T) {
+	m := &memWriter{}
+	w := NewPrefixWriter(m, nil)
+	_ = w.Write([]byte("a\nb\n"))
+	if got := m.Lines()[0]; got != "a\nb\n" {
+		t.Fatalf("got %q, want unchanged", got)
+	}
+}
+
+func TestPrefixWriterForwardsRecordsFlushClose(t *testing.T) {
+	m := &recordWriter{}
+	w := NewPrefixWriter(m, []byte("p: "))
+	r := core.LogRecord{Message: "x"}
+	if err := w.WriteRecord(r, []byte("x\ny\n")); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.records) != 1 || m.records[0].Message != "x" {
+		t.Fatalf("records = %+v, want the original record", m.records)
+	}
+	if got := m.Lines()[0]; got != "p: x\np: y\n" {
+		t.Fatalf("got %q", got)
+	}
+	if err := w.Flush(); err != nil || m.flushes != 1 {
+		t.Fatalf("Flush: err %v, flushes %d", err, m.flushes)
+	}
+	if err := w.Close(); err != nil || !m.closed {
+		t.Fatalf("Close: err %v, closed %v", err, m.closed)
+	}
+}
@@ -0,0 +1,99 @@
+package writer
+
+import (
+	"errors"
+	"funchooooza-ossh/loggo/core"
+	"io"
+	"sync"
+)
+
+// DefaultQuietContext — сколько последних записей ниже порога держит
+// QuietWriter, если размер не задан.
+const DefaultQuietContext = 100
+
+// QuietWriter — режим «молчать до ошибки» для CLI: записи ниже порога не
+// пишутся, а копятся в кольцевом буфере последних context записей. Пришла
+// запись уровня >= threshold — сначала выводится накопленный контекст (по
+// порядку), затем она сама, и буфер очищается. Если ошибки так и не было,
+// контекст пропадает при Close. Уровень известен только через WriteRecord;
+// обычный Write проходит сразу.
+type QuietWriter struct {
+	next      core.WriteProcessor
+	threshold core.LogLevel
+
+	mu    sync.Mutex
+	ring  []reorderItem // кольцо; start — самая старая запись
+	size  int
+	start int
+}
+
+// NewQuietWriter оборачивает next режимом «молчать до ошибки». context <= 0 —
+// DefaultQuietContext.
+func NewQuietWriter(next core.WriteProcessor, threshold core.LogLevel, context int) *QuietWriter {
+	if context <= 0 {
+		context = DefaultQuietContext
+	}
+	return &QuietWriter{next: next, threshold: threshold, ring: make([]reorderItem, context)}
+}
+
+func (w *QuietWriter) Write(p []byte) error {
+	return w.next.Write(p)
+}
+
+func (w *QuietWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if r.Level < w.threshold {
+		// formatted может переиспользоваться вызывающим — храним копию
+		it := reorderItem{record: r, formatted: append([]byte(nil), formatted...)}
+		if w.size < len(w.ring) {
+			w.ring[(w.start+w.size)%len(w.ring)] = it
+			w.size++
+		} else {
+			// буфер полон — вытесняем самую старую
+			w.ring[w.start] = it
+			w.start = (w.start + 1) % len(w.ring)
+		}
+		return nil
+	}
+
+	var errs []error
+	for i := 0; i < w.size; i++ {
+		it := &w.ring[(w.start+i)%len(w.ring)]
+		errs = append(errs, writeRecordTo(w.next, it.record, it.formatted))
+		*it = reorderItem{}
+	}
+	w.start, w.size = 0, 0
+	errs = append(errs, writeRecordTo(w.next, r, formatted))
+	return errors.Join(errs...)
+}
+
+// Buffered — число записей контекста, ожидающих ошибки.
+func (w *QuietWriter) Buffered() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
+
+// Flush сбрасывает вложенный writer; накопленный контекст не выводится.
+func (w *QuietWriter) Flush() error {
+	if f, ok := w.next.(core.FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close отбрасывает накопленный контекст и закрывает вложенный writer, если
+// он это умеет.
+func (w *QuietWriter) Close() error {
+	w.mu.Lock()
+	clear(w.ring)
+	w.start, w.size = 0, 0
+	w.mu.Unlock()
+
+	if c, ok := w.next.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"testing"
+)
+
+func writeLevels(t *testing.T, w *QuietWriter, entries ...core.LogRecord) {
+	t.Helper()
+	for _, r := range entries {
+		if err := w.WriteRecord(r, []byte(r.Message)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func rec(level core.LogLevel, msg string) core.LogRecord {
+	return core.LogRecord{Level: level, Message: msg}
+}
+
+func TestQuietWriterDiscardsWithoutError(t *testing.T) {
+	mem := &recordWriter{}
+	w := NewQuietWriter(mem, core.Error, 0)
+	writeLevels(t, w, rec(core.Debug, "a"), rec(core.Info, "b"), rec(core.Warning, "c"))
+	if n := w.Buffered(); n != 3 {
+		t.Errorf("Buffered = %d", n)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mem.Lines(); len(got) != 0 {
+		t.Errorf("written without error: %q", got)
+	}
+	if w.Buffered() != 0 || !mem.closed {
+		t.Error("context kept or next not closed")
+	}
+}
+
+func TestQuietWriterFlushesContextOnError(t *testing.T) {
+	mem := &recordWriter{}
+	w := NewQuietWriter(mem, core.Error, 2)
+	writeLevels(t, w,
+		rec(core.Info, "dropped"), rec(core.Info, "ctx1"), rec(core.Warning, "ctx2"),
+		rec(core.Error, "boom"),
+		rec(core.Info, "after"), rec(core.Exception, "boom2"),
+	)
+	want := []string{"ctx1", "ctx2", "boom", "after", "boom2"}
+	if got := mem.Lines(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(mem.records) != len(want) || mem.records[1].Level != core.Warning {
+		t.Errorf("records = %v", mem.records)
+	}
+	if w.Buffered() != 0 {
+		t.Errorf("Buffered = %d after error", w.Buffered())
+	}
+}
+
+func TestQuietWriterCopiesBuffer(t *testing.T) {
+	mem := &memWriter{}
+	w := NewQuietWriter(mem, core.Error, 0)
+	buf := []byte("first")
+	if err := w.WriteRecord(rec(core.Info, ""), buf); err != nil {
+		t.Fatal(err)
+	}
+	copy(buf, "XXXXX")
+	writeLevels(t, w, rec(core.Error, "err"))
+	if got := mem.Lines(); !reflect.DeepEqual(got, []string{"first", "err"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestQuietWriterPlainWritePassesThrough(t *testing.T) {
+	mem := &memWriter{}
+	w := NewQuietWriter(mem, core.Error, 0)
+	if err := w.Write([]byte("raw")); err != nil {
+		t.Fatal(err)
+	}
+	if got := mem.Lines(); !reflect.DeepEqual(got, []string{"raw"}) {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package writer
+
+import (
+	"errors"
+	"funchooooza-ossh/loggo/core"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ReorderWriter придерживает записи на window и выпускает их отсортированными
+// по Timestamp: так в общем файле от нескольких роутов/воркеров строки идут по
+// времени. Цена — задержка: запись попадает в файл не раньше чем через window
+// после своего Timestamp. Запись, опоздавшая больше чем на window, пишется
+// при ближайшем выпуске и может оказаться не на своём месте.
+type ReorderWriter struct {
+	next   core.WriteProcessor
+	window time.Duration
+
+	mu    sync.Mutex
+	buf   []reorderItem
+	timer *time.Timer
+}
+
+type reorderItem struct {
+	record    core.LogRecord
+	formatted []byte
+}
+
+// NewReorderWriter оборачивает writer буфером переупорядочивания на window.
+func NewReorderWriter(next core.WriteProcessor, window time.Duration) *ReorderWriter {
+	return &ReorderWriter{next: next, window: window}
+}
+
+// Write пишет сразу: без записи нет Timestamp, сортировать не по чему.
+func (w *ReorderWriter) Write(p []byte) error {
+	return w.next.Write(p)
+}
+
+func (w *ReorderWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, reorderItem{record: r, formatted: append([]byte(nil), formatted...)})
+	err := w.release(time.Now().Add(-w.window))
+	if len(w.buf) > 0 && w.timer == nil {
+		w.timer = time.AfterFunc(w.window, w.onTimer)
+	}
+	return err
+}
+
+// Flush выпускает весь буфер и сбрасывает вложенный writer.
+func (w *ReorderWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	err := w.releaseAll()
+	if f, ok := w.next.(core.FlushableWriter); ok {
+		err = errors.Join(err, f.Flush())
+	}
+	return err
+}
+
+func (w *ReorderWriter) onTimer() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.timer = nil
+	_ = w.release(time.Now().Add(-w.window))
+	if len(w.buf) > 0 {
+		w.timer = time.AfterFunc(w.window, w.onTimer)
+	}
+}
+
+// release пишет по порядку записи с Timestamp не позже cutoff. Вызывать под mu.
+func (w *ReorderWriter) release(cutoff time.Time) error {
+	w.sortBuf()
+	n := sort.Search(len(w.buf), func(i int) bool {
+		return w.buf[i].record.Timestamp.After(cutoff)
+	})
+	return w.writeFirst(n)
+}
+
+func (w *ReorderWriter) releaseAll() error {
+	w.sortBuf()
+	return w.writeFirst(len(w.buf))
+}
+
+func (w *ReorderWriter) sortBuf() {
+	sort.SliceStable(w.buf, func(i, j int) bool {
+		return w.buf[i].record.Timestamp.Before(w.buf[j].record.Timestamp)
+	})
+}
+
+func (w *ReorderWriter) writeFirst(n int) error {
+	var errs []error
+	for _, it := range w.buf[:n] {
+		errs = append(errs, writeRecordTo(w.next, it.record, it.formatted))
+	}
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+	return errors.Join(errs...)
+}
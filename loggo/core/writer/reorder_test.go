@@ -0,0 +1,50 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReorderWriterSortsWithinWindow(t *testing.T) {
+	mem := &memWriter{}
+	w := NewReorderWriter(mem, time.Hour)
+	now := time.Now()
+	for _, tc := range []struct {
+		line string
+		ago  time.Duration
+	}{
+		{"c", 3 * time.Second}, {"a", 5 * time.Second}, {"b", 4 * time.Second}, {"d", 2 * time.Second},
+	} {
+		r := core.LogRecord{Level: core.Info, Timestamp: now.Add(-tc.ago)}
+		if err := w.WriteRecord(r, []byte(tc.line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := mem.Lines(); len(got) != 0 {
+		t.Fatalf("released before window: %q", got)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mem.Lines(); !reflect.DeepEqual(got, []string{"a", "b", "c", "d"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestReorderWriterReleasesOnTimer(t *testing.T) {
+	mem := &memWriter{}
+	w := NewReorderWriter(mem, 30*time.Millisecond)
+	now := time.Now()
+	_ = w.WriteRecord(core.LogRecord{Timestamp: now.Add(10 * time.Millisecond)}, []byte("2"))
+	_ = w.WriteRecord(core.LogRecord{Timestamp: now}, []byte("1"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(mem.Lines()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := mem.Lines(); !reflect.DeepEqual(got, []string{"1", "2"}) {
+		t.Errorf("got %q", got)
+	}
+}
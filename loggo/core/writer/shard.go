@@ -0,0 +1,126 @@
+package writer
+
+import (
+	"container/list"
+	"errors"
+	"funchooooza-ossh/loggo/core"
+	"strings"
+	"sync"
+)
+
+// DefaultShard — шард для записей без значения ключа (и для обычного Write).
+const DefaultShard = "default"
+
+// ShardKeyFunc извлекает из записи значение шарда, например r.Fields["tenant"].
+type ShardKeyFunc func(r core.LogRecord) string
+
+// ShardOpenFunc создаёт writer для шарда, например
+// NewFileWriter("logs/"+shard+".json", ...).
+type ShardOpenFunc func(shard string) (*FileWriter, error)
+
+// ShardingWriter раскладывает записи по отдельным файлам в зависимости от поля.
+// FileWriter'ы создаются лениво и кешируются; открытых файлов не больше maxOpen,
+// самый давно не использованный закрывается (LRU).
+type ShardingWriter struct {
+	key     ShardKeyFunc
+	open    ShardOpenFunc
+	maxOpen int
+
+	mu      sync.Mutex
+	lru     *list.List // *shardEntry, спереди — недавние
+	entries map[string]*list.Element
+}
+
+type shardEntry struct {
+	shard string
+	w     *FileWriter
+}
+
+// NewShardingWriter создаёт ShardingWriter; maxOpen <= 0 — без ограничения.
+func NewShardingWriter(key ShardKeyFunc, open ShardOpenFunc, maxOpen int) *ShardingWriter {
+	return &ShardingWriter{
+		key:     key,
+		open:    open,
+		maxOpen: maxOpen,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Write пишет в DefaultShard: без записи значение ключа неизвестно.
+func (w *ShardingWriter) Write(p []byte) error {
+	return w.writeShard(DefaultShard, func(fw *FileWriter) error { return fw.Write(p) })
+}
+
+// WriteRecord передаёт запись writer'у шарда целиком: его индекс (Index)
+// учитывает время записи.
+func (w *ShardingWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	return w.writeShard(sanitizeShard(w.key(r)), func(fw *FileWriter) error { return fw.WriteRecord(r, formatted) })
+}
+
+func (w *ShardingWriter) writeShard(shard string, write func(*FileWriter) error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fw, err := w.get(shard)
+	if err != nil {
+		return err
+	}
+	return write(fw)
+}
+
+// get возвращает writer шарда, открывая его при необходимости. Вызывать под mu.
+func (w *ShardingWriter) get(shard string) (*FileWriter, error) {
+	if el, ok := w.entries[shard]; ok {
+		w.lru.MoveToFront(el)
+		return el.Value.(*shardEntry).w, nil
+	}
+
+	fw, err := w.open(shard)
+	if err != nil {
+		return nil, err
+	}
+	w.entries[shard] = w.lru.PushFront(&shardEntry{shard: shard, w: fw})
+
+	for w.maxOpen > 0 && w.lru.Len() > w.maxOpen {
+		oldest := w.lru.Back()
+		e := oldest.Value.(*shardEntry)
+		w.lru.Remove(oldest)
+		delete(w.entries, e.shard)
+		_ = e.w.Close()
+	}
+	return fw, nil
+}
+
+func (w *ShardingWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []error
+	for el := w.lru.Front(); el != nil; el = el.Next() {
+		errs = append(errs, el.Value.(*shardEntry).w.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close закрывает все открытые файлы шардов.
+func (w *ShardingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []error
+	for el := w.lru.Front(); el != nil; el = el.Next() {
+		errs = append(errs, el.Value.(*shardEntry).w.Close())
+	}
+	w.lru.Init()
+	w.entries = make(map[string]*list.Element)
+	return errors.Join(errs...)
+}
+
+// sanitizeShard не даёт значению поля выйти за пределы каталога логов.
+func sanitizeShard(s string) string {
+	if s == "" || s == "." || s == ".." {
+		return DefaultShard
+	}
+	return strings.NewReplacer("/", "_", "\\", "_", "\x00", "_").Replace(s)
+}
@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"encoding/json"
+	"funchooooza-ossh/loggo/core"
+	"testing"
+	"time"
+)
+
+func TestShardingWriterSplitsByField(t *testing.T) {
+	fs := newMemFS()
+	opened := 0
+	w := NewShardingWriter(
+		func(r core.LogRecord) string { s, _ := r.Fields["tenant"].(string); return s },
+		func(shard string) (*FileWriter, error) {
+			opened++
+			return NewFileWriterFS("/logs/"+shard+".json", 0, 0, "", nil, fs, nil)
+		},
+		1, // второй шард вытесняет первый
+	)
+	rec := func(tenant string) core.LogRecord {
+		return core.LogRecord{Level: core.Info, Fields: map[string]any{"tenant": tenant}}
+	}
+	for _, tc := range []struct{ tenant, line string }{
+		{"acme", "a1"}, {"globex", "g1"}, {"acme", "a2"}, {"../etc", "x"}, {"", "d"},
+	} {
+		if err := w.WriteRecord(rec(tc.tenant), []byte(tc.line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Write([]byte("plain")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"/logs/acme.json":    "a1\na2\n",
+		"/logs/globex.json":  "g1\n",
+		"/logs/.._etc.json":  "x\n",
+		"/logs/default.json": "d\nplain\n",
+	} {
+		if got, _ := fs.content(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	// при maxOpen 1 каждая смена шарда переоткрывает файл
+	if opened != 5 {
+		t.Errorf("opened %d writers, want 5", opened)
+	}
+}
+
+// Writer шарда получает запись целиком: в его индексе есть диапазон времени.
+func TestShardingWriterKeepsIndexTimes(t *testing.T) {
+	fs := newMemFS()
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)}
+	w := NewShardingWriter(
+		func(r core.LogRecord) string { s, _ := r.Fields["tenant"].(string); return s },
+		func(shard string) (*FileWriter, error) {
+			fw, err := NewFileWriterFS("/logs/"+shard+".log", 0, 0, RotateDaily, nil, fs, clock.Now)
+			if err == nil {
+				fw.Index = true
+			}
+			return fw, err
+		},
+		0,
+	)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	acme := map[string]any{"tenant": "acme"}
+	for _, off := range []time.Duration{time.Hour, 0} {
+		if err := w.WriteRecord(core.LogRecord{Timestamp: base.Add(off), Fields: acme}, []byte("r")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.Advance(3 * time.Hour)
+	if err := w.WriteRecord(core.LogRecord{Timestamp: clock.Now(), Fields: acme}, []byte("next")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, ok := fs.content("/logs/acme.log.2024-03-02T01-00-00.idx")
+	if !ok {
+		t.Fatal("no sidecar")
+	}
+	var ix fileIndex
+	if err := json.Unmarshal([]byte(raw), &ix); err != nil {
+		t.Fatalf("sidecar %q: %v", raw, err)
+	}
+	if !ix.From.Equal(base) || !ix.To.Equal(base.Add(time.Hour)) || ix.Count != 2 {
+		t.Errorf("index %+v", ix)
+	}
+}
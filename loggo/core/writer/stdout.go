@@ -22,3 +22,22 @@ func (w *StdoutWriter) Write(data []byte) error {
 func (w *StdoutWriter) Flush() error {
 	return nil
 }
+
+// StderrWriter пишет логи в стандартный поток ошибок.
+type StderrWriter struct{}
+
+// NewStderrWriter создаёт StderrWriter.
+func NewStderrWriter() *StderrWriter {
+	return &StderrWriter{}
+}
+
+// Write выводит отформатированные данные в stderr, добавляя перенос строки.
+func (w *StderrWriter) Write(data []byte) error {
+	_, err := os.Stderr.Write(append(data, '\n'))
+	return err
+}
+
+// Flush ничего не делает: stderr не буферизуется.
+func (w *StderrWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"sort"
+	"sync"
+	"time"
+)
+
+// timedWindow — сколько последних замеров хранится для перцентилей.
+const timedWindow = 1024
+
+// WriteLatencies — сводка по длительности записей во вложенный writer.
+// P50/P95 считаются по последним замерам (до 1024), Count и Max — за всё время.
+type WriteLatencies struct {
+	Count uint64
+	P50   time.Duration
+	P95   time.Duration
+	Max   time.Duration
+}
+
+// TimedWriter измеряет длительность каждого Write/WriteRecord вложенного
+// writer'а — чтобы заметить медленный диск. На запись — два time.Now и запись
+// в кольцевой буфер; сортировка происходит только в Latencies().
+type TimedWriter struct {
+	next core.WriteProcessor
+
+	mu      sync.Mutex
+	samples [timedWindow]time.Duration
+	pos     int
+	count   uint64
+	max     time.Duration
+}
+
+// NewTimedWriter оборачивает writer замером длительности записей.
+func NewTimedWriter(next core.WriteProcessor) *TimedWriter {
+	return &TimedWriter{next: next}
+}
+
+func (w *TimedWriter) Write(p []byte) error {
+	start := time.Now()
+	err := w.next.Write(p)
+	w.observe(time.Since(start))
+	return err
+}
+
+func (w *TimedWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	start := time.Now()
+	err := writeRecordTo(w.next, r, formatted)
+	w.observe(time.Since(start))
+	return err
+}
+
+func (w *TimedWriter) Flush() error {
+	if f, ok := w.next.(core.FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (w *TimedWriter) observe(d time.Duration) {
+	w.mu.Lock()
+	w.samples[w.pos] = d
+	w.pos = (w.pos + 1) % timedWindow
+	w.count++
+	if d > w.max {
+		w.max = d
+	}
+	w.mu.Unlock()
+}
+
+// Latencies возвращает текущую сводку; нулевая, если записей ещё не было.
+func (w *TimedWriter) Latencies() WriteLatencies {
+	w.mu.Lock()
+	n := min(w.count, timedWindow)
+	window := make([]time.Duration, n)
+	copy(window, w.samples[:n])
+	res := WriteLatencies{Count: w.count, Max: w.max}
+	w.mu.Unlock()
+
+	if n == 0 {
+		return res
+	}
+	sort.Slice(window, func(i, j int) bool { return window[i] < window[j] })
+	res.P50 = window[percentileIndex(len(window), 50)]
+	res.P95 = window[percentileIndex(len(window), 95)]
+	return res
+}
+
+// percentileIndex — индекс p-го перцентиля (nearest-rank) в отсортированном срезе.
+func percentileIndex(n, p int) int {
+	i := (n*p + 99) / 100
+	if i < 1 {
+		i = 1
+	}
+	return i - 1
+}
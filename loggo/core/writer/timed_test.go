@@ -0,0 +1,66 @@
+package writer
+
+import (
+	"funchooooza-ossh/loggo/core"
+	"testing"
+	"time"
+)
+
+// sleepWriter — recordWriter, каждая запись которого занимает delay
+// (строка "slow" — slowDelay).
+type sleepWriter struct {
+	recordWriter
+	delay, slowDelay time.Duration
+}
+
+func (w *sleepWriter) Write(p []byte) error {
+	if string(p) == "slow" {
+		time.Sleep(w.slowDelay)
+	} else {
+		time.Sleep(w.delay)
+	}
+	return w.recordWriter.Write(p)
+}
+
+func (w *sleepWriter) WriteRecord(r core.LogRecord, formatted []byte) error {
+	w.mu.Lock()
+	w.records = append(w.records, r)
+	w.mu.Unlock()
+	return w.Write(formatted)
+}
+
+func TestTimedWriterLatencies(t *testing.T) {
+	const fast, slow = 5 * time.Millisecond, 40 * time.Millisecond
+	next := &sleepWriter{delay: fast, slowDelay: slow}
+	w := NewTimedWriter(next)
+	if got := w.Latencies(); got != (WriteLatencies{}) {
+		t.Fatalf("before writes: %+v", got)
+	}
+
+	for i := 0; i < 19; i++ {
+		if err := w.Write([]byte("x")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.WriteRecord(core.LogRecord{Message: "slow"}, []byte("slow")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := w.Latencies()
+	if got.Count != 20 {
+		t.Errorf("Count = %d", got.Count)
+	}
+	// нижние границы точные (sleep не короче), верхние — с запасом на планировщик
+	if got.P50 < fast || got.P50 >= slow {
+		t.Errorf("P50 = %v, want in [%v, %v)", got.P50, fast, slow)
+	}
+	if got.P95 < fast || got.P95 > got.Max {
+		t.Errorf("P95 = %v", got.P95)
+	}
+	if got.Max < slow || got.Max > slow+time.Second {
+		t.Errorf("Max = %v, want about %v", got.Max, slow)
+	}
+	if len(next.Lines()) != 20 || len(next.records) != 1 {
+		t.Errorf("forwarded %d lines, %d records", len(next.Lines()), len(next.records))
+	}
+}
@@ -0,0 +1,135 @@
+package writer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// unixSocketBufSize — сколько байт кадров копится перед отправкой агенту.
+const unixSocketBufSize = 4096
+
+// UnixSocketWriter отправляет записи локальному агенту (sidecar) через
+// stream-сокет Unix. Каждая запись — кадр: 4 байта длины (big-endian) и
+// payload, так что агент собирает записи независимо от границ чтения.
+// Кадры копятся в буфере и отправляются при его заполнении и на Flush. При
+// ошибке отправки неотправленные кадры (в том числе оборванный на полпути)
+// повторяются целиком через новое соединение — агент мог перезапуститься; не
+// вышло и так — они отбрасываются, а ошибка и Dropped сообщают их число.
+type UnixSocketWriter struct {
+	path        string
+	dialTimeout time.Duration
+
+	mu      sync.Mutex
+	conn    net.Conn
+	pending []byte // кадры, ещё не отправленные целиком
+	frames  int    // число кадров в pending
+	dropped uint64
+}
+
+// NewUnixSocketWriter создаёт writer для сокета path. Подключение — лениво,
+// при первой отправке; dialTimeout == 0 — без таймаута.
+func NewUnixSocketWriter(path string, dialTimeout time.Duration) *UnixSocketWriter {
+	return &UnixSocketWriter{path: path, dialTimeout: dialTimeout}
+}
+
+func (w *UnixSocketWriter) Write(p []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = binary.BigEndian.AppendUint32(w.pending, uint32(len(p)))
+	w.pending = append(w.pending, p...)
+	w.frames++
+	if len(w.pending) < unixSocketBufSize {
+		return nil
+	}
+	return w.send()
+}
+
+// Flush отправляет буферизованные кадры агенту.
+func (w *UnixSocketWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.send()
+}
+
+// Close отправляет остаток буфера и закрывает соединение.
+func (w *UnixSocketWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.send()
+	if w.conn != nil {
+		if cerr := w.conn.Close(); err == nil {
+			err = cerr
+		}
+		w.conn = nil
+	}
+	return err
+}
+
+// Dropped — сколько записей отброшено после неудачной отправки.
+func (w *UnixSocketWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// send отправляет pending; при ошибке — ещё одна попытка через новое
+// соединение, затем кадры отбрасываются. Вызывать под mu.
+func (w *UnixSocketWriter) send() error {
+	var err error
+	for attempt := 0; attempt < 2 && len(w.pending) > 0; attempt++ {
+		if err = w.sendOnce(); err == nil {
+			return nil
+		}
+		w.closeConn()
+	}
+	if len(w.pending) == 0 {
+		return nil
+	}
+	n := w.frames
+	w.dropped += uint64(n)
+	w.pending, w.frames = w.pending[:0], 0
+	return fmt.Errorf("unix socket writer: %d records dropped: %w", n, err)
+}
+
+// sendOnce пишет pending в соединение, при необходимости подключаясь, и
+// убирает из него доставленные кадры. Вызывать под mu.
+func (w *UnixSocketWriter) sendOnce() error {
+	if w.conn == nil {
+		conn, err := net.DialTimeout("unix", w.path, w.dialTimeout)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	n, err := w.conn.Write(w.pending)
+	w.consume(n)
+	return err
+}
+
+// consume убирает из pending кадры, целиком вошедшие в первые n отправленных
+// байт. Оборванный кадр остаётся и при повторе уходит заново с начала.
+func (w *UnixSocketWriter) consume(n int) {
+	off := 0
+	for off+4 <= n {
+		end := off + 4 + int(binary.BigEndian.Uint32(w.pending[off:]))
+		if end > n {
+			break
+		}
+		off = end
+		w.frames--
+	}
+	w.pending = w.pending[:copy(w.pending, w.pending[off:])]
+}
+
+// closeConn закрывает соединение; кадры в pending сохраняются. Вызывать под mu.
+func (w *UnixSocketWriter) closeConn() {
+	if w.conn != nil {
+		_ = w.conn.Close()
+	}
+	w.conn = nil
+}
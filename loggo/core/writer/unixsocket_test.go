@@ -0,0 +1,175 @@
+package writer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// frameServer — тестовый агент: читает кадры [len u32][payload] со всех
+// соединений. Первое соединение закрывается после closeAfter кадров.
+type frameServer struct {
+	ln         net.Listener
+	closeAfter int
+	firstDone  chan struct{}
+
+	mu     sync.Mutex
+	frames []string
+	conns  int
+}
+
+func newFrameServer(t *testing.T, closeAfter int) (*frameServer, string) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "loggo-sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "agent.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &frameServer{ln: ln, closeAfter: closeAfter, firstDone: make(chan struct{})}
+	t.Cleanup(func() { ln.Close() })
+	go s.serve()
+	return s, path
+}
+
+func (s *frameServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		first := s.conns == 1
+		s.mu.Unlock()
+		go s.read(conn, first)
+	}
+}
+
+func (s *frameServer) read(conn net.Conn, first bool) {
+	defer conn.Close()
+	for n := 0; ; n++ {
+		if first && s.closeAfter > 0 && n == s.closeAfter {
+			conn.Close()
+			close(s.firstDone)
+			return
+		}
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.frames = append(s.frames, string(payload))
+		s.mu.Unlock()
+	}
+}
+
+func (s *frameServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.frames...)
+}
+
+func waitFrames(t *testing.T, s *frameServer, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got := s.received()
+		if len(got) >= n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUnixSocketWriterFrames(t *testing.T) {
+	srv, path := newFrameServer(t, 0)
+	w := NewUnixSocketWriter(path, time.Second)
+
+	want := []string{"first", "", "третья запись\nс переводом строки"}
+	for _, p := range want {
+		if err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := srv.received(); len(got) != 0 {
+		t.Fatalf("frames sent before Flush: %q", got)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := waitFrames(t, srv, len(want)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnixSocketWriterResendsBufferedAfterReconnect(t *testing.T) {
+	srv, path := newFrameServer(t, 2)
+	w := NewUnixSocketWriter(path, time.Second)
+	defer w.Close()
+
+	for _, p := range []string{"a", "b"} {
+		if err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	<-srv.firstDone // агент «перезапустился»: старое соединение закрыто
+
+	// эти кадры уже подтверждены Write'ом и не должны потеряться
+	for _, p := range []string{"c", "d"} {
+		if err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a", "b", "c", "d"}
+	if got := waitFrames(t, srv, len(want)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if n := w.Dropped(); n != 0 {
+		t.Fatalf("Dropped = %d, want 0", n)
+	}
+}
+
+func TestUnixSocketWriterReportsDropped(t *testing.T) {
+	srv, path := newFrameServer(t, 0)
+	w := NewUnixSocketWriter(path, time.Second)
+
+	for _, p := range []string{"x", "y", "z"} {
+		if err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.ln.Close() // агента больше нет: переподключиться некуда
+	os.Remove(path)
+
+	err := w.Flush()
+	if err == nil {
+		t.Fatal("Flush succeeded without an agent")
+	}
+	if n := w.Dropped(); n != 3 {
+		t.Fatalf("Dropped = %d, want 3 (err: %v)", n, err)
+	}
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+// levelSplitWriter раскладывает записи по уровню: Warning и выше — в high.
+type levelSplitWriter struct {
+	low, high *memWriter
+	plain     int // вызовы обычного Write
+}
+
+func (w *levelSplitWriter) Write(p []byte) error {
+	w.plain++
+	return w.low.Write(p)
+}
+
+func (w *levelSplitWriter) WriteRecord(r LogRecord, formatted []byte) error {
+	if r.Level >= Warning {
+		return w.high.Write(formatted)
+	}
+	return w.low.Write(formatted)
+}
+
+func TestRouteUsesWriteRecordWhenAvailable(t *testing.T) {
+	split := &levelSplitWriter{low: &memWriter{}, high: &memWriter{}}
+	plain := &plainWriter{}
+	l := NewLogger(
+		NewRouteProcessor(lineFormatter{}, split, Trace),
+		NewRouteProcessor(lineFormatter{}, plain, Trace),
+	)
+	l.Log(info("i"))
+	l.Log(LogRecordRaw{Level: Error, Message: []byte("e")})
+	l.Log(LogRecordRaw{Level: Debug, Message: []byte("d")})
+	l.Close()
+
+	if got := split.low.Lines(); !reflect.DeepEqual(got, []string{"i", "d"}) {
+		t.Errorf("low %q", got)
+	}
+	if got := split.high.Lines(); !reflect.DeepEqual(got, []string{"e"}) {
+		t.Errorf("high %q", got)
+	}
+	if split.plain != 0 {
+		t.Errorf("Write called %d times for a record-aware writer", split.plain)
+	}
+	// writer без WriteRecord получает те же байты через Write
+	if !reflect.DeepEqual(plain.lines, []string{"i", "e", "d"}) {
+		t.Errorf("plain %q", plain.lines)
+	}
+}
+
+// plainWriter — только WriteProcessor.
+type plainWriter struct{ lines []string }
+
+func (w *plainWriter) Write(p []byte) error {
+	w.lines = append(w.lines, string(p))
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"funchooooza-ossh/loggo/core/formatter"
 	"funchooooza-ossh/loggo/core/writer"
 	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -64,6 +65,51 @@ func NewRouteProcessor(formatterID, writerID C.uintptr_t, level C.uintptr_t) C.u
 	return C.uintptr_t(id)
 }
 
+//export NewSyncRouteProcessor
+func NewSyncRouteProcessor(formatterID, writerID C.uintptr_t, level C.uintptr_t) C.uintptr_t {
+	formatter := formatterStore[uintptr(formatterID)]
+	writer := writerStore[uintptr(writerID)]
+
+	route := core.NewSyncRouteProcessor(formatter, writer, core.LogLevel(level))
+	id := makeID()
+	routeStore[id] = route
+	return C.uintptr_t(id)
+}
+
+//export RouteProcessor_SetFlushMinLevel
+func RouteProcessor_SetFlushMinLevel(routeID C.uintptr_t, level C.uintptr_t) {
+	storeMu.Lock()
+	route := routeStore[uintptr(routeID)]
+	storeMu.Unlock()
+	if route == nil {
+		return
+	}
+	route.FlushMinLevel = core.LogLevel(level)
+}
+
+//export RouteProcessor_SetDrainTimeout
+func RouteProcessor_SetDrainTimeout(routeID C.uintptr_t, timeoutMs C.longlong) {
+	storeMu.Lock()
+	route := routeStore[uintptr(routeID)]
+	storeMu.Unlock()
+	if route == nil {
+		return
+	}
+	route.DrainTimeout = time.Duration(timeoutMs) * time.Millisecond
+}
+
+//export RouteProcessor_SetSpill
+func RouteProcessor_SetSpill(routeID C.uintptr_t, dir *C.char, maxBytes C.longlong) {
+	storeMu.Lock()
+	route := routeStore[uintptr(routeID)]
+	storeMu.Unlock()
+	if route == nil {
+		return
+	}
+	route.SpillDir = C.GoString(dir)
+	route.SpillMaxBytes = int64(maxBytes)
+}
+
 //export NewStdoutWriter
 func NewStdoutWriter() C.uintptr_t {
 	writer := &writer.StdoutWriter{}
@@ -72,6 +118,103 @@ func NewStdoutWriter() C.uintptr_t {
 	return C.uintptr_t(id)
 }
 
+//export WithStderrEcho
+func WithStderrEcho(writerID C.uintptr_t, minLevel C.int) C.uintptr_t {
+	storeMu.Lock()
+	w := writerStore[uintptr(writerID)]
+	storeMu.Unlock()
+	if w == nil {
+		return 0
+	}
+	echo := writer.WithStderrEcho(w, core.LogLevel(minLevel))
+	id := makeID()
+	writerStore[id] = echo
+	return C.uintptr_t(id)
+}
+
+//export NewQuietWriter
+func NewQuietWriter(writerID C.uintptr_t, threshold C.int, context C.int) C.uintptr_t {
+	storeMu.Lock()
+	w := writerStore[uintptr(writerID)]
+	storeMu.Unlock()
+	if w == nil {
+		return 0
+	}
+	quiet := writer.NewQuietWriter(w, core.LogLevel(threshold), int(context))
+	id := makeID()
+	writerStore[id] = quiet
+	return C.uintptr_t(id)
+}
+
+//export NewPrefixWriter
+func NewPrefixWriter(writerID C.uintptr_t, prefix *C.char, prefixLen C.size_t) C.uintptr_t {
+	storeMu.Lock()
+	w := writerStore[uintptr(writerID)]
+	storeMu.Unlock()
+	if w == nil {
+		return 0
+	}
+	var p []byte
+	if prefix != nil && prefixLen > 0 {
+		p = C.GoBytes(unsafe.Pointer(prefix), C.int(prefixLen))
+	}
+	prefixed := writer.NewPrefixWriter(w, p)
+	id := makeID()
+	writerStore[id] = prefixed
+	return C.uintptr_t(id)
+}
+
+//export NewMaxLineWriter
+func NewMaxLineWriter(writerID C.uintptr_t, maxBytes C.int) C.uintptr_t {
+	storeMu.Lock()
+	w := writerStore[uintptr(writerID)]
+	storeMu.Unlock()
+	if w == nil {
+		return 0
+	}
+	limited := writer.NewMaxLineWriter(w, int(maxBytes))
+	id := makeID()
+	writerStore[id] = limited
+	return C.uintptr_t(id)
+}
+
+//export NewChunkWriter
+func NewChunkWriter(writerID C.uintptr_t, maxBytes C.int) C.uintptr_t {
+	storeMu.Lock()
+	w := writerStore[uintptr(writerID)]
+	storeMu.Unlock()
+	if w == nil {
+		return 0
+	}
+	chunked := writer.NewChunkWriter(w, int(maxBytes))
+	id := makeID()
+	writerStore[id] = chunked
+	return C.uintptr_t(id)
+}
+
+//export NewFallbackWriter
+func NewFallbackWriter(primaryID C.uintptr_t, secondaryID C.uintptr_t) C.uintptr_t {
+	storeMu.Lock()
+	primary := writerStore[uintptr(primaryID)]
+	secondary := writerStore[uintptr(secondaryID)]
+	storeMu.Unlock()
+	if primary == nil || secondary == nil {
+		return 0
+	}
+	fw := writer.NewFallbackWriter(primary, secondary)
+	id := makeID()
+	writerStore[id] = fw
+	return C.uintptr_t(id)
+}
+
+//export NewUnixSocketWriter
+func NewUnixSocketWriter(path *C.char, dialTimeoutMs C.longlong) C.uintptr_t {
+	w := writer.NewUnixSocketWriter(C.GoString(path), time.Duration(dialTimeoutMs)*time.Millisecond)
+	id := makeID()
+	writerStore[id] = w
+	return C.uintptr_t(id)
+}
+
 //export NewFileWriter
 func NewFileWriter(path *C.char, maxSizeMB C.long, maxBackups C.int, interval *C.char, compress *C.char) C.uintptr_t {
 	goPath := C.GoString(path)
@@ -99,6 +242,73 @@ func NewFileWriter(path *C.char, maxSizeMB C.long, maxBackups C.int, interval *C
 	return C.uintptr_t(id)
 }
 
+//export NewDatedFileWriter
+func NewDatedFileWriter(pattern *C.char, maxSizeMB C.long, maxBackups C.int, compress *C.char) C.uintptr_t {
+	var goCompress *writer.Compress
+	if compress != nil {
+		c := writer.Compress(C.GoString(compress))
+		goCompress = &c
+	}
+
+	fw, err := writer.NewDatedFileWriter(C.GoString(pattern), int64(maxSizeMB), int(maxBackups), goCompress)
+	if err != nil {
+		return 0
+	}
+
+	id := makeID()
+	writerStore[id] = fw
+	return C.uintptr_t(id)
+}
+
+//export NewFileWriterExt
+func NewFileWriterExt(path *C.char, maxSizeMB C.long, maxBackups C.int, interval *C.char, backupExt *C.char, compress *C.char) C.uintptr_t {
+	var goCompress *writer.Compress
+	if compress != nil {
+		c := writer.Compress(C.GoString(compress))
+		goCompress = &c
+	}
+
+	fw, err := writer.NewFileWriterExt(
+		C.GoString(path),
+		int64(maxSizeMB),
+		int(maxBackups),
+		writer.RotateInterval(C.GoString(interval)),
+		C.GoString(backupExt),
+		goCompress,
+	)
+	if err != nil {
+		return 0
+	}
+
+	id := makeID()
+	writerStore[id] = fw
+	return C.uintptr_t(id)
+}
+
+//export FileWriter_SetIndex
+func FileWriter_SetIndex(writerID C.uintptr_t, enabled C.int) {
+	storeMu.Lock()
+	w := writerStore[uintptr(writerID)]
+	storeMu.Unlock()
+	if fw, ok := w.(*writer.FileWriter); ok {
+		fw.Index = enabled != 0
+	}
+}
+
+//export FileWriter_SetHeader
+func FileWriter_SetHeader(writerID C.uintptr_t, header *C.char) {
+	storeMu.Lock()
+	w := writerStore[uintptr(writerID)]
+	storeMu.Unlock()
+	if fw, ok := w.(*writer.FileWriter); ok {
+		if header == nil {
+			fw.Header = nil
+			return
+		}
+		fw.Header = []byte(C.GoString(header))
+	}
+}
+
 //export NewTextFormatter
 func NewTextFormatter(styleID C.uintptr_t, maxDepth C.int) C.uintptr_t {
 	var style *core.FormatStyle
@@ -125,6 +335,62 @@ func NewJsonFormatter(styleId C.uintptr_t, maxDepth C.int) C.uintptr_t {
 	return C.uintptr_t(id)
 }
 
+//export NewMsgpackFormatter
+func NewMsgpackFormatter(maxDepth C.int, levelAsInt C.int) C.uintptr_t {
+	depth := int(maxDepth)
+	f := formatter.NewMsgpackFormatter(&depth)
+	f.LevelAsInt = levelAsInt != 0
+	id := makeID()
+	formatterStore[id] = f
+	return C.uintptr_t(id)
+}
+
+//export NewDebugFormatter
+func NewDebugFormatter(maxDepth C.int, showUnexported C.int) C.uintptr_t {
+	depth := int(maxDepth)
+	formatter := formatter.NewDebugFormatter(&depth, showUnexported != 0)
+	id := makeID()
+	formatterStore[id] = formatter
+	return C.uintptr_t(id)
+}
+
+//export JsonFormatter_SetFieldsKey
+func JsonFormatter_SetFieldsKey(formatterID C.uintptr_t, key *C.char) {
+	storeMu.Lock()
+	f, ok := formatterStore[uintptr(formatterID)].(*formatter.JsonFormatter)
+	storeMu.Unlock()
+	if !ok {
+		return
+	}
+	f.FieldsKey = C.GoString(key)
+}
+
+//export Formatter_SetTimePrecision
+func Formatter_SetTimePrecision(formatterID C.uintptr_t, precisionNs C.longlong) {
+	storeMu.Lock()
+	f := formatterStore[uintptr(formatterID)]
+	storeMu.Unlock()
+
+	precision := time.Duration(precisionNs)
+	switch f := f.(type) {
+	case *formatter.JsonFormatter:
+		f.TimePrecision = precision
+	case *formatter.TextFormatter:
+		f.TimePrecision = precision
+	}
+}
+
+//export TextFormatter_SetShortTimestamp
+func TextFormatter_SetShortTimestamp(formatterID C.uintptr_t, enabled C.uintptr_t) {
+	storeMu.Lock()
+	f, ok := formatterStore[uintptr(formatterID)].(*formatter.TextFormatter)
+	storeMu.Unlock()
+	if !ok {
+		return
+	}
+	f.ShortTimestamp = enabled != 0
+}
+
 //export NewFormatStyle
 func NewFormatStyle(colorKeys, colorValues, colorLevel C.uintptr_t, keyColor, valueColor, reset *C.char) C.uintptr_t {
 	style := &core.FormatStyle{
@@ -140,6 +406,42 @@ func NewFormatStyle(colorKeys, colorValues, colorLevel C.uintptr_t, keyColor, va
 	return C.uintptr_t(id)
 }
 
+//export FormatStyle_SetTokens
+func FormatStyle_SetTokens(styleID C.uintptr_t, trueToken, falseToken, nullToken *C.char) {
+	storeMu.Lock()
+	style := formatStyleStore[uintptr(styleID)]
+	storeMu.Unlock()
+	if style == nil {
+		return
+	}
+	style.TrueToken = C.GoString(trueToken)
+	style.FalseToken = C.GoString(falseToken)
+	style.NullToken = C.GoString(nullToken)
+}
+
+//export FormatStyle_SetHumanizeNumbers
+func FormatStyle_SetHumanizeNumbers(styleID C.uintptr_t, enabled C.uintptr_t, threshold C.ulonglong) {
+	storeMu.Lock()
+	style := formatStyleStore[uintptr(styleID)]
+	storeMu.Unlock()
+	if style == nil {
+		return
+	}
+	style.HumanizeNumbers = enabled != 0
+	style.HumanizeThreshold = uint64(threshold)
+}
+
+//export FormatStyle_SetColorWholeLine
+func FormatStyle_SetColorWholeLine(styleID C.uintptr_t, enabled C.uintptr_t) {
+	storeMu.Lock()
+	style := formatStyleStore[uintptr(styleID)]
+	storeMu.Unlock()
+	if style == nil {
+		return
+	}
+	style.ColorWholeLine = enabled != 0
+}
+
 //export NewLoggerWithSingleRoute
 func NewLoggerWithSingleRoute(routeID C.uintptr_t) C.uintptr_t {
 	storeMu.Lock()
@@ -166,7 +468,6 @@ func LogN(loggerId C.uintptr_t, level core.LogLevel,
 	if !lg.AnyRouteShouldLog(level) {
 		return
 	}
-	rts := lg.RoutesSnapshot()
 
 	var goMsg []byte
 	if msg != nil && msgLen > 0 {
@@ -177,18 +478,74 @@ func LogN(loggerId C.uintptr_t, level core.LogLevel,
 		fieldsRaw = C.GoBytes(unsafe.Pointer(fieldsJSON), C.int(fieldsLen))
 	}
 
-	record := core.LogRecordRaw{
+	lg.Log(core.LogRecordRaw{
 		Level:   level,
 		Message: goMsg,
 		Fields:  fieldsRaw,
+	})
+}
+
+//export Logger_LogAt
+func Logger_LogAt(loggerId C.uintptr_t, level C.int, tsUnixNano C.longlong,
+	msg *C.char, msgLen C.size_t,
+	fieldsJSON *C.char, fieldsLen C.size_t,
+) {
+	storeMu.Lock()
+	lg := loggerStore[uintptr(loggerId)]
+	storeMu.Unlock()
+	if lg == nil || !lg.AnyRouteShouldLog(core.LogLevel(level)) {
+		return
 	}
 
-	for _, r := range rts {
-		if r != nil && r.ShouldLog(level) {
-			r.Enqueue(record)
-		}
+	var goMsg []byte
+	if msg != nil && msgLen > 0 {
+		goMsg = C.GoBytes(unsafe.Pointer(msg), C.int(msgLen))
+	}
+	var fieldsRaw []byte
+	if fieldsJSON != nil && fieldsLen > 0 {
+		fieldsRaw = C.GoBytes(unsafe.Pointer(fieldsJSON), C.int(fieldsLen))
+	}
+
+	// 0 — нулевое время: конвейер подставит текущее, а не 1970-01-01
+	var ts time.Time
+	if tsUnixNano != 0 {
+		ts = time.Unix(0, int64(tsUnixNano))
+	}
+	lg.Log(core.LogRecordRaw{
+		Level:     core.LogLevel(level),
+		Timestamp: ts,
+		Message:   goMsg,
+		Fields:    fieldsRaw,
+	})
+}
+
+//export Logger_LogCaller
+func Logger_LogCaller(loggerId C.uintptr_t, level C.int, caller *C.char,
+	msg *C.char, msgLen C.size_t,
+	fieldsJSON *C.char, fieldsLen C.size_t,
+) {
+	storeMu.Lock()
+	lg := loggerStore[uintptr(loggerId)]
+	storeMu.Unlock()
+	if lg == nil || !lg.AnyRouteShouldLog(core.LogLevel(level)) {
+		return
+	}
+
+	var goMsg []byte
+	if msg != nil && msgLen > 0 {
+		goMsg = C.GoBytes(unsafe.Pointer(msg), C.int(msgLen))
+	}
+	var fieldsRaw []byte
+	if fieldsJSON != nil && fieldsLen > 0 {
+		fieldsRaw = C.GoBytes(unsafe.Pointer(fieldsJSON), C.int(fieldsLen))
 	}
 
+	lg.Log(core.LogRecordRaw{
+		Level:   core.LogLevel(level),
+		Message: goMsg,
+		Fields:  fieldsRaw,
+		Caller:  C.GoString(caller),
+	})
 }
 
 //export Logger_Trace
@@ -227,6 +584,28 @@ func Logger_Exception(loggerId C.uintptr_t, msg *C.char, msgLen C.size_t,
 	LogN(loggerId, core.Exception, msg, msgLen, fields, fieldsLen)
 }
 
+//export Logger_EnableSeq
+func Logger_EnableSeq(loggerID C.uintptr_t, enabled C.int) {
+	storeMu.Lock()
+	logger := loggerStore[uintptr(loggerID)]
+	storeMu.Unlock()
+	if logger == nil {
+		return
+	}
+	logger.EnableSeq(enabled != 0)
+}
+
+//export Logger_EnableUptime
+func Logger_EnableUptime(loggerID C.uintptr_t, enabled C.int) {
+	storeMu.Lock()
+	logger := loggerStore[uintptr(loggerID)]
+	storeMu.Unlock()
+	if logger == nil {
+		return
+	}
+	logger.EnableUptime(enabled != 0)
+}
+
 //export FreeLogger
 func FreeLogger(loggerID C.uintptr_t) {
 	storeMu.Lock()